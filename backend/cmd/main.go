@@ -6,6 +6,10 @@ import (
 	"backend/internal/routes"
 	"backend/internal/controllers"
 	"backend/internal/repository"
+	adminRepo "backend/internal/repository/admin"
+	customerRepo "backend/internal/repository/customer"
+	"backend/internal/search"
+	"backend/internal/service"
 	"log"
 	"net/http"
 	"os"
@@ -19,13 +23,87 @@ func main() {
 	
 	configs.ConnectDatabase()
 
-	if err := configs.DB.AutoMigrate(&models.User{}); err != nil {
+	// quyền mặc định của staff chỉ seed khi bảng role_permissions được tạo lần đầu
+	seedPermissions := !repository.RolePermissionsTableExists()
+	if err := configs.DB.AutoMigrate(
+		&models.User{},
+		&models.RolePermission{},
+		&models.RefreshToken{},
+		&models.PasswordResetToken{},
+		&models.PendingRegistration{},
+		&models.UserMFA{},
+		&models.MFARecoveryCode{},
+		&models.RoleMFAPolicy{},
+		&models.UserIdentity{},
+		&models.EmailChangeRequest{},
+		&models.AuditLog{},
+		&models.Category{},
+		&models.SlugHistory{},
+		&models.SearchLog{},
+		&models.SearchSynonym{},
+		&models.ProductRecommendation{},
+		&models.ProductImage{},
+		&models.Attribute{},
+		&models.AttributeValue{},
+		&models.CategoryAttribute{},
+		&models.ProductAttributeValue{},
+		&models.SizeOption{},
+		&models.ColorOption{},
+		&models.ProductVariant{},
+		&models.Review{},
+		&models.ReviewImage{},
+		&models.ReviewReply{},
+		&models.ReviewVote{},
+		&models.WishlistItem{},
+		&models.StockSubscription{},
+		&models.GuestSession{},
+		&models.CartItem{},
+	); err != nil {
 		log.Fatal("Migration failed:", err)
 	}
+	if seedPermissions {
+		if err := repository.SeedRolePermissions(); err != nil {
+			log.Println("Seed role permissions failed:", err)
+		}
+	}
+	if err := service.LoadPermissions(); err != nil {
+		log.Println("Load role permissions failed:", err)
+	}
+	if err := repository.SeedMFAPolicy(); err != nil {
+		log.Println("Seed MFA policy failed:", err)
+	}
+	if err := adminRepo.MigrateProductRatingColumns(); err != nil {
+		log.Println("Add product rating columns failed:", err)
+	}
+	if err := customerRepo.MigrateGuestCheckout(); err != nil {
+		log.Println("Migrate guest checkout columns failed:", err)
+	}
+	if err := adminRepo.MigrateSupplierSoftDelete(); err != nil {
+		log.Println("Migrate supplier soft delete failed:", err)
+	}
+	if err := adminRepo.MigrateCategoryGroups(); err != nil {
+		log.Println("Migrate category groups failed:", err)
+	}
+	if err := adminRepo.BackfillVariantOptions(); err != nil {
+		log.Println("Backfill size/color options failed:", err)
+	}
+	if err := search.Rebuild(); err != nil {
+		log.Println("Build search index failed:", err)
+	}
+	if err := search.LoadSynonyms(); err != nil {
+		log.Println("Load search synonyms failed:", err)
+	}
+	service.StartRecommendationJob()
+	service.StartBestSellerJob()
 
 
 	msgRepo := repository.NewMessageRepo(configs.DB)
 	chatHandler := controllers.NewChatHandler(msgRepo)
+	service.SetCustomerPusher(chatHandler)
+	service.StartBackInStockJob()
+	service.StartGuestCartCleanupJob()
+	service.StartAuthTokenCleanupJob()
+	service.StartAuditCleanupJob()
 	msgController := controllers.NewMessageController(msgRepo)
 
 	r := mux.NewRouter()
@@ -33,11 +111,18 @@ func main() {
 	routes.SetupRoutes(r, chatHandler, msgController)
 	routes.SetupAdminRoutes(r)
     routes.SetupCustomerRoutes(r)
+
+	// Ảnh upload khi dùng STORAGE_DRIVER=local (chỉ trả file, không liệt kê thư mục)
+	uploadDir := os.Getenv("UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "uploads"
+	}
+	r.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(service.UploadFileSystem(uploadDir))))
 	
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5173"}, 
 		AllowedMethods:   []string{"GET", "POST", "PUT","PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Origin", "Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Origin", "Content-Type", "Authorization", "X-Guest-Token"},
 		ExposedHeaders:   []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           int((12 * time.Hour).Seconds()),
@@ -28,7 +28,10 @@ func ConnectDatabase() {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		user, password, host, port, name)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// Không tạo FK khi AutoMigrate: dữ liệu cũ (order_items, inventory_logs...) có thể trỏ tới bản ghi đã xoá
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
 	if err != nil {
 		log.Fatal("❌ Failed to connect database:", err)
 	}
@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/middlewares"
+	adminRepo "backend/internal/repository/admin"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// writeArchiveError: không tìm thấy → 404, vướng ràng buộc lưu trữ → 409
+func writeArchiveError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, adminRepo.ErrCategoryInUse),
+		errors.Is(err, adminRepo.ErrParentArchived),
+		errors.Is(err, adminRepo.ErrLastAdmin):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+// writeDeleteResult: archived = chỉ lưu trữ (còn dữ liệu lịch sử tham chiếu), khôi phục được
+func writeDeleteResult(w http.ResponseWriter, entity string, archived bool) {
+	msg := entity + " deleted successfully"
+	if archived {
+		msg = entity + " archived (still referenced by history), restore it from the archive"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": msg, "archived": archived})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// GET /api/admin/users/archived
+func GetArchivedUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := adminRepo.GetArchivedUsers()
+	if err != nil {
+		http.Error(w, "Failed to fetch archived users", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, users)
+}
+
+// GET /api/admin/products/archived
+func GetArchivedProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := adminRepo.GetArchivedProducts()
+	if err != nil {
+		http.Error(w, "Failed to fetch archived products", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, products)
+}
+
+// GET /api/admin/products/{id}/variants/archived
+func GetArchivedVariants(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	variants, err := adminRepo.GetArchivedVariants(uint(id))
+	if err != nil {
+		http.Error(w, "Failed to fetch archived variants", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, variants)
+}
+
+// GET /api/admin/categories/archived
+func GetArchivedCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := adminRepo.GetArchivedCategories()
+	if err != nil {
+		http.Error(w, "Failed to fetch archived categories", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, categories)
+}
+
+// GET /api/admin/suppliers/archived
+func GetArchivedSuppliers(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := adminRepo.GetArchivedSuppliers()
+	if err != nil {
+		http.Error(w, "Failed to fetch archived suppliers", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, suppliers)
+}
+
+// POST /api/admin/users/{id}/restore
+func RestoreUser(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if err := adminRepo.RestoreUser(uint(id)); err != nil {
+		writeArchiveError(w, err, "Failed to restore user")
+		return
+	}
+	writeJSON(w, map[string]string{"message": "User restored successfully"})
+}
+
+// POST /api/admin/products/{id}/restore — khôi phục cả các variant bị lưu trữ cùng sản phẩm
+func RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if err := adminRepo.RestoreProduct(uint(id)); err != nil {
+		writeArchiveError(w, err, "Failed to restore product")
+		return
+	}
+	writeJSON(w, map[string]string{"message": "Product restored successfully"})
+}
+
+// POST /api/admin/products/{id}/variants/{variantId}/restore
+func RestoreVariant(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := strconv.Atoi(vars["id"])
+	variantID, _ := strconv.Atoi(vars["variantId"])
+	if err := adminRepo.RestoreVariant(uint(id), uint(variantID)); err != nil {
+		writeArchiveError(w, err, "Failed to restore variant")
+		return
+	}
+	writeJSON(w, map[string]string{"message": "Variant restored successfully"})
+}
+
+// POST /api/admin/categories/{id}/restore
+func RestoreCategory(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if err := adminRepo.RestoreCategory(uint(id)); err != nil {
+		writeArchiveError(w, err, "Failed to restore category")
+		return
+	}
+	writeJSON(w, map[string]string{"message": "Category restored successfully"})
+}
+
+// POST /api/admin/suppliers/{id}/restore
+func RestoreSupplier(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if err := adminRepo.RestoreSupplier(uint(id)); err != nil {
+		writeArchiveError(w, err, "Failed to restore supplier")
+		return
+	}
+	writeJSON(w, map[string]string{"message": "Supplier restored successfully"})
+}
+
+// isSelf: admin không được tự xoá tài khoản đang đăng nhập
+func isSelf(r *http.Request, id uint) bool {
+	claims := middlewares.GetUserFromContext(r)
+	return claims != nil && claims.UserID == id
+}
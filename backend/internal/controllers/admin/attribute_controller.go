@@ -0,0 +1,339 @@
+package admin
+
+import (
+	"backend/internal/models"
+	admin "backend/internal/repository/admin"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ================= ATTRIBUTES ==================
+
+// GET /api/admin/attributes
+func GetAllAttributes(w http.ResponseWriter, r *http.Request) {
+	attrs, err := admin.GetAllAttributes()
+	if err != nil {
+		http.Error(w, "Failed to fetch attributes", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": attrs})
+}
+
+// POST /api/admin/attributes  body: {"code":"material","name":"Chất liệu","type":"single","values":[{"value":"Cotton"}]}
+func CreateAttribute(w http.ResponseWriter, r *http.Request) {
+	var req models.Attribute
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	attr, err := admin.CreateAttribute(&req)
+	if err != nil {
+		http.Error(w, "Failed to create attribute: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attr)
+}
+
+// PUT /api/admin/attributes/{id}
+func EditAttribute(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid attribute ID", http.StatusBadRequest)
+		return
+	}
+	var req models.Attribute
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	attr, err := admin.UpdateAttribute(uint(id), &req)
+	if err != nil {
+		http.Error(w, "Failed to update attribute", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attr)
+}
+
+// DELETE /api/admin/attributes/{id}
+func DeleteAttribute(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid attribute ID", http.StatusBadRequest)
+		return
+	}
+	if err := admin.DeleteAttribute(uint(id)); err != nil {
+		http.Error(w, "Failed to delete attribute", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Attribute deleted successfully"})
+}
+
+// POST /api/admin/attributes/{id}/values
+func CreateAttributeValue(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid attribute ID", http.StatusBadRequest)
+		return
+	}
+	var req models.AttributeValue
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	value, err := admin.CreateAttributeValue(uint(id), &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(value)
+}
+
+// PUT /api/admin/attributes/{id}/values/{valueId}
+func EditAttributeValue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid attribute ID", http.StatusBadRequest)
+		return
+	}
+	valueID, err := strconv.Atoi(vars["valueId"])
+	if err != nil {
+		http.Error(w, "Invalid value ID", http.StatusBadRequest)
+		return
+	}
+	var req models.AttributeValue
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	value, err := admin.UpdateAttributeValue(uint(id), uint(valueID), &req)
+	if err != nil {
+		http.Error(w, "Failed to update value", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// DELETE /api/admin/attributes/{id}/values/{valueId}
+func DeleteAttributeValue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid attribute ID", http.StatusBadRequest)
+		return
+	}
+	valueID, err := strconv.Atoi(vars["valueId"])
+	if err != nil {
+		http.Error(w, "Invalid value ID", http.StatusBadRequest)
+		return
+	}
+	if err := admin.DeleteAttributeValue(uint(id), uint(valueID)); err != nil {
+		http.Error(w, "Failed to delete value", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Value deleted successfully"})
+}
+
+// GET /api/admin/categories/{id}/attributes
+func GetCategoryAttributes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+	attrs, err := admin.GetCategoryAttributes(uint(id))
+	if err != nil {
+		http.Error(w, "Failed to fetch attributes", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": attrs})
+}
+
+// PUT /api/admin/categories/{id}/attributes  body: {"attribute_ids":[1,2]}
+func SetCategoryAttributes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		AttributeIDs []uint `json:"attribute_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	attrs, err := admin.SetCategoryAttributes(uint(id), body.AttributeIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": attrs})
+}
+
+// PUT /api/admin/products/{id}/attributes  body: {"value_ids":[3,7]}
+func SetProductAttributes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		ValueIDs []uint `json:"value_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	values, err := admin.SetProductAttributes(uint(id), body.ValueIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": values})
+}
+
+// ================= SIZE / COLOR OPTIONS ==================
+
+// GET /api/admin/sizes
+func GetSizeOptions(w http.ResponseWriter, r *http.Request) {
+	sizes, err := admin.GetSizeOptions()
+	if err != nil {
+		http.Error(w, "Failed to fetch sizes", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": sizes})
+}
+
+// POST /api/admin/sizes
+func CreateSizeOption(w http.ResponseWriter, r *http.Request) {
+	var req models.SizeOption
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	size, err := admin.CreateSizeOption(&req)
+	if err != nil {
+		http.Error(w, "Failed to create size: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(size)
+}
+
+// PUT /api/admin/sizes/{id}
+func EditSizeOption(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid size ID", http.StatusBadRequest)
+		return
+	}
+	var req models.SizeOption
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	size, err := admin.UpdateSizeOption(uint(id), &req)
+	if err != nil {
+		http.Error(w, "Failed to update size", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(size)
+}
+
+// DELETE /api/admin/sizes/{id}
+func DeleteSizeOption(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid size ID", http.StatusBadRequest)
+		return
+	}
+	if err := admin.DeleteSizeOption(uint(id)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Size deleted successfully"})
+}
+
+// GET /api/admin/colors
+func GetColorOptions(w http.ResponseWriter, r *http.Request) {
+	colors, err := admin.GetColorOptions()
+	if err != nil {
+		http.Error(w, "Failed to fetch colors", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": colors})
+}
+
+// POST /api/admin/colors
+func CreateColorOption(w http.ResponseWriter, r *http.Request) {
+	var req models.ColorOption
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	color, err := admin.CreateColorOption(&req)
+	if err != nil {
+		http.Error(w, "Failed to create color: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(color)
+}
+
+// PUT /api/admin/colors/{id}
+func EditColorOption(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid color ID", http.StatusBadRequest)
+		return
+	}
+	var req models.ColorOption
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	color, err := admin.UpdateColorOption(uint(id), &req)
+	if err != nil {
+		http.Error(w, "Failed to update color", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(color)
+}
+
+// DELETE /api/admin/colors/{id}
+func DeleteColorOption(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid color ID", http.StatusBadRequest)
+		return
+	}
+	if err := admin.DeleteColorOption(uint(id)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Color deleted successfully"})
+}
@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	adminRepo "backend/internal/repository/admin"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GET /api/admin/audit-logs?actor_id=&action=&entity_type=&entity_id=&ip=&q=&from=YYYY-MM-DD&to=YYYY-MM-DD&page=&limit=
+func GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dates, err := parseExportFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f := adminRepo.AuditLogFilter{
+		Action:     q.Get("action"),
+		EntityType: q.Get("entity_type"),
+		EntityID:   q.Get("entity_id"),
+		IP:         q.Get("ip"),
+		Query:      q.Get("q"),
+		From:       dates.From,
+		To:         dates.To,
+		Page:       1,
+		Limit:      50,
+	}
+	if val, err := strconv.Atoi(q.Get("actor_id")); err == nil && val > 0 {
+		f.ActorID = uint(val)
+	}
+	if val, err := strconv.Atoi(q.Get("page")); err == nil && val > 0 {
+		f.Page = val
+	}
+	if val, err := strconv.Atoi(q.Get("limit")); err == nil && val > 0 && val <= 200 {
+		f.Limit = val
+	}
+
+	logs, total, err := adminRepo.GetAuditLogs(f)
+	if err != nil {
+		http.Error(w, "Failed to fetch audit logs", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  logs,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// GET /api/admin/audit-logs/{id}
+func GetAuditLogDetail(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	entry, err := adminRepo.GetAuditLog(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Audit log not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch audit log", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
@@ -21,6 +21,40 @@ func GetAllCategories(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{"data": cats})
 }
 
+// GET /api/admin/categories/tree
+func GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := admin.GetCategoryTree()
+	if err != nil {
+		http.Error(w, "Failed to fetch categories", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": tree})
+}
+
+// PUT /api/admin/categories/reorder  body: {"parent_id": null, "category_ids": [3,1,2]}
+func ReorderCategories(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ParentID    *uint  `json:"parent_id"`
+		CategoryIDs []uint `json:"category_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.CategoryIDs) == 0 {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := admin.ReorderCategories(req.ParentID, req.CategoryIDs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tree, err := admin.GetCategoryTree()
+	if err != nil {
+		http.Error(w, "Failed to fetch categories", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": tree})
+}
+
 // GET /api/admin/categories/{id}
 func GetCategoryDetail(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -44,8 +78,8 @@ func GetCategoryDetail(w http.ResponseWriter, r *http.Request) {
 // POST /api/admin/categories
 func CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name      string `json:"name"`
-		GroupName string `json:"group_name"`
+		Name     string `json:"name"`
+		ParentID *uint  `json:"parent_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
@@ -53,12 +87,12 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	category := models.Category{
-		Name:      req.Name,
-		GroupName: req.GroupName,
+		Name:     req.Name,
+		ParentID: req.ParentID,
 	}
 	created, err := admin.CreateCategory(&category)
 	if err != nil {
-		http.Error(w, "Failed to create category", http.StatusInternalServerError)
+		http.Error(w, "Failed to create category: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -78,8 +112,8 @@ func EditCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Name      string `json:"name"`
-		GroupName string `json:"group_name"`
+		Name     string `json:"name"`
+		ParentID *uint  `json:"parent_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
@@ -87,11 +121,11 @@ func EditCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	updated, err := admin.UpdateCategory(uint(id), &models.Category{
-		Name:      req.Name,
-		GroupName: req.GroupName,
+		Name:     req.Name,
+		ParentID: req.ParentID,
 	})
 	if err != nil {
-		http.Error(w, "Failed to update category", http.StatusInternalServerError)
+		http.Error(w, "Failed to update category: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -110,11 +144,10 @@ func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := admin.DeleteCategory(uint(id)); err != nil {
-		http.Error(w, "Failed to delete category", http.StatusInternalServerError)
+	archived, err := admin.DeleteCategory(uint(id))
+	if err != nil {
+		writeArchiveError(w, err, "Failed to delete category")
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Category deleted successfully"})
+	writeDeleteResult(w, "Category", archived)
 }
@@ -0,0 +1,113 @@
+package admin
+
+import (
+	admin "backend/internal/repository/admin"
+	"backend/internal/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+type exportSource struct {
+	name    string
+	headers []string
+	run     func(admin.ExportFilter, admin.ExportRowFunc) error
+}
+
+// parseExportFilter đọc ?from=YYYY-MM-DD&to=YYYY-MM-DD&status=...; to tính cả ngày đó
+func parseExportFilter(r *http.Request) (admin.ExportFilter, error) {
+	var f admin.ExportFilter
+	q := r.URL.Query()
+	if s := q.Get("from"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("invalid from date")
+		}
+		f.From = &t
+	}
+	if s := q.Get("to"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("invalid to date")
+		}
+		t = t.AddDate(0, 0, 1)
+		f.To = &t
+	}
+	f.Status = q.Get("status")
+	return f, nil
+}
+
+func serveExport(w http.ResponseWriter, r *http.Request, src exportSource) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = utils.ExportCSV
+	}
+	if format != utils.ExportCSV && format != utils.ExportXLSX && format != utils.ExportNDJSON {
+		http.Error(w, "Invalid format (csv, xlsx, ndjson)", http.StatusBadRequest)
+		return
+	}
+	filter, err := parseExportFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ext := format
+	if format == utils.ExportNDJSON {
+		ext = "jsonl"
+	}
+	filename := fmt.Sprintf("%s-%s.%s", src.name, time.Now().Format("20060102-150405"), ext)
+	w.Header().Set("Content-Type", utils.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	writer, err := utils.NewRowWriter(w, format, src.headers)
+	if err != nil {
+		http.Error(w, "Failed to start export", http.StatusInternalServerError)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	err = src.run(filter, func(values []interface{}) error {
+		if err := writer.WriteRow(values); err != nil {
+			return err
+		}
+		count++
+		if flusher != nil && count%500 == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// header đã gửi, chỉ log lại
+		log.Printf("Export %s failed after %d rows: %v", src.name, count, err)
+	}
+	if err := writer.Close(); err != nil {
+		log.Printf("Export %s close error: %v", src.name, err)
+	}
+}
+
+// GET /api/admin/export/products?format=csv|xlsx|ndjson&from=&to=
+func ExportProducts(w http.ResponseWriter, r *http.Request) {
+	serveExport(w, r, exportSource{"products", admin.ProductExportHeaders, admin.ExportProducts})
+}
+
+// GET /api/admin/export/orders?format=&from=&to=&status=
+func ExportOrders(w http.ResponseWriter, r *http.Request) {
+	serveExport(w, r, exportSource{"orders", admin.OrderExportHeaders, admin.ExportOrders})
+}
+
+// GET /api/admin/export/purchases?format=&from=&to=
+func ExportPurchases(w http.ResponseWriter, r *http.Request) {
+	serveExport(w, r, exportSource{"purchases", admin.PurchaseExportHeaders, admin.ExportPurchases})
+}
+
+// GET /api/admin/export/inventory_logs?format=&from=&to=&status=import|sale|return|adjust
+func ExportInventoryLogs(w http.ResponseWriter, r *http.Request) {
+	serveExport(w, r, exportSource{"inventory_logs", admin.InventoryLogExportHeaders, admin.ExportInventoryLogs})
+}
+
+// GET /api/admin/export/customers?format=&from=&to=&status=<role>
+func ExportCustomers(w http.ResponseWriter, r *http.Request) {
+	serveExport(w, r, exportSource{"customers", admin.CustomerExportHeaders, admin.ExportCustomers})
+}
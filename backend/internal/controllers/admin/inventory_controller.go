@@ -3,6 +3,7 @@ package admin
 import (
 	"backend/internal/models"
 	admin "backend/internal/repository/admin"
+	"backend/internal/service"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -44,6 +45,7 @@ func CreateInventoryLog(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	service.NotifyBackInStock(log.VariantID)
 	json.NewEncoder(w).Encode(log)
 }
 
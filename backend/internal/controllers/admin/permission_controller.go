@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// GET /api/admin/permissions — danh sách quyền và quyền hiện tại của từng role
+func GetPermissions(w http.ResponseWriter, r *http.Request) {
+	roles, err := repository.GetRolePermissions()
+	if err != nil {
+		http.Error(w, "Failed to fetch permissions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"permissions": models.AllPermissions,
+		"roles":       roles,
+	})
+}
+
+// PUT /api/admin/permissions/{role}  body: {"permissions": ["orders:write", ...]}
+func UpdateRolePermissions(w http.ResponseWriter, r *http.Request) {
+	role := mux.Vars(r)["role"]
+	// admin luôn toàn quyền, chỉ cấu hình staff/customer
+	if role != "staff" && role != "customer" {
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := repository.SetRolePermissions(role, req.Permissions); err != nil {
+		if errors.Is(err, repository.ErrUnknownPermission) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to update permissions", http.StatusInternalServerError)
+		return
+	}
+	if err := service.LoadPermissions(); err != nil {
+		http.Error(w, "Failed to reload permissions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"role": role, "permissions": req.Permissions})
+}
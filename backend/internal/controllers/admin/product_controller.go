@@ -3,6 +3,7 @@ package admin
 import (
 	"backend/internal/models"
 	admin "backend/internal/repository/admin"
+	"backend/internal/service"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -77,10 +78,10 @@ func DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	idParam := mux.Vars(r)["id"]
 	id, _ := strconv.Atoi(idParam)
 	if err := admin.DeleteProduct(uint(id)); err != nil {
-		http.Error(w, "Failed to delete product", http.StatusInternalServerError)
+		writeArchiveError(w, err, "Failed to delete product")
 		return
 	}
-	json.NewEncoder(w).Encode(map[string]string{"message": "Product deleted successfully"})
+	writeDeleteResult(w, "Product", true)
 }
 
 // ================= VARIANTS ==================
@@ -139,6 +140,7 @@ func EditVariant(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to update variant", http.StatusInternalServerError)
 		return
 	}
+	service.NotifyBackInStock(variant.ID)
 	json.NewEncoder(w).Encode(variant)
 }
 
@@ -152,10 +154,11 @@ func DeleteVariant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := admin.DeleteVariant(uint(variantID)); err != nil {
-		http.Error(w, "Failed to delete variant", http.StatusInternalServerError)
+	archived, err := admin.DeleteVariant(uint(variantID))
+	if err != nil {
+		writeArchiveError(w, err, "Failed to delete variant")
 		return
 	}
-	json.NewEncoder(w).Encode(map[string]string{"message": "Variant deleted successfully"})
+	writeDeleteResult(w, "Variant", archived)
 }
 
@@ -3,6 +3,7 @@ package admin
 import (
 	"backend/internal/models"
 	admin "backend/internal/repository/admin"
+	"backend/internal/service"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -39,6 +40,7 @@ func CreatePurchaseGlobal(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to create purchase", http.StatusInternalServerError)
 		return
 	}
+	service.NotifyBackInStock(purchase.VariantID)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(purchase)
 }
@@ -61,6 +63,7 @@ func EditPurchaseGlobal(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to update purchase", http.StatusInternalServerError)
 		return
 	}
+	service.NotifyBackInStock(purchase.VariantID)
 	json.NewEncoder(w).Encode(purchase)
 }
 
@@ -117,6 +120,7 @@ func CreatePurchaseForSupplier(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to create purchase", http.StatusInternalServerError)
 		return
 	}
+	service.NotifyBackInStock(purchase.VariantID)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(purchase)
 }
@@ -139,6 +143,7 @@ func EditPurchaseForSupplier(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to update purchase", http.StatusInternalServerError)
 		return
 	}
+	service.NotifyBackInStock(purchase.VariantID)
 	json.NewEncoder(w).Encode(purchase)
 }
 
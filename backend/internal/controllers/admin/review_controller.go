@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"backend/internal/middlewares"
+	"backend/internal/repository/admin"
+	"backend/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GET /api/admin/reviews?status=pending&product_id=&rating=&page=1&limit=20
+func GetReviews(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := admin.ReviewFilter{Status: q.Get("status"), Page: 1, Limit: 20}
+	if val, err := strconv.Atoi(q.Get("page")); err == nil && val > 0 {
+		f.Page = val
+	}
+	if val, err := strconv.Atoi(q.Get("limit")); err == nil && val > 0 && val <= 100 {
+		f.Limit = val
+	}
+	if val, err := strconv.Atoi(q.Get("product_id")); err == nil && val > 0 {
+		f.ProductID = uint(val)
+	}
+	f.Rating, _ = strconv.Atoi(q.Get("rating"))
+
+	reviews, total, err := admin.GetReviews(f)
+	if err != nil {
+		http.Error(w, "Failed to fetch reviews", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  reviews,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// PATCH /api/admin/reviews/{id}/status  body: {"status": "approved"}
+func UpdateReviewStatus(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	review, err := admin.UpdateReviewStatus(uint(id), req.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, admin.ErrInvalidReviewStatus):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, "Review not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to update review", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// DELETE /api/admin/reviews/{id}
+func DeleteReview(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	images, err := admin.DeleteReview(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Review not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete review", http.StatusInternalServerError)
+		return
+	}
+	store := service.DefaultStorage()
+	for _, img := range images {
+		service.DeleteImage(r.Context(), store, img.StorageKey)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/admin/reviews/{id}/replies  body: {"content": "..."}
+func CreateReviewReply(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	reply, err := admin.AddReviewReply(uint(id), claims.UserID, req.Content)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Review not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reply)
+}
+
+// DELETE /api/admin/reviews/{id}/replies/{replyId}
+func DeleteReviewReply(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := strconv.Atoi(vars["id"])
+	replyID, _ := strconv.Atoi(vars["replyId"])
+	if err := admin.DeleteReviewReply(uint(id), uint(replyID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Reply not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete reply", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
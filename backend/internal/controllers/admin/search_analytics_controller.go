@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"backend/internal/repository/admin"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// đọc ?days=30&source=search&limit=20
+func analyticsParams(r *http.Request) (time.Time, string, int) {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	return time.Now().AddDate(0, 0, -days), r.URL.Query().Get("source"), limit
+}
+
+// GET /api/admin/search/analytics/top?days=30&source=search&limit=20
+func GetTopSearchQueries(w http.ResponseWriter, r *http.Request) {
+	since, source, limit := analyticsParams(r)
+	stats, err := admin.TopQueries(since, source, limit)
+	if err != nil {
+		http.Error(w, "Failed to load search stats", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": stats})
+}
+
+// GET /api/admin/search/analytics/zero-results?days=30&source=search&limit=20
+func GetZeroResultQueries(w http.ResponseWriter, r *http.Request) {
+	since, source, limit := analyticsParams(r)
+	stats, err := admin.ZeroResultQueries(since, source, limit)
+	if err != nil {
+		http.Error(w, "Failed to load search stats", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": stats})
+}
+
+// GET /api/admin/search/synonyms
+func GetSearchSynonyms(w http.ResponseWriter, r *http.Request) {
+	rows, err := admin.GetSearchSynonyms()
+	if err != nil {
+		http.Error(w, "Failed to fetch synonyms", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": rows})
+}
+
+type synonymRequest struct {
+	Terms []string `json:"terms"`
+}
+
+// POST /api/admin/search/synonyms  body: {"terms": ["quần bò", "jeans"]}
+func CreateSearchSynonym(w http.ResponseWriter, r *http.Request) {
+	var req synonymRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	row, err := admin.CreateSearchSynonym(req.Terms)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(row)
+}
+
+// PUT /api/admin/search/synonyms/{id}
+func UpdateSearchSynonym(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid synonym ID", http.StatusBadRequest)
+		return
+	}
+	var req synonymRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	row, err := admin.UpdateSearchSynonym(uint(id), req.Terms)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(row)
+}
+
+// DELETE /api/admin/search/synonyms/{id}
+func DeleteSearchSynonym(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid synonym ID", http.StatusBadRequest)
+		return
+	}
+	if err := admin.DeleteSearchSynonym(uint(id)); err != nil {
+		http.Error(w, "Failed to delete synonym", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"message": "Synonym deleted successfully"})
+}
@@ -1,22 +1,54 @@
 package admin
 
 import (
-    "encoding/json"
-    "net/http"
-    "backend/internal/repository/admin"
+	"backend/internal/repository/admin"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
 )
 
+// GET /api/admin/search?q=...&types=order,user&page=1&limit=5&status=pending
+// q được nhận diện: #123 / DH123 (đơn hàng), PN12 (phiếu nhập), TxnRef, SKU, email, số điện thoại
 func SearchAll(w http.ResponseWriter, r *http.Request) {
-    keyword := r.URL.Query().Get("q")
-    if len(keyword) == 0 {
-        json.NewEncoder(w).Encode([]interface{}{}) 
-        return
-    }
-    results, err := admin.SearchAll(keyword)
-    if err != nil {
-        http.Error(w, "Search failed", http.StatusInternalServerError)
-        return
-    }
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(results)
-}
\ No newline at end of file
+	q := r.URL.Query()
+	keyword := strings.TrimSpace(q.Get("q"))
+	if len(keyword) == 0 {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+
+	opt := admin.AdminSearchOptions{OrderStatus: q.Get("status")}
+	opt.Page, _ = strconv.Atoi(q.Get("page"))
+	opt.Limit, _ = strconv.Atoi(q.Get("limit"))
+	if types := q.Get("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				opt.Types = append(opt.Types, t)
+			}
+		}
+	}
+	for _, t := range opt.Types {
+		if !isAdminSearchType(t) {
+			http.Error(w, "Invalid search type: "+t, http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := admin.SearchAll(keyword, opt)
+	if err != nil {
+		http.Error(w, "Search failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func isAdminSearchType(t string) bool {
+	for _, known := range admin.AdminSearchTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"backend/internal/repository"
+
+	"github.com/gorilla/mux"
+)
+
+// GET /api/admin/security/mfa-policy
+func GetMFAPolicy(w http.ResponseWriter, r *http.Request) {
+	rows, err := repository.GetMFAPolicies()
+	if err != nil {
+		http.Error(w, "Failed to fetch MFA policy", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rows)
+}
+
+// PUT /api/admin/security/mfa-policy/{role}  body: {"required": true}
+func UpdateMFAPolicy(w http.ResponseWriter, r *http.Request) {
+	role := mux.Vars(r)["role"]
+	if role != "admin" && role != "staff" && role != "customer" {
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Required bool `json:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := repository.SetMFARequired(role, req.Required); err != nil {
+		http.Error(w, "Failed to update MFA policy", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"role": role, "required": req.Required})
+}
+
+// DELETE /api/admin/users/{id}/mfa — gỡ MFA khi user mất thiết bị (lần đăng nhập sau phải đăng ký lại nếu role bắt buộc)
+func ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if err := repository.DisableMFA(uint(id)); err != nil {
+		http.Error(w, "Failed to reset MFA", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "MFA reset successfully"})
+}
@@ -2,9 +2,11 @@ package admin
 
 import (
 	"backend/internal/models"
+	"backend/internal/repository"
 	admin "backend/internal/repository/admin"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/gorilla/mux"
@@ -36,6 +38,25 @@ func GetSupplierDetail(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(supplier)
 }
 
+// GET /api/admin/suppliers/slug/{slug} — slug cũ trả 301 về slug hiện tại
+func GetSupplierBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+	supplier, err := admin.GetSupplierBySlug(slug)
+	if err != nil {
+		if current, rerr := repository.ResolveOldSlug(models.SlugEntitySupplier, slug); rerr == nil {
+			location := "/api/admin/suppliers/slug/" + url.PathEscape(current)
+			w.Header().Set("Location", location)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMovedPermanently)
+			json.NewEncoder(w).Encode(map[string]interface{}{"redirect": true, "slug": current, "location": location})
+			return
+		}
+		http.Error(w, "Supplier not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(supplier)
+}
+
 // CREATE SUPPLIER
 func CreateSupplier(w http.ResponseWriter, r *http.Request) {
 	var req models.Supplier
@@ -80,9 +101,10 @@ func DeleteSupplier(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
 		return
 	}
-	if err := admin.DeleteSupplier(uint(id)); err != nil {
-		http.Error(w, "Failed to delete supplier", http.StatusInternalServerError)
+	archived, err := admin.DeleteSupplier(uint(id))
+	if err != nil {
+		writeArchiveError(w, err, "Failed to delete supplier")
 		return
 	}
-	json.NewEncoder(w).Encode(map[string]string{"message": "Supplier deleted successfully"})
+	writeDeleteResult(w, "Supplier", archived)
 }
@@ -0,0 +1,193 @@
+package admin
+
+import (
+	"backend/internal/models"
+	admin "backend/internal/repository/admin"
+	"backend/internal/service"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// uploadFolders: thư mục được phép khi upload ảnh lẻ
+var uploadFolders = map[string]bool{"products": true, "variants": true, "messages": true}
+
+const maxImagesPerUpload = 10
+
+func writeUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrFileTooLarge):
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, service.ErrImageTooLarge):
+		http.Error(w, "Image dimensions too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, service.ErrUnsupportedImage):
+		http.Error(w, "Unsupported image type (jpeg, png, gif, webp)", http.StatusUnsupportedMediaType)
+	default:
+		log.Println("Upload error:", err)
+		http.Error(w, "Failed to upload image", http.StatusInternalServerError)
+	}
+}
+
+// POST /api/admin/uploads (multipart: file, folder=products|variants|messages)
+// Trả về URL để gán vào ProductVariant.Image, Product.Image...
+func UploadImage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxUploadBytes()+1<<20)
+	if err := r.ParseMultipartForm(service.MaxUploadBytes()); err != nil {
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	folder := r.FormValue("folder")
+	if folder == "" {
+		folder = "products"
+	}
+	if !uploadFolders[folder] {
+		http.Error(w, "Invalid folder", http.StatusBadRequest)
+		return
+	}
+	_, fh, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Missing file", http.StatusBadRequest)
+		return
+	}
+	data, err := service.ReadUploadedFile(fh)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	img, err := service.SaveImage(r.Context(), service.DefaultStorage(), folder, data)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(img)
+}
+
+// GET /api/admin/products/{id}/images
+func GetProductImages(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	images, err := admin.GetProductImages(uint(id))
+	if err != nil {
+		http.Error(w, "Failed to fetch images", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": images})
+}
+
+// POST /api/admin/products/{id}/images (multipart: files[] theo thứ tự hiển thị)
+func UploadProductImages(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImagesPerUpload*service.MaxUploadBytes()+1<<20)
+	if err := r.ParseMultipartForm(service.MaxUploadBytes()); err != nil {
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	files := r.MultipartForm.File["files"]
+	if len(files) == 0 {
+		files = r.MultipartForm.File["file"]
+	}
+	if len(files) == 0 || len(files) > maxImagesPerUpload {
+		http.Error(w, "Missing files or too many files", http.StatusBadRequest)
+		return
+	}
+
+	store := service.DefaultStorage()
+	var images []models.ProductImage
+	for _, fh := range files {
+		data, err := service.ReadUploadedFile(fh)
+		if err == nil {
+			var up *service.UploadedImage
+			if up, err = service.SaveImage(r.Context(), store, "products", data); err == nil {
+				images = append(images, models.ProductImage{
+					StorageKey:   up.Key,
+					URL:          up.URL,
+					ThumbnailURL: up.ThumbnailURL,
+					WebPURL:      up.WebPURL,
+					Width:        up.Width,
+					Height:       up.Height,
+				})
+				continue
+			}
+		}
+		// lỗi giữa chừng -> dọn các file đã lưu
+		for _, img := range images {
+			service.DeleteImage(r.Context(), store, img.StorageKey)
+		}
+		writeUploadError(w, err)
+		return
+	}
+
+	saved, err := admin.AddProductImages(uint(id), images)
+	if err != nil {
+		for _, img := range images {
+			service.DeleteImage(r.Context(), store, img.StorageKey)
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": saved})
+}
+
+// PUT /api/admin/products/{id}/images/order  body: {"image_ids":[3,1,2]}
+func ReorderProductImages(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		ImageIDs []uint `json:"image_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.ImageIDs) == 0 {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	images, err := admin.ReorderProductImages(uint(id), body.ImageIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": images})
+}
+
+// DELETE /api/admin/products/{id}/images/{imageId}
+func DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	imageID, err := strconv.Atoi(vars["imageId"])
+	if err != nil {
+		http.Error(w, "Invalid image ID", http.StatusBadRequest)
+		return
+	}
+	img, err := admin.DeleteProductImage(uint(id), uint(imageID))
+	if err != nil {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+	if err := service.DeleteImage(r.Context(), service.DefaultStorage(), img.StorageKey); err != nil {
+		log.Println("Delete image file error:", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Image deleted successfully"})
+}
@@ -1,15 +1,17 @@
 package admin
 
 import (
-	"backend/configs"
 	"backend/internal/middlewares"
 	"backend/internal/models"
 	adminRepo "backend/internal/repository/admin"
+	"backend/internal/service"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 // GET ALL USERS
@@ -57,15 +59,22 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := adminRepo.DeleteUser(uint(id)); err != nil {
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+	if isSelf(r, uint(id)) {
+		http.Error(w, "Cannot delete your own account", http.StatusConflict)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "User deleted successfully"})
+	released, err := adminRepo.DeleteUser(uint(id))
+	if err != nil {
+		writeArchiveError(w, err, "Failed to delete user")
+		return
+	}
+	if len(released) > 0 {
+		service.NotifyBackInStock(released...)
+	}
+	writeDeleteResult(w, "User", true)
 }
 // GET USER LOGS
+// người có quyền users:manage xem toàn bộ, lọc được theo ?status=failed|blocked|success&user_id=&ip=&email=&limit=
 func GetUserLogsHandler(w http.ResponseWriter, r *http.Request) {
 	claims := middlewares.GetUserFromContext(r)
 	if claims == nil {
@@ -76,8 +85,17 @@ func GetUserLogsHandler(w http.ResponseWriter, r *http.Request) {
 	var logs []models.LoginLog
 	var err error
 
-	if claims.Role == "admin" {
-		err = configs.DB.Order("created_at desc").Find(&logs).Error
+	if service.HasPermission(claims.Role, models.PermUsersManage) {
+		q := r.URL.Query()
+		userID, _ := strconv.Atoi(q.Get("user_id"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		logs, err = adminRepo.GetLoginLogs(adminRepo.LoginLogFilter{
+			UserID: uint(userID),
+			Status: q.Get("status"),
+			IP:     q.Get("ip"),
+			Email:  q.Get("email"),
+			Limit:  limit,
+		})
 	} else {
 		logs, err = adminRepo.GetLoginLogsByUserID(claims.UserID)
 	}
@@ -90,3 +108,33 @@ func GetUserLogsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(logs)
 }
+
+// GET /api/admin/logs/lockouts — tài khoản đang bị khoá hoặc có lần đăng nhập sai
+func GetLockedAccounts(w http.ResponseWriter, r *http.Request) {
+	users, err := adminRepo.GetLockedAccounts()
+	if err != nil {
+		http.Error(w, "Failed to fetch locked accounts", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// POST /api/admin/logs/lockouts/{id}/unlock
+func UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if err := adminRepo.UnlockUser(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to unlock user", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "User unlocked successfully"})
+}
@@ -1,18 +1,24 @@
 package controllers
 
 import (
+	customerCtrl "backend/internal/controllers/customer"
+	"backend/internal/middlewares"
 	"backend/internal/models"
+	"backend/internal/ratelimit"
 	"backend/internal/repository"
+	customerRepo "backend/internal/repository/customer"
 	"backend/internal/service"
 	"backend/internal/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // ================= REGISTER =================
@@ -24,12 +30,52 @@ type RegisterRequest struct {
 	Address  string `json:"address"`
 }
 
+// validate chuẩn hoá và kiểm tra dữ liệu đăng ký
+func (req *RegisterRequest) validate() error {
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	req.Phone = strings.TrimSpace(req.Phone)
+	req.Address = strings.TrimSpace(req.Address)
+
+	if err := utils.ValidateUsername(req.Username); err != nil {
+		return err
+	}
+	if err := utils.ValidateEmail(req.Email); err != nil {
+		return err
+	}
+	if err := utils.ValidatePhone(req.Phone); err != nil {
+		return err
+	}
+	if err := utils.ValidateAddress(req.Address); err != nil {
+		return err
+	}
+	return utils.ValidatePassword(req.Password)
+}
+
+// RegistrationTTL: hạn của link xác nhận (REGISTRATION_TTL_HOURS, mặc định 24h)
+func RegistrationTTL() time.Duration {
+	if h, err := strconv.Atoi(os.Getenv("REGISTRATION_TTL_HOURS")); err == nil && h > 0 {
+		return time.Duration(h) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func sendRegistrationEmail(email, token string) error {
+	link := fmt.Sprintf("%s/api/auth/confirm?token=%s",
+		os.Getenv("BACKEND_URL"), url.QueryEscape(token))
+	return service.SendConfirmationEmail(email, link)
+}
+
 func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	hash, err := utils.HashPassword(req.Password)
 	if err != nil {
@@ -37,27 +83,23 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims := jwt.MapClaims{
-		"username": req.Username,
-		"email":    req.Email,
-		"phone":    req.Phone,
-		"role":     "customer",
-		"hash":     hash,
-		"address":  req.Address,
-		"exp":      time.Now().Add(time.Hour).Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	token, err := repository.CreatePendingRegistration(&models.PendingRegistration{
+		Username:     req.Username,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		Address:      req.Address,
+		PasswordHash: hash,
+	}, RegistrationTTL())
 	if err != nil {
-		http.Error(w, "Failed to create token", http.StatusInternalServerError)
+		if errors.Is(err, repository.ErrEmailTaken) || errors.Is(err, repository.ErrUsernameTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to save registration", http.StatusInternalServerError)
 		return
 	}
 
-	link := fmt.Sprintf("%s/api/auth/confirm?token=%s",
-		os.Getenv("BACKEND_URL"), url.QueryEscape(tokenStr))
-
-	if err := service.SendConfirmationEmail(req.Email, link); err != nil {
+	if err := sendRegistrationEmail(req.Email, token); err != nil {
 		http.Error(w, "Failed to send email", http.StatusInternalServerError)
 		return
 	}
@@ -66,38 +108,50 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Please check your email to confirm"})
 }
 
-// ================= CONFIRM REGISTER =================
-func ConfirmRegisterHandler(w http.ResponseWriter, r *http.Request) {
-	tokenStr := r.URL.Query().Get("token")
-	frontend := os.Getenv("FRONTEND_URL")
-
-	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(tokenStr, &claims, func(t *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-
-	if err != nil || !token.Valid {
-		http.Redirect(w, r, frontend+"/register/failpage", http.StatusSeeOther)
+// POST /api/auth/resend-confirmation  body: {"email"} — luôn trả 200 để không lộ email
+func ResendConfirmationHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		http.Error(w, "Missing email", http.StatusBadRequest)
 		return
 	}
 
-	hash, ok := claims["hash"].(string)
-	if !ok || hash == "" {
-		http.Redirect(w, r, frontend+"/register/failpage", http.StatusSeeOther)
+	p, token, err := repository.RenewPendingRegistration(strings.ToLower(strings.TrimSpace(req.Email)), RegistrationTTL())
+	if err != nil {
+		http.Error(w, "Failed to resend confirmation", http.StatusInternalServerError)
 		return
 	}
-
-	user := models.User{
-		Username:     claims["username"].(string),
-		PasswordHash: hash,
-		Email:        claims["email"].(string),
-		Phone:        claims["phone"].(string),
-		Address:      claims["address"].(string),
-		Role:         "customer",
+	if p != nil {
+		if err := sendRegistrationEmail(p.Email, token); err != nil {
+			log.Println("Failed to resend confirmation email:", err)
+		}
 	}
 
-	if err := repository.CreateUser(&user); err != nil {
-		http.Redirect(w, r, frontend+"/register/failpage", http.StatusSeeOther)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "If a pending registration exists, a new confirmation email has been sent"})
+}
+
+// ================= CONFIRM REGISTER =================
+func ConfirmRegisterHandler(w http.ResponseWriter, r *http.Request) {
+	frontend := os.Getenv("FRONTEND_URL")
+
+	if _, err := repository.ConfirmPendingRegistration(r.URL.Query().Get("token")); err != nil {
+		// FailPage hiển thị theo reason
+		reason := "invalid"
+		switch {
+		case errors.Is(err, repository.ErrRegistrationConfirmed):
+			reason = "already_confirmed"
+		case errors.Is(err, repository.ErrRegistrationExpired):
+			reason = "expired"
+		case errors.Is(err, repository.ErrEmailTaken), errors.Is(err, repository.ErrUsernameTaken):
+			reason = "taken"
+		case !errors.Is(err, repository.ErrRegistrationInvalid):
+			log.Println("Confirm registration failed:", err)
+			reason = "error"
+		}
+		http.Redirect(w, r, frontend+"/register/failpage?reason="+reason, http.StatusSeeOther)
 		return
 	}
 
@@ -108,6 +162,46 @@ func ConfirmRegisterHandler(w http.ResponseWriter, r *http.Request) {
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// token giỏ hàng khách vãng lai (hoặc header X-Guest-Token) để gộp vào giỏ của user
+	GuestToken string `json:"guest_token"`
+}
+
+// loginAccountLimiter: số lần thử đăng nhập mỗi email trong 15 phút (LOGIN_RATE_LIMIT_PER_ACCOUNT, mặc định 10),
+// chặn cả khi kẻ tấn công đổi IP liên tục
+func loginAccountLimiter() ratelimit.Limiter {
+	return ratelimit.FromEnv("login_account", "LOGIN_RATE_LIMIT_PER_ACCOUNT", 10, 15*time.Minute)
+}
+
+// loginLockoutPolicy: LOGIN_LOCKOUT_THRESHOLD lần sai liên tiếp (mặc định 5) thì khoá LOGIN_LOCKOUT_MINUTES phút
+// (mặc định 1), mỗi lần khoá tiếp theo gấp đôi, tối đa 24h
+func loginLockoutPolicy() repository.LockoutPolicy {
+	p := repository.LockoutPolicy{Threshold: 5, Base: time.Minute, Max: 24 * time.Hour}
+	if v, err := strconv.Atoi(os.Getenv("LOGIN_LOCKOUT_THRESHOLD")); err == nil && v >= 0 {
+		p.Threshold = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("LOGIN_LOCKOUT_MINUTES")); err == nil && v > 0 {
+		p.Base = time.Duration(v) * time.Minute
+	}
+	return p
+}
+
+func writeLoginLog(r *http.Request, user *models.User, email, status, message string) {
+	entry := models.LoginLog{
+		Role:      "unknown",
+		Email:     email,
+		IP:        utils.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Status:    status,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+	if user != nil {
+		entry.UserID = user.ID
+		entry.Role = user.Role
+	}
+	if err := repository.CreateLoginLog(&entry); err != nil {
+		log.Println("Write login log failed:", err)
+	}
 }
 
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -116,52 +210,322 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
 
-	user, err := repository.GetUserByEmail(req.Email)
-	if err != nil || !utils.CheckPasswordHash(user.PasswordHash, req.Password) {
-		repository.CreateLoginLog(&models.LoginLog{
-			UserID:    0,
-			Role:      "unknown",
-			IP:        r.RemoteAddr,
-			UserAgent: r.UserAgent(),
-			Status:    "failed",
-			Message:   "Invalid credentials",
-			CreatedAt: time.Now(),
-		})
+	if ok, retry := loginAccountLimiter().Allow(email); !ok {
+		writeLoginLog(r, nil, email, "blocked", "Too many attempts for account")
+		w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds()+0.5)))
+		http.Error(w, "Too many login attempts, please try again later", http.StatusTooManyRequests)
+		return
+	}
 
+	user, err := repository.GetUserByEmail(email)
+	if err != nil {
+		writeLoginLog(r, nil, email, "failed", "Invalid credentials")
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		writeLoginLog(r, user, email, "blocked", "Account locked")
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(*user.LockedUntil).Seconds()+0.5)))
+		http.Error(w, "Account is temporarily locked, please try again later", http.StatusLocked)
+		return
+	}
+	if !utils.CheckPasswordHash(user.PasswordHash, req.Password) {
+		lockedUntil, err := repository.RecordLoginFailure(user.ID, loginLockoutPolicy())
+		if err != nil {
+			log.Println("Record login failure failed:", err)
+		}
+		if lockedUntil != nil {
+			writeLoginLog(r, user, email, "failed", "Invalid credentials, account locked until "+lockedUntil.Format(time.RFC3339))
+		} else {
+			writeLoginLog(r, user, email, "failed", "Invalid credentials")
+		}
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
 
-	
-	claims := jwt.MapClaims{
-		"user_id":  user.ID,
-		"role":     user.Role,
-		"username": user.Username, 
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), 
-		"iat":      time.Now().Unix(),
+	mfa, err := mfaChallenge(user)
+	if err != nil {
+		http.Error(w, "Failed to check MFA", http.StatusInternalServerError)
+		return
+	}
+	if mfa != nil {
+		writeLoginLog(r, user, email, "mfa_pending", "Password accepted, waiting for MFA")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mfa)
+		return
+	}
+
+	completeLogin(w, r, user, req.GuestToken, nil)
+}
+
+// mfaChallenge: MFA đã bật thì bắt nhập mã, role bắt buộc MFA mà chưa bật thì bắt đăng ký trước khi vào.
+// Trả về nil nếu user được vào thẳng
+func mfaChallenge(user *models.User) (map[string]interface{}, error) {
+	enabled, err := service.MFAEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	enroll := false
+	if !enabled {
+		if enroll, err = repository.IsMFARequired(user.Role); err != nil {
+			return nil, err
+		}
+	}
+	if !enabled && !enroll {
+		return nil, nil
 	}
+	mfaToken, err := service.GenerateMFAToken(user, enroll)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"mfa_required":            enabled,
+		"mfa_enrollment_required": enroll,
+		"mfa_token":               mfaToken,
+	}, nil
+}
 
-	tokenJWT := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := tokenJWT.SignedString([]byte(os.Getenv("JWT_SECRET")))
+// completeLogin cấp phiên đăng nhập sau khi đã qua mọi bước xác thực, extra được gộp vào response
+func completeLogin(w http.ResponseWriter, r *http.Request, user *models.User, guestToken string, extra map[string]interface{}) {
+	resp, err := startLogin(r, user, guestToken, "Login successful")
 	if err != nil {
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
 	}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
 
-	repository.CreateLoginLog(&models.LoginLog{
-		UserID:    user.ID,
-		Role:      user.Role,
-		IP:        r.RemoteAddr,
-		UserAgent: r.UserAgent(),
-		Status:    "success",
-		Message:   "Login successful",
-		CreatedAt: time.Now(),
-	})
+// startLogin tạo phiên, xoá bộ đếm đăng nhập sai, ghi log và gộp giỏ hàng khách
+func startLogin(r *http.Request, user *models.User, guestToken, message string) (map[string]interface{}, error) {
+	session, err := service.StartSession(user, utils.ClientIP(r), r.UserAgent())
+	if err != nil {
+		return nil, err
+	}
+
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := repository.ResetLoginFailures(user.ID); err != nil {
+			log.Println("Reset login failures failed:", err)
+		}
+	}
+	email := strings.ToLower(user.Email)
+	loginAccountLimiter().Reset(email)
+	writeLoginLog(r, user, email, "success", message)
+
+	resp := map[string]interface{}{
+		"token":         session.Token,
+		"refresh_token": session.RefreshToken,
+		"expires_in":    session.ExpiresIn,
+	}
+	if guestToken == "" {
+		guestToken = r.Header.Get(customerCtrl.GuestTokenHeader)
+	}
+	if guestToken != "" {
+		adjustments, err := customerRepo.MergeGuestCart(guestToken, user.ID)
+		if err != nil {
+			log.Println("Merge guest cart failed:", err)
+		} else {
+			resp["cart_merge"] = adjustments
+		}
+	}
+	return resp, nil
+}
+
+// ================= REFRESH =================
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// POST /api/auth/refresh — đổi refresh token lấy cặp token mới (refresh token cũ hết hiệu lực)
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Missing refresh_token", http.StatusBadRequest)
+		return
+	}
+
+	session, err := service.RefreshSession(req.RefreshToken, utils.ClientIP(r), r.UserAgent())
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			log.Printf("Refresh token reuse detected from %s", utils.ClientIP(r))
+		}
+		if errors.Is(err, repository.ErrRefreshTokenInvalid) || errors.Is(err, repository.ErrRefreshTokenReused) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// ================= LOGOUT =================
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	All          bool   `json:"all"` // đăng xuất mọi thiết bị
+}
+
+// POST /api/auth/logout — thu hồi phiên hiện tại, all=true thu hồi mọi phiên + access token của user
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	var req LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Missing refresh_token", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := repository.RevokeRefreshToken(req.RefreshToken)
+	if err != nil {
+		http.Error(w, "Failed to logout", http.StatusInternalServerError)
+		return
+	}
+	// all chỉ có hiệu lực với refresh token còn hạn, chưa bị thu hồi
+	if req.All && userID != 0 {
+		if err := repository.RevokeUserSessions(userID); err != nil {
+			http.Error(w, "Failed to logout", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Logged out"})
+}
+
+// ================= FORGOT / RESET PASSWORD =================
+// PasswordResetTTL: PASSWORD_RESET_TTL_MINUTES, mặc định 30 phút
+func PasswordResetTTL() time.Duration {
+	if m, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_TTL_MINUTES")); err == nil && m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return 30 * time.Minute
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// POST /api/auth/forgot-password — luôn trả 200 để không lộ email nào đã đăng ký
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		http.Error(w, "Missing email", http.StatusBadRequest)
+		return
+	}
+
+	user, err := repository.GetUserByEmail(strings.ToLower(strings.TrimSpace(req.Email)))
+	if err == nil {
+		ttl := PasswordResetTTL()
+		token, err := repository.CreatePasswordResetToken(user.ID, ttl)
+		if err != nil {
+			http.Error(w, "Failed to create reset token", http.StatusInternalServerError)
+			return
+		}
+		link := fmt.Sprintf("%s/reset-password?token=%s", os.Getenv("FRONTEND_URL"), url.QueryEscape(token))
+		if err := service.SendPasswordResetEmail(user.Email, link, ttl); err != nil {
+			log.Println("Failed to send password reset email:", err)
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"token": tokenStr,
-	})
+	json.NewEncoder(w).Encode(map[string]string{"message": "If the email exists, a reset link has been sent"})
 }
 
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// POST /api/auth/reset-password — đặt mật khẩu mới, mọi phiên cũ bị đăng xuất
+func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+	if _, err := repository.ResetPasswordWithToken(req.Token, hash); err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password has been reset, please log in again"})
+}
+
+// ================= CHANGE PASSWORD =================
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// POST /api/auth/change-password (JWT) — đăng xuất mọi phiên khác, trả về phiên mới cho thiết bị hiện tại
+func ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := utils.ValidatePassword(req.NewPassword); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := repository.GetUserByID(claims.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if !utils.CheckPasswordHash(user.PasswordHash, req.OldPassword) {
+		http.Error(w, "Old password is incorrect", http.StatusBadRequest)
+		return
+	}
+
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+	if err := repository.ChangePassword(user.ID, hash); err != nil {
+		http.Error(w, "Failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	// token_version đã tăng, nạp lại user để cấp token hợp lệ
+	user, err = repository.GetUserByID(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load user", http.StatusInternalServerError)
+		return
+	}
+	session, err := service.StartSession(user, utils.ClientIP(r), r.UserAgent())
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
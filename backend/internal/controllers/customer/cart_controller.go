@@ -7,14 +7,47 @@ import (
 
     "github.com/gorilla/mux"
     repo "backend/internal/repository/customer"
+    "backend/internal/middlewares"
     "backend/internal/models"
+    "backend/internal/service"
 )
 
-func GetCart(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    uid, _ := strconv.Atoi(vars["userId"])
+// currentUserID lấy user từ JWT, trả 401 nếu không có
+func currentUserID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+    claims := middlewares.GetUserFromContext(r)
+    if claims == nil {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return 0, false
+    }
+    return claims.UserID, true
+}
 
-    items, err := repo.GetCartByUser(uint(uid))
+// requireSameUser: id truyền từ path/body (route cũ) phải trùng user trong JWT, khác -> 403.
+// id = 0 nghĩa là client không gửi, dùng user trong JWT.
+func requireSameUser(w http.ResponseWriter, r *http.Request, id uint64) (uint, bool) {
+    uid, ok := currentUserID(w, r)
+    if !ok {
+        return 0, false
+    }
+    if id != 0 && id != uint64(uid) {
+        http.Error(w, "Forbidden", http.StatusForbidden)
+        return 0, false
+    }
+    return uid, true
+}
+
+// pathUserID đọc {userId} của route cũ và kiểm tra quyền sở hữu
+func pathUserID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+    id, err := strconv.ParseUint(mux.Vars(r)["userId"], 10, 64)
+    if err != nil || id == 0 {
+        http.Error(w, "Invalid user ID", http.StatusBadRequest)
+        return 0, false
+    }
+    return requireSameUser(w, r, id)
+}
+
+func writeCart(w http.ResponseWriter, uid uint) {
+    items, err := repo.GetCartByUser(uid)
     if err != nil {
         http.Error(w, err.Error(), 500)
         return
@@ -22,12 +55,37 @@ func GetCart(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(items)
 }
 
+// GET /api/customer/cart/{userId} (cũ, chỉ được đọc giỏ của chính mình)
+func GetCart(w http.ResponseWriter, r *http.Request) {
+    uid, ok := pathUserID(w, r)
+    if !ok {
+        return
+    }
+    writeCart(w, uid)
+}
+
+// GET /api/customer/me/cart
+func GetMyCart(w http.ResponseWriter, r *http.Request) {
+    uid, ok := currentUserID(w, r)
+    if !ok {
+        return
+    }
+    writeCart(w, uid)
+}
+
+// POST /api/customer/cart/add, /api/customer/me/cart — user_id luôn lấy từ JWT
 func AddToCart(w http.ResponseWriter, r *http.Request) {
     var item models.CartItem
     if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
         http.Error(w, "Invalid body", http.StatusBadRequest)
         return
     }
+    uid, ok := requireSameUser(w, r, item.UserID)
+    if !ok {
+        return
+    }
+    item.UserID = uint64(uid)
+    item.SessionID = nil
     if err := repo.AddToCart(&item); err != nil {
         http.Error(w, err.Error(), 500)
         return
@@ -35,6 +93,7 @@ func AddToCart(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(item)
 }
 
+// PUT /api/customer/cart/update, /api/customer/me/cart
 func UpdateCartItem(w http.ResponseWriter, r *http.Request) {
     var body struct {
         UserID    uint64 `json:"user_id"`
@@ -45,32 +104,73 @@ func UpdateCartItem(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "Invalid body", http.StatusBadRequest)
         return
     }
-    if err := repo.UpdateCartItem(body.UserID, body.VariantID, body.Quantity); err != nil {
+    uid, ok := requireSameUser(w, r, body.UserID)
+    if !ok {
+        return
+    }
+    if err := repo.UpdateCartItem(uint64(uid), body.VariantID, body.Quantity); err != nil {
         http.Error(w, err.Error(), 500)
         return
     }
+    service.NotifyBackInStock(uint(body.VariantID))
     w.WriteHeader(http.StatusOK)
 }
 
+func removeCartItem(w http.ResponseWriter, r *http.Request, uid uint) {
+    variantID, err := strconv.ParseUint(mux.Vars(r)["variantId"], 10, 64)
+    if err != nil {
+        http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+        return
+    }
+    if err := repo.RemoveCartItem(uint64(uid), variantID); err != nil {
+        http.Error(w, err.Error(), 500)
+        return
+    }
+    service.NotifyBackInStock(uint(variantID))
+    w.WriteHeader(http.StatusOK)
+}
+
+// DELETE /api/customer/cart/remove/{userId}/{variantId} (cũ)
 func RemoveCartItem(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    uid, _ := strconv.ParseUint(vars["userId"], 10, 64)
-    variantID, _ := strconv.ParseUint(vars["variantId"], 10, 64)
+    uid, ok := pathUserID(w, r)
+    if !ok {
+        return
+    }
+    removeCartItem(w, r, uid)
+}
+
+// DELETE /api/customer/me/cart/{variantId}
+func RemoveMyCartItem(w http.ResponseWriter, r *http.Request) {
+    uid, ok := currentUserID(w, r)
+    if !ok {
+        return
+    }
+    removeCartItem(w, r, uid)
+}
 
-    if err := repo.RemoveCartItem(uid, variantID); err != nil {
+func clearCart(w http.ResponseWriter, uid uint) {
+    if err := repo.ClearCart(uint64(uid)); err != nil {
         http.Error(w, err.Error(), 500)
         return
     }
+    service.NotifyBackInStock()
     w.WriteHeader(http.StatusOK)
 }
 
+// DELETE /api/customer/cart/clear/{userId} (cũ)
 func ClearCart(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    uid, _ := strconv.ParseUint(vars["userId"], 10, 64)
+    uid, ok := pathUserID(w, r)
+    if !ok {
+        return
+    }
+    clearCart(w, uid)
+}
 
-    if err := repo.ClearCart(uid); err != nil {
-        http.Error(w, err.Error(), 500)
+// DELETE /api/customer/me/cart
+func ClearMyCart(w http.ResponseWriter, r *http.Request) {
+    uid, ok := currentUserID(w, r)
+    if !ok {
         return
     }
-    w.WriteHeader(http.StatusOK)
+    clearCart(w, uid)
 }
@@ -1,21 +1,33 @@
 package customer
 
 import (
+	"backend/internal/models"
+	"backend/internal/repository"
 	"backend/internal/repository/customer"
 	"encoding/json"
 	"net/http"
-	
+	"net/url"
+
+	"gorm.io/gorm"
 )
 
-// GET /api/customer/categories?group_name=Đồ nam
+// GET /api/customer/categories?group_name=Đồ nam  (hoặc ?parent=do-nam)
+// Trả về các category con của category gốc tương ứng
 func GetCategoriesByGroup(w http.ResponseWriter, r *http.Request) {
 	groupName := r.URL.Query().Get("group_name")
+	if groupName == "" {
+		groupName = r.URL.Query().Get("parent")
+	}
 	if groupName == "" {
 		http.Error(w, "Missing group_name param", http.StatusBadRequest)
 		return
 	}
 
-	cats, err := customer.GetCategoriesByGroup(groupName)
+	root, cats, err := customer.GetCategoriesByGroup(groupName)
+	if err == gorm.ErrRecordNotFound {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to fetch categories", http.StatusInternalServerError)
 		return
@@ -23,12 +35,24 @@ func GetCategoriesByGroup(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"group_name": groupName,
+		"group_name": root.Name,
+		"parent":     root,
 		"categories": cats,
 	})
 }
 
-// GET /api/customer/categories/products?slug=bo-vest
+// GET /api/customer/categories/tree
+func GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := customer.GetCategoryTree()
+	if err != nil {
+		http.Error(w, "Failed to fetch categories", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": tree})
+}
+
+// GET /api/customer/products?slug=bo-vest
 func GetProductsByCategory(w http.ResponseWriter, r *http.Request) {
 	slug := r.URL.Query().Get("slug")
 	if slug == "" {
@@ -37,6 +61,14 @@ func GetProductsByCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	products, err := customer.GetProductsByCategorySlug(slug)
+	if err == gorm.ErrRecordNotFound {
+		if current, rerr := repository.ResolveOldSlug(models.SlugEntityCategory, slug); rerr == nil {
+			redirectSlug(w, r, "/api/customer/products?slug="+url.QueryEscape(current), current)
+			return
+		}
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -0,0 +1,60 @@
+package customer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	repo "backend/internal/repository/customer"
+)
+
+// splitParam gộp ?size=M&size=L và ?size=M,L
+func splitParam(q url.Values, key string) []string {
+	var out []string
+	for _, v := range q[key] {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// GET /api/customer/products/filter?category=ao-thun&min_price=&max_price=&size=M,L&color=Đen&material=cotton&sort=price_asc&page=1&limit=20
+// Các query param trùng code thuộc tính (material, fit, season, gender, brand...) được dùng làm filter.
+func FilterProducts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := repo.ProductFilter{
+		CategorySlug: q.Get("category"),
+		Sizes:        splitParam(q, "size"),
+		Colors:       splitParam(q, "color"),
+		Attributes:   map[string][]string{},
+		Sort:         q.Get("sort"),
+	}
+	f.MinPrice, _ = strconv.ParseFloat(q.Get("min_price"), 64)
+	f.MaxPrice, _ = strconv.ParseFloat(q.Get("max_price"), 64)
+	f.Page, _ = strconv.Atoi(q.Get("page"))
+	f.Limit, _ = strconv.Atoi(q.Get("limit"))
+
+	codes, err := repo.AttributeCodes()
+	if err != nil {
+		http.Error(w, "Failed to load attributes", http.StatusInternalServerError)
+		return
+	}
+	for _, code := range codes {
+		if values := splitParam(q, code); len(values) > 0 {
+			f.Attributes[code] = values
+		}
+	}
+
+	result, err := repo.FilterProducts(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
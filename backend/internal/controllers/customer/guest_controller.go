@@ -0,0 +1,244 @@
+package customer
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/middlewares"
+	"backend/internal/models"
+	"backend/internal/repository"
+	repo "backend/internal/repository/customer"
+	"backend/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// GuestTokenHeader: header mang token phiên khách vãng lai
+const GuestTokenHeader = "X-Guest-Token"
+
+// GuestSessionTTL: thời gian sống của giỏ khách kể từ lần dùng cuối (GUEST_CART_TTL_HOURS, mặc định 72h)
+func GuestSessionTTL() time.Duration {
+	if h, err := strconv.Atoi(os.Getenv("GUEST_CART_TTL_HOURS")); err == nil && h > 0 {
+		return time.Duration(h) * time.Hour
+	}
+	return 72 * time.Hour
+}
+
+// guestSession đọc + gia hạn phiên từ header, tự trả lỗi 401 nếu không hợp lệ
+func guestSession(w http.ResponseWriter, r *http.Request) (*models.GuestSession, bool) {
+	s, err := repo.TouchGuestSession(r.Header.Get(GuestTokenHeader), GuestSessionTTL())
+	if err != nil {
+		if errors.Is(err, repo.ErrGuestSessionInvalid) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return s, true
+}
+
+func writeGuestCartError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repo.ErrVariantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repo.ErrInsufficientStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// POST /api/customer/guest/session — tạo phiên khách, trả về token
+func CreateGuestSession(w http.ResponseWriter, r *http.Request) {
+	s, err := repo.CreateGuestSession(GuestSessionTTL())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s)
+}
+
+// GET /api/customer/guest/cart
+func GetGuestCart(w http.ResponseWriter, r *http.Request) {
+	s, ok := guestSession(w, r)
+	if !ok {
+		return
+	}
+	items, err := repo.GetGuestCart(s.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+type guestCartRequest struct {
+	VariantID uint64 `json:"variantId"`
+	Quantity  int    `json:"quantity"`
+}
+
+func setGuestCart(w http.ResponseWriter, r *http.Request, add bool) {
+	s, ok := guestSession(w, r)
+	if !ok {
+		return
+	}
+	var req guestCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.VariantID == 0 {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if add && req.Quantity <= 0 {
+		req.Quantity = 1
+	}
+	item, err := repo.SetGuestCartQuantity(s.ID, req.VariantID, req.Quantity, add)
+	if err != nil {
+		writeGuestCartError(w, err)
+		return
+	}
+	if !add {
+		// giảm số lượng trả stock về kho
+		service.NotifyBackInStock(uint(req.VariantID))
+	}
+	if item == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// POST /api/customer/guest/cart/add  body: {"variantId": 1, "quantity": 2}
+func AddToGuestCart(w http.ResponseWriter, r *http.Request) {
+	setGuestCart(w, r, true)
+}
+
+// PUT /api/customer/guest/cart/update  body: {"variantId": 1, "quantity": 3} (0 = xoá)
+func UpdateGuestCartItem(w http.ResponseWriter, r *http.Request) {
+	setGuestCart(w, r, false)
+}
+
+// DELETE /api/customer/guest/cart/remove/{variantId}
+func RemoveGuestCartItem(w http.ResponseWriter, r *http.Request) {
+	s, ok := guestSession(w, r)
+	if !ok {
+		return
+	}
+	variantID, err := strconv.ParseUint(mux.Vars(r)["variantId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := repo.SetGuestCartQuantity(s.ID, variantID, 0, false); err != nil {
+		writeGuestCartError(w, err)
+		return
+	}
+	service.NotifyBackInStock(uint(variantID))
+	w.WriteHeader(http.StatusOK)
+}
+
+// DELETE /api/customer/guest/cart/clear
+func ClearGuestCart(w http.ResponseWriter, r *http.Request) {
+	s, ok := guestSession(w, r)
+	if !ok {
+		return
+	}
+	ids, err := repo.ClearGuestCart(s.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(ids) > 0 {
+		service.NotifyBackInStock(ids...)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// POST /api/customer/guest/checkout
+// body: {"name", "email", "phone", "address", "payment_method": "cod"|"vnpay"} — hàng lấy từ giỏ phía server
+func GuestCheckout(w http.ResponseWriter, r *http.Request) {
+	s, ok := guestSession(w, r)
+	if !ok {
+		return
+	}
+	var req repo.GuestCheckoutInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if _, err := mail.ParseAddress(strings.TrimSpace(req.Email)); err != nil {
+		http.Error(w, "Valid email is required", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Address) == "" {
+		http.Error(w, "Address is required", http.StatusBadRequest)
+		return
+	}
+	method := strings.ToLower(req.PaymentMethod)
+	if method != "cod" && method != "vnpay" {
+		http.Error(w, "Invalid payment method", http.StatusBadRequest)
+		return
+	}
+
+	// giỏ chuyển hẳn sang đơn; VNPay thất bại thì hàng quay lại giỏ (xem VnpayReturnHandler)
+	order, err := repo.CreateGuestOrder(s.ID, req)
+	if err != nil {
+		if errors.Is(err, repo.ErrGuestCartEmpty) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to create order: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	address := repository.OrderAddress(order, false)
+
+	w.Header().Set("Content-Type", "application/json")
+	if method == "cod" {
+		if err := service.SendOrderEmail(order.GuestEmail, orderEmailItems(order)); err != nil {
+			log.Println("Failed to send order email:", err)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"order":   order,
+			"address": address,
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"order": order,
+		"url":   vnpayPaymentURL(r, order),
+	})
+}
+
+// POST /api/customer/cart/merge (header X-Guest-Token) — gộp giỏ khách vào giỏ của user đang đăng nhập
+func MergeGuestCart(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	adjustments, err := repo.MergeGuestCart(r.Header.Get(GuestTokenHeader), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	items, err := repo.GetCartByUser(claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":       items,
+		"adjustments": adjustments,
+	})
+}
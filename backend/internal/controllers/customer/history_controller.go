@@ -7,6 +7,7 @@ import (
 	"github.com/gorilla/mux"
 	customerRepo "backend/internal/repository/customer"
 	"backend/internal/middlewares"
+	"backend/internal/service"
 )
 
 // GET /api/customer/orders/processing
@@ -72,6 +73,10 @@ func CancelOrderHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// huỷ đơn trả stock -> báo khách đang chờ hàng
+	if ids, err := customerRepo.OrderVariantIDs(uint(orderID)); err == nil && len(ids) > 0 {
+		service.NotifyBackInStock(ids...)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
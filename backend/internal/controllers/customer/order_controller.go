@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -18,42 +17,12 @@ import (
 
 // Lấy địa chỉ IP của client từ request
 func getClientIP(r *http.Request) string {
-	xff := strings.TrimSpace(r.Header.Get("X-Forwarded-For"))
-	if xff != "" {
-		parts := strings.Split(xff, ",")
-		ip := strings.TrimSpace(parts[0])
-		if ip == "::1" {
-			return "127.0.0.1"
-		}
-		return ip
-	}
-	hostPort := strings.TrimSpace(r.RemoteAddr)
-	if hostPort == "" {
-		return "127.0.0.1"
-	}
-	if host, _, err := net.SplitHostPort(hostPort); err == nil {
-		host = strings.TrimSpace(host)
-		if host == "::1" {
-			return "127.0.0.1"
-		}
-		return host
-	}
-	h := hostPort
-	if strings.HasPrefix(h, "[") && strings.Contains(h, "]") {
-		h = h[1:strings.Index(h, "]")]
-	}
-	if strings.Contains(h, ":") {
-		h = strings.Split(h, ":")[0]
-	}
-	if h == "::1" || h == "" {
-		return "127.0.0.1"
-	}
-	return h
+	return utils.ClientIP(r)
 }
 
 // PlaceOrderRequest giống kiểu bạn đang dùng
 type PlaceOrderRequest struct {
-	CustomerID    uint                   `json:"customer_id"`
+	CustomerID    uint                   `json:"customer_id"` // không bắt buộc, nếu gửi phải trùng user trong JWT
 	PaymentMethod string                 `json:"payment_method"` 
 	Total         float64                `json:"total"`
 	Items         []models.OrderItem     `json:"items"`
@@ -67,15 +36,20 @@ func PlaceOrderHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	if req.CustomerID == 0 || len(req.Items) == 0 {
-		http.Error(w, "Missing customer_id or items", http.StatusBadRequest)
+	if len(req.Items) == 0 {
+		http.Error(w, "Missing items", http.StatusBadRequest)
+		return
+	}
+	uid, ok := requireSameUser(w, r, uint64(req.CustomerID))
+	if !ok {
 		return
 	}
+	req.CustomerID = uid
 
 	txnRef := fmt.Sprintf("%d-%d", time.Now().UnixNano(), req.CustomerID)
 
 	order := models.Order{
-		CustomerID:    req.CustomerID,
+		CustomerID:    &req.CustomerID,
 		PaymentMethod: req.PaymentMethod,
 		Total:         req.Total,
 		Status:        "pending",
@@ -99,19 +73,8 @@ func PlaceOrderHandler(w http.ResponseWriter, r *http.Request) {
 
 	// For COD: send a confirmation email and return the order.
 	if strings.ToLower(order.PaymentMethod) == "cod" {
-		var emailItems []map[string]interface{}
-		for _, item := range order.Items {
-			emailItems = append(emailItems, map[string]interface{}{
-				"name":     item.ProductName,
-				"sku":      item.SKU,
-				"quantity": item.Quantity,
-				"price":    item.Price,
-				"size":     item.Size,
-				"color":    item.Color,
-			})
-		}
 		// Send email 
-		if err := service.SendOrderEmail(address.Email, emailItems); err != nil {
+		if err := service.SendOrderEmail(address.Email, orderEmailItems(&order)); err != nil {
 			log.Println("Failed to send order email:", err)
 		}
 
@@ -125,26 +88,7 @@ func PlaceOrderHandler(w http.ResponseWriter, r *http.Request) {
 
 	// For VNPay: create the payment URL and return it to the frontend (frontend redirect).
 	if strings.ToLower(order.PaymentMethod) == "vnpay" {
-		amount := int(math.Round(req.Total * 100)) 
-		orderInfo := fmt.Sprintf("Thanh toán đơn hàng -%s", order.TxnRef)
-
-		params := map[string]string{
-			"vnp_Version":    "2.1.0",
-			"vnp_Command":    "pay",
-			"vnp_TmnCode":    os.Getenv("VNP_TMN_CODE"),
-			"vnp_Amount":     fmt.Sprintf("%d", amount),
-			"vnp_CurrCode":   "VND",
-			"vnp_TxnRef":     order.TxnRef,
-			"vnp_OrderInfo":  orderInfo,
-			"vnp_OrderType":  "other",
-			"vnp_Locale":     "vn",
-			"vnp_ReturnUrl":  os.Getenv("VNP_RETURN_URL"),
-			"vnp_CreateDate": time.Now().Format("20060102150405"),
-			"vnp_IpAddr":     getClientIP(r),
-		}
-
-		paymentUrl := utils.CreateVnpayUrl(params, os.Getenv("VNP_HASH_SECRET"), os.Getenv("VNP_URL"))
-		log.Println("VNPay URL:", paymentUrl)
+		paymentUrl := vnpayPaymentURL(r, &order)
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -157,6 +101,47 @@ func PlaceOrderHandler(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "Invalid payment method", http.StatusBadRequest)
 }
 
+// orderEmailItems: dòng hàng cho email xác nhận đơn
+func orderEmailItems(order *models.Order) []map[string]interface{} {
+	var emailItems []map[string]interface{}
+	for _, item := range order.Items {
+		emailItems = append(emailItems, map[string]interface{}{
+			"name":     item.ProductName,
+			"sku":      item.SKU,
+			"quantity": item.Quantity,
+			"price":    item.Price,
+			"size":     item.Size,
+			"color":    item.Color,
+		})
+	}
+	return emailItems
+}
+
+// vnpayPaymentURL tạo URL thanh toán VNPay cho đơn (frontend redirect tới URL này)
+func vnpayPaymentURL(r *http.Request, order *models.Order) string {
+	amount := int(math.Round(order.Total * 100)) 
+	orderInfo := fmt.Sprintf("Thanh toán đơn hàng -%s", order.TxnRef)
+
+	params := map[string]string{
+		"vnp_Version":    "2.1.0",
+		"vnp_Command":    "pay",
+		"vnp_TmnCode":    os.Getenv("VNP_TMN_CODE"),
+		"vnp_Amount":     fmt.Sprintf("%d", amount),
+		"vnp_CurrCode":   "VND",
+		"vnp_TxnRef":     order.TxnRef,
+		"vnp_OrderInfo":  orderInfo,
+		"vnp_OrderType":  "other",
+		"vnp_Locale":     "vn",
+		"vnp_ReturnUrl":  os.Getenv("VNP_RETURN_URL"),
+		"vnp_CreateDate": time.Now().Format("20060102150405"),
+		"vnp_IpAddr":     getClientIP(r),
+	}
+
+	paymentUrl := utils.CreateVnpayUrl(params, os.Getenv("VNP_HASH_SECRET"), os.Getenv("VNP_URL"))
+	log.Println("VNPay URL:", paymentUrl)
+	return paymentUrl
+}
+
 func VnpayReturnHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	vnpData := make(map[string]string)
@@ -186,15 +171,24 @@ func VnpayReturnHandler(w http.ResponseWriter, r *http.Request) {
 		newStatus = "cancelled"
 	}
 
-	if err := customerRepo.UpdateOrderStatus(order.ID, newStatus); err != nil {
+	if newStatus == "cancelled" && order.GuestSessionID != nil {
+		// đơn khách vãng lai: hàng của đơn quay lại giỏ (hoặc trả stock nếu phiên đã hết hạn)
+		released, err := customerRepo.CancelGuestOrder(order.ID)
+		if err != nil {
+			log.Println("Failed to cancel guest order:", err)
+		}
+		if len(released) > 0 {
+			service.NotifyBackInStock(released...)
+		}
+	} else if err := customerRepo.UpdateOrderStatus(order.ID, newStatus); err != nil {
 		log.Println("Failed to update order status:", err)
 	}
-	if newStatus == "confirmed" {
-    rows, err := customerRepo.ClearCartAfterPayment(uint(order.CustomerID))
+	if newStatus == "confirmed" && order.CustomerID != nil {
+    rows, err := customerRepo.ClearCartAfterPayment(*order.CustomerID)
     if err != nil {
         log.Printf("VNPay clear cart failed: %v", err)
     } else {
-        log.Printf("VNPay clear cart success: user=%d rows=%d", order.CustomerID, rows)
+        log.Printf("VNPay clear cart success: user=%d rows=%d", *order.CustomerID, rows)
     }
 }
 
@@ -205,16 +199,10 @@ func VnpayReturnHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 
-// GET /api/customer/orders (customer)
+// GET /api/customer/me/orders
 func GetCustomerOrdersHandler(w http.ResponseWriter, r *http.Request) {
-	uidVal := r.Context().Value("userID")
-	if uidVal == nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-	userID, ok := uidVal.(uint)
+	userID, ok := currentUserID(w, r)
 	if !ok {
-		http.Error(w, "Invalid user id type in context", http.StatusInternalServerError)
 		return
 	}
 	orders, err := customerRepo.GetOrdersByCustomer(userID)
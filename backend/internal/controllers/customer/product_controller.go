@@ -2,11 +2,16 @@ package customer
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
     "github.com/gorilla/mux"
 
+	"backend/internal/models"
+	"backend/internal/repository"
 	repo "backend/internal/repository/customer"
+	"gorm.io/gorm"
 )
 
 func GetAllProducts(w http.ResponseWriter, r *http.Request) {
@@ -52,6 +57,11 @@ func GetProductDetail(w http.ResponseWriter, r *http.Request) {
 
 	product, err := repo.GetProductBySlug(slug)
 	if err != nil {
+		// slug cũ -> 301 về slug hiện tại
+		if current, rerr := repository.ResolveOldSlug(models.SlugEntityProduct, slug); rerr == nil {
+			redirectSlug(w, r, "/api/customer/product/"+url.PathEscape(current), current)
+			return
+		}
 		http.Error(w, "Product not found", http.StatusNotFound)
 		return
 	}
@@ -59,18 +69,34 @@ func GetProductDetail(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(product)
 }
-func GetBestSellersHandler(w http.ResponseWriter, r *http.Request) {
-    limitStr := r.URL.Query().Get("limit")
-    limit := 6
-    if limitStr != "" {
-        if val, err := strconv.Atoi(limitStr); err == nil && val > 0 {
-            limit = val
-        }
+// bestSellerOptions đọc ?limit=6&days=7|30|90 (0 = toàn thời gian)&category=slug
+func bestSellerOptions(r *http.Request) repo.BestSellerOptions {
+    q := r.URL.Query()
+    opt := repo.BestSellerOptions{Limit: 6, CategorySlug: q.Get("category")}
+    if val, err := strconv.Atoi(q.Get("limit")); err == nil && val > 0 {
+        opt.Limit = val
+    }
+    if val, err := strconv.Atoi(q.Get("days")); err == nil && val > 0 {
+        opt.Days = val
+    }
+    return opt
+}
+
+// bestSellerError: ?category= không tồn tại → 404, còn lại 500
+func bestSellerError(w http.ResponseWriter, err error) {
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        http.Error(w, "Category not found", http.StatusNotFound)
+        return
     }
-    bestSellers, err := repo.GetBestSellers(limit) 
+    http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// GET /api/customer/products-best-sellers?limit=6&days=30&category=ao-thun
+func GetBestSellersHandler(w http.ResponseWriter, r *http.Request) {
+    bestSellers, err := repo.GetBestSellers(bestSellerOptions(r))
 
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        bestSellerError(w, err)
         return
     }
     w.Header().Set("Content-Type", "application/json")
@@ -78,6 +104,33 @@ func GetBestSellersHandler(w http.ResponseWriter, r *http.Request) {
         "best_sellers": bestSellers,
     })
 }
+
+// GET /api/customer/products-best-sellers/by-category?limit=6&days=30
+func GetBestSellersByCategoryHandler(w http.ResponseWriter, r *http.Request) {
+    rankings, err := repo.GetBestSellersByCategory(bestSellerOptions(r))
+    if err != nil {
+        bestSellerError(w, err)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "categories": rankings,
+    })
+}
+
+// GET /api/customer/products-trending?limit=6&days=7
+func GetTrendingProductsHandler(w http.ResponseWriter, r *http.Request) {
+    trending, err := repo.GetTrendingProducts(bestSellerOptions(r))
+    if err != nil {
+        bestSellerError(w, err)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "trending": trending,
+    })
+}
+
 func GetDiscountedProductsHandler(w http.ResponseWriter, r *http.Request) {
 	products, err := repo.GetDiscountedProducts()
 	if err != nil {
@@ -90,3 +143,15 @@ func GetDiscountedProductsHandler(w http.ResponseWriter, r *http.Request) {
 		"discounted_products": products,
 	})
 }
+
+// redirectSlug trả 301 kèm body JSON để client tự cập nhật URL
+func redirectSlug(w http.ResponseWriter, r *http.Request, location, slug string) {
+	w.Header().Set("Location", location)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMovedPermanently)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"redirect": true,
+		"slug":     slug,
+		"location": location,
+	})
+}
@@ -0,0 +1,254 @@
+package customer
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+type profileResponse struct {
+	ID           uint      `json:"id"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	Phone        string    `json:"phone"`
+	Address      string    `json:"address"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+	PendingEmail string    `json:"pending_email,omitempty"` // email mới đang chờ xác nhận
+}
+
+func writeProfile(w http.ResponseWriter, user *models.User) {
+	resp := profileResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Phone:     user.Phone,
+		Address:   user.Address,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
+	if pending, err := repository.GetPendingEmailChange(user.ID); err != nil {
+		log.Println("Get pending email change failed:", err)
+	} else if pending != nil {
+		resp.PendingEmail = pending.NewEmail
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// currentUser: bản ghi User của người đang đăng nhập
+func currentUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	uid, ok := currentUserID(w, r)
+	if !ok {
+		return nil, false
+	}
+	user, err := repository.GetUserByID(uid)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return nil, false
+	}
+	return user, true
+}
+
+// GET /api/customer/me
+func GetMyProfile(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	writeProfile(w, user)
+}
+
+// PUT /api/customer/me  body: {"username", "phone", "address"}
+func UpdateMyProfile(w http.ResponseWriter, r *http.Request) {
+	uid, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Username string `json:"username"`
+		Phone    string `json:"phone"`
+		Address  string `json:"address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	req.Phone = strings.TrimSpace(req.Phone)
+	req.Address = strings.TrimSpace(req.Address)
+	for _, err := range []error{
+		utils.ValidateUsername(req.Username),
+		utils.ValidatePhone(req.Phone),
+		utils.ValidateAddress(req.Address),
+	} {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, err := repository.UpdateProfile(uid, req.Username, req.Phone, req.Address)
+	if err != nil {
+		if errors.Is(err, repository.ErrUsernameTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+		return
+	}
+	writeProfile(w, user)
+}
+
+// EmailChangeTTL: hạn của link xác nhận email mới (EMAIL_CHANGE_TTL_HOURS, mặc định 24h)
+func EmailChangeTTL() time.Duration {
+	if h, err := strconv.Atoi(os.Getenv("EMAIL_CHANGE_TTL_HOURS")); err == nil && h > 0 {
+		return time.Duration(h) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// POST /api/customer/me/email  body: {"new_email", "password"} — email chỉ đổi sau khi bấm link gửi tới email mới
+func RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		NewEmail string `json:"new_email"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	req.NewEmail = strings.ToLower(strings.TrimSpace(req.NewEmail))
+	if err := utils.ValidateEmail(req.NewEmail); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.NewEmail == user.Email {
+		http.Error(w, "New email is the same as the current one", http.StatusBadRequest)
+		return
+	}
+	if !utils.CheckPasswordHash(user.PasswordHash, req.Password) {
+		http.Error(w, "Password is incorrect", http.StatusBadRequest)
+		return
+	}
+
+	ttl := EmailChangeTTL()
+	token, err := repository.CreateEmailChangeRequest(user.ID, req.NewEmail, ttl)
+	if err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to request email change", http.StatusInternalServerError)
+		return
+	}
+	link := fmt.Sprintf("%s/api/customer/email-change/confirm?token=%s", os.Getenv("BACKEND_URL"), url.QueryEscape(token))
+	if err := service.SendEmailChangeEmail(req.NewEmail, link, ttl); err != nil {
+		http.Error(w, "Failed to send confirmation email", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Confirmation email sent to " + req.NewEmail})
+}
+
+// GET /api/customer/email-change/confirm?token= (link trong email, không cần đăng nhập)
+func ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	frontend := os.Getenv("FRONTEND_URL")
+	if _, err := repository.ConfirmEmailChange(r.URL.Query().Get("token")); err != nil {
+		reason := "error"
+		switch {
+		case errors.Is(err, repository.ErrEmailChangeInvalid):
+			reason = "invalid"
+		case errors.Is(err, repository.ErrEmailChangeExpired):
+			reason = "expired"
+		case errors.Is(err, repository.ErrEmailTaken):
+			reason = "taken"
+		default:
+			log.Println("Confirm email change failed:", err)
+		}
+		http.Redirect(w, r, frontend+"/login?email_change="+reason, http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, frontend+"/login?email_change=ok", http.StatusSeeOther)
+}
+
+// DELETE /api/customer/me  body: {"password"} — xoá tài khoản, đơn hàng cũ được giữ nhưng ẩn danh
+func DeleteMyAccount(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if user.Role != "customer" {
+		http.Error(w, "Staff accounts must be removed by an administrator", http.StatusForbidden)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if !utils.CheckPasswordHash(user.PasswordHash, req.Password) {
+		http.Error(w, "Password is incorrect", http.StatusBadRequest)
+		return
+	}
+
+	// mật khẩu ngẫu nhiên không ai biết để tài khoản ẩn danh không đăng nhập được nữa
+	random, err := utils.HashPassword(rand.Text())
+	if err != nil {
+		http.Error(w, "Failed to delete account", http.StatusInternalServerError)
+		return
+	}
+	released, err := repository.DeleteAccount(user.ID, random)
+	if err != nil {
+		if errors.Is(err, repository.ErrOpenOrders) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to delete account", http.StatusInternalServerError)
+		return
+	}
+	if len(released) > 0 {
+		service.NotifyBackInStock(released...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Account deleted"})
+}
+
+// GET /api/customer/me/export — tải toàn bộ dữ liệu cá nhân dạng JSON
+func ExportMyData(w http.ResponseWriter, r *http.Request) {
+	uid, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	data, err := repository.ExportUserData(uid)
+	if err != nil {
+		http.Error(w, "Failed to export data", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="my-data-%d.json"`, uid))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(data)
+}
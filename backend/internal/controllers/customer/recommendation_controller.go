@@ -0,0 +1,62 @@
+package customer
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"backend/internal/middlewares"
+	"backend/internal/models"
+	repo "backend/internal/repository/customer"
+
+	"github.com/gorilla/mux"
+)
+
+func recommendationLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 24 {
+		return 8
+	}
+	return limit
+}
+
+func serveRelated(w http.ResponseWriter, r *http.Request, kind string) {
+	product, err := repo.GetProductBySlug(mux.Vars(r)["slug"])
+	if err != nil {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+	products, err := repo.GetRelatedProducts(product.ID, kind, recommendationLimit(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// GET /api/customer/product/{slug}/bought-together?limit=8
+func GetBoughtTogether(w http.ResponseWriter, r *http.Request) {
+	serveRelated(w, r, models.RecommendBoughtTogether)
+}
+
+// GET /api/customer/product/{slug}/similar?limit=8
+func GetSimilarProducts(w http.ResponseWriter, r *http.Request) {
+	serveRelated(w, r, models.RecommendSimilar)
+}
+
+// GET /api/customer/recommendations?limit=8 — gợi ý theo đơn hàng và giỏ hàng của user đang đăng nhập
+func GetRecommendationsForMe(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	products, err := repo.GetRecommendationsForUser(claims.UserID, recommendationLimit(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
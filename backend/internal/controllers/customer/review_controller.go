@@ -0,0 +1,204 @@
+package customer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"backend/internal/middlewares"
+	"backend/internal/models"
+	repo "backend/internal/repository/customer"
+	"backend/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	maxReviewImages  = 5
+	maxReviewContent = 2000
+)
+
+// GET /api/customer/product/{slug}/reviews?page=1&limit=10&rating=5&sort=newest|helpful|rating_desc|rating_asc
+func GetProductReviews(w http.ResponseWriter, r *http.Request) {
+	product, err := repo.GetProductBySlug(mux.Vars(r)["slug"])
+	if err != nil {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	q := r.URL.Query()
+	opt := repo.ReviewListOptions{Page: 1, Limit: 10, Sort: q.Get("sort")}
+	if val, err := strconv.Atoi(q.Get("page")); err == nil && val > 0 {
+		opt.Page = val
+	}
+	if val, err := strconv.Atoi(q.Get("limit")); err == nil && val > 0 && val <= 50 {
+		opt.Limit = val
+	}
+	opt.Rating, _ = strconv.Atoi(q.Get("rating"))
+
+	page, err := repo.GetProductReviews(product.ID, opt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// GET /api/customer/product/{slug}/reviews/eligibility — user hiện tại có được đánh giá không
+func GetReviewEligibility(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	product, err := repo.GetProductBySlug(mux.Vars(r)["slug"])
+	if err != nil {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+	result, err := repo.GetReviewEligibility(claims.UserID, product.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// POST /api/customer/product/{slug}/reviews (multipart: rating, content, files[])
+func CreateProductReview(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	product, err := repo.GetProductBySlug(mux.Vars(r)["slug"])
+	if err != nil {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxReviewImages*service.MaxUploadBytes()+1<<20)
+	if err := r.ParseMultipartForm(service.MaxUploadBytes()); err != nil {
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+
+	rating, err := strconv.Atoi(r.FormValue("rating"))
+	if err != nil || rating < 1 || rating > 5 {
+		http.Error(w, "Rating must be between 1 and 5", http.StatusBadRequest)
+		return
+	}
+	content := strings.TrimSpace(r.FormValue("content"))
+	if utf8.RuneCountInString(content) > maxReviewContent {
+		http.Error(w, "Content too long", http.StatusBadRequest)
+		return
+	}
+	files := r.MultipartForm.File["files"]
+	if len(files) > maxReviewImages {
+		http.Error(w, "Too many images (max 5)", http.StatusBadRequest)
+		return
+	}
+
+	// kiểm tra quyền trước khi upload ảnh để không để lại file rác
+	eligibility, err := repo.GetReviewEligibility(claims.UserID, product.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !eligibility.CanReview {
+		http.Error(w, eligibility.Reason, http.StatusForbidden)
+		return
+	}
+
+	review := models.Review{ProductID: product.ID, UserID: claims.UserID, Rating: rating, Content: content}
+	store := service.DefaultStorage()
+	for i, fh := range files {
+		data, err := service.ReadUploadedFile(fh)
+		var img *service.UploadedImage
+		if err == nil {
+			img, err = service.SaveImage(r.Context(), store, "reviews", data)
+		}
+		if err != nil {
+			for _, saved := range review.Images {
+				service.DeleteImage(r.Context(), store, saved.StorageKey)
+			}
+			writeReviewUploadError(w, err)
+			return
+		}
+		review.Images = append(review.Images, models.ReviewImage{
+			StorageKey:   img.Key,
+			URL:          img.URL,
+			ThumbnailURL: img.ThumbnailURL,
+			SortOrder:    i,
+		})
+	}
+
+	if err := repo.CreateReview(&review); err != nil {
+		for _, saved := range review.Images {
+			service.DeleteImage(r.Context(), store, saved.StorageKey)
+		}
+		switch {
+		case errors.Is(err, repo.ErrReviewNotPurchased):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, repo.ErrReviewAlreadyExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(review)
+}
+
+func writeReviewUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrFileTooLarge):
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, service.ErrImageTooLarge):
+		http.Error(w, "Image dimensions too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, service.ErrUnsupportedImage):
+		http.Error(w, "Unsupported image type (jpeg, png, gif, webp)", http.StatusUnsupportedMediaType)
+	default:
+		http.Error(w, "Failed to upload image", http.StatusInternalServerError)
+	}
+}
+
+// POST /api/customer/reviews/{id}/helpful — bật/tắt vote hữu ích
+func ToggleReviewHelpful(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid review id", http.StatusBadRequest)
+		return
+	}
+
+	voted, total, err := repo.ToggleReviewHelpful(uint(id), claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrReviewNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repo.ErrReviewOwnVote):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"voted":         voted,
+		"helpful_count": total,
+	})
+}
@@ -34,15 +34,17 @@ func SearchSuggestions(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(products)
 }
 
-// GET /api/customer/search?q=...
+// GET /api/customer/search?q=...&page=1&limit=20
 func SearchProducts(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query().Get("q")
 	if q == "" {
 		http.Error(w, "Missing query", http.StatusBadRequest)
 		return
 	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 
-	products, err := repo.SearchProducts(q)
+	products, err := repo.SearchProducts(q, page, limit)
 	if err != nil {
 		http.Error(w, err.Error(), 500)
 		return
@@ -0,0 +1,45 @@
+package customer
+
+import (
+	"backend/internal/service"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// POST /api/customer/uploads/chat (multipart: file) -> URL dùng cho Message.ImageURL
+func UploadChatImage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxUploadBytes()+1<<20)
+	if err := r.ParseMultipartForm(service.MaxUploadBytes()); err != nil {
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	_, fh, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Missing file", http.StatusBadRequest)
+		return
+	}
+	data, err := service.ReadUploadedFile(fh)
+	if err == nil {
+		var img *service.UploadedImage
+		if img, err = service.SaveImage(r.Context(), service.DefaultStorage(), "messages", data); err == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(img)
+			return
+		}
+	}
+
+	switch {
+	case errors.Is(err, service.ErrFileTooLarge):
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, service.ErrImageTooLarge):
+		http.Error(w, "Image dimensions too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, service.ErrUnsupportedImage):
+		http.Error(w, "Unsupported image type (jpeg, png, gif, webp)", http.StatusUnsupportedMediaType)
+	default:
+		log.Println("Upload error:", err)
+		http.Error(w, "Failed to upload image", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,141 @@
+package customer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/middlewares"
+	repo "backend/internal/repository/customer"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+func pathID(r *http.Request, key string) (uint, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)[key], 10, 64)
+	return uint(id), err == nil && id > 0
+}
+
+// GET /api/customer/wishlist
+func GetWishlist(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	items, err := repo.GetWishlist(claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// POST /api/customer/wishlist/{productId}
+func AddToWishlist(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	productID, ok := pathID(r, "productId")
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	if err := repo.AddToWishlist(claims.UserID, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/customer/wishlist/{productId}
+func RemoveFromWishlist(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	productID, ok := pathID(r, "productId")
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	if err := repo.RemoveFromWishlist(claims.UserID, productID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/customer/stock-subscriptions — các variant đang chờ báo có hàng
+func GetStockSubscriptions(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	subs, err := repo.GetStockSubscriptions(claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+// POST /api/customer/variants/{variantId}/notify-me
+func SubscribeBackInStock(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	variantID, ok := pathID(r, "variantId")
+	if !ok {
+		http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+		return
+	}
+	sub, err := repo.SubscribeBackInStock(claims.UserID, variantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrVariantNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repo.ErrVariantInStock):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// DELETE /api/customer/variants/{variantId}/notify-me
+func UnsubscribeBackInStock(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	variantID, ok := pathID(r, "variantId")
+	if !ok {
+		http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+		return
+	}
+	if err := repo.UnsubscribeBackInStock(claims.UserID, variantID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
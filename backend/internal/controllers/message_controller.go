@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"backend/internal/middlewares"
 	"backend/internal/models"
 	"backend/internal/repository"
+	"backend/internal/service"
 	"encoding/json"           
 	"log"
 	"net/http"
@@ -16,63 +18,137 @@ import (
 
 var upgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
 
+// wsClient: gorilla/websocket chỉ cho 1 writer tại 1 thời điểm, mọi lần ghi (broadcast, push, ping) đi qua mu
+type wsClient struct {
+	conn   *websocket.Conn
+	userID uint // user trong JWT lúc kết nối
+	mu     sync.Mutex
+}
+
+func (c *wsClient) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return c.conn.WriteJSON(v)
+}
+
+func (c *wsClient) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return c.conn.WriteMessage(messageType, data)
+}
+
 type ChatHandler struct {
 	Repo *repository.MessageRepo
 
 	mu              sync.RWMutex
-	CustomerClients map[uint]*websocket.Conn
-	AdminClients    map[*websocket.Conn]bool
+	CustomerClients map[uint]*wsClient
+	AdminClients    map[*wsClient]bool
 }
 
 func NewChatHandler(repo *repository.MessageRepo) *ChatHandler {
 	return &ChatHandler{
 		Repo:            repo,
-		CustomerClients: make(map[uint]*websocket.Conn),
-		AdminClients:    make(map[*websocket.Conn]bool),
+		CustomerClients: make(map[uint]*wsClient),
+		AdminClients:    make(map[*wsClient]bool),
+	}
+}
+
+// broadcastAdmins gửi msg tới mọi admin đang kết nối, bỏ các kết nối ghi lỗi
+func (h *ChatHandler) broadcastAdmins(msg interface{}) {
+	h.mu.RLock()
+	clients := make([]*wsClient, 0, len(h.AdminClients))
+	for c := range h.AdminClients {
+		clients = append(clients, c)
+	}
+	h.mu.RUnlock()
+
+	for _, c := range clients {
+		if err := c.WriteJSON(msg); err != nil {
+			h.mu.Lock()
+			delete(h.AdminClients, c)
+			h.mu.Unlock()
+		}
+	}
+}
+
+// removeCustomer bỏ kết nối của khách, chỉ khi map vẫn trỏ đúng client này (khách có thể đã kết nối lại)
+func (h *ChatHandler) removeCustomer(customerID uint, client *wsClient) {
+	h.mu.Lock()
+	if h.CustomerClients[customerID] == client {
+		delete(h.CustomerClients, customerID)
 	}
+	h.mu.Unlock()
 }
 
 func (h *ChatHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 	role := r.URL.Query().Get("role")
-	customerIDStr := r.URL.Query().Get("customer_id")
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// role/customer_id trên query phải khớp danh tính trong JWT
+	var customerID uint
+	switch role {
+	case "customer":
+		if claims.Role != "customer" {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if idStr := r.URL.Query().Get("customer_id"); idStr != "" {
+			id, err := strconv.Atoi(idStr)
+			if err != nil || uint(id) != claims.UserID {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+		}
+		customerID = claims.UserID
+	case "admin":
+		if (claims.Role != "admin" && claims.Role != "staff") || !service.HasPermission(claims.Role, models.PermMessagesManage) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+	default:
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+		return
+	}
 
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println("WebSocket upgrade error:", err)
 		return
 	}
+	client := &wsClient{conn: conn, userID: claims.UserID}
 
-	var customerID uint
 	if role == "customer" {
-		id, err := strconv.Atoi(customerIDStr)
-		if err != nil {
-			log.Println("Invalid customer_id:", err)
-			conn.Close()
-			return
-		}
-		customerID = uint(id)
-
 		h.mu.Lock()
-		h.CustomerClients[customerID] = conn
+		old := h.CustomerClients[customerID]
+		h.CustomerClients[customerID] = client
 		h.mu.Unlock()
+		if old != nil {
+			old.conn.Close()
+		}
 
 		// Send the message history.
 		msgs, _ := h.Repo.GetMessagesByCustomer(customerID)
-		conn.WriteJSON(struct {
+		client.WriteJSON(struct {
 			Type     string           `json:"type"`
 			Messages []models.Message `json:"messages"`
 		}{
 			Type:     "history",
 			Messages: msgs,
 		})
-	} else if role == "admin" {
+	} else {
 		h.mu.Lock()
-		h.AdminClients[conn] = true
+		h.AdminClients[client] = true
 		h.mu.Unlock()
 
 		// Send recent messages
 		recentMsgs, _ := h.Repo.GetRecentMessages(200)
-		conn.WriteJSON(struct {
+		client.WriteJSON(struct {
 			Type     string           `json:"type"`
 			Messages []models.Message `json:"messages"`
 		}{
@@ -82,7 +158,7 @@ func (h *ChatHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 
 		// Send unread summary
 		if summary, err := h.Repo.GetUnreadSummary(); err == nil {
-			conn.WriteJSON(struct {
+			client.WriteJSON(struct {
 				Type    string                     `json:"type"`
 				Summary []repository.UnreadSummary `json:"unread_summary"`
 			}{
@@ -98,12 +174,19 @@ func (h *ChatHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
-		for range ticker.C {
-			if err := conn.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
+		for {
+			select {
+			case <-done:
 				return
+			case <-ticker.C:
+				if err := client.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
+					return
+				}
 			}
 		}
 	}()
@@ -120,7 +203,7 @@ func (h *ChatHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 		if role == "customer" {
 			msg.CustomerID = customerID
 			msg.SenderRole = "customer"
-		} else if role == "admin" {
+		} else {
 			msg.SenderRole = "admin"
 		}
 
@@ -140,64 +223,50 @@ func (h *ChatHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		// Broadcast to other admins (does not send back to itself)
-		h.mu.RLock()
-		for c := range h.AdminClients {
-			if err := c.WriteJSON(msg); err != nil {
-				h.mu.RUnlock()
-				h.mu.Lock()
-				delete(h.AdminClients, c)
-				h.mu.Unlock()
-				h.mu.RLock()
-			}
-		}
-		h.mu.RUnlock()
+		// Broadcast to admins
+		h.broadcastAdmins(msg)
 
 		// If admin sends -> sends to the correct corresponding customer
 		if role == "admin" && msg.CustomerID != 0 {
-			h.mu.RLock()
-			custConn, ok := h.CustomerClients[msg.CustomerID]
-			h.mu.RUnlock()
-			if ok {
-				if err := custConn.WriteJSON(msg); err != nil {
-					log.Println("Error sending to customer:", err)
-					h.mu.Lock()
-					delete(h.CustomerClients, msg.CustomerID)
-					h.mu.Unlock()
-				}
-			}
+			h.PushToCustomer(msg.CustomerID, msg)
 		}
 
 		if role == "customer" {
-			h.mu.RLock()
-			for c := range h.AdminClients {
-				if err := c.WriteJSON(msg); err != nil {
-					h.mu.RUnlock()
-					h.mu.Lock()
-					delete(h.AdminClients, c)
-					h.mu.Unlock()
-					h.mu.RLock()
-				}
-			}
-			h.mu.RUnlock()
 			// Echo back to itself
-			if err := conn.WriteJSON(msg); err != nil {
+			if err := client.WriteJSON(msg); err != nil {
 				log.Println("Echo to customer error:", err)
 			}
 		}
 	}
 
 	// Cleanup on disconnect
-	h.mu.Lock()
 	if role == "customer" {
-		delete(h.CustomerClients, customerID)
-	} else if role == "admin" {
-		delete(h.AdminClients, conn)
+		h.removeCustomer(customerID, client)
+	} else {
+		h.mu.Lock()
+		delete(h.AdminClients, client)
+		h.mu.Unlock()
 	}
-	h.mu.Unlock()
 	conn.Close()
 }
 
+// PushToCustomer gửi payload tới khách nếu đang kết nối (dùng cho thông báo có hàng...).
+// Chỉ gửi tới socket đã xác thực bằng JWT của chính customerID.
+func (h *ChatHandler) PushToCustomer(customerID uint, payload interface{}) bool {
+	h.mu.RLock()
+	client, ok := h.CustomerClients[customerID]
+	h.mu.RUnlock()
+	if !ok || client.userID != customerID {
+		return false
+	}
+	if err := client.WriteJSON(payload); err != nil {
+		log.Println("Push to customer error:", err)
+		h.removeCustomer(customerID, client)
+		return false
+	}
+	return true
+}
+
 type MessageController struct {
 	Repo *repository.MessageRepo
 }
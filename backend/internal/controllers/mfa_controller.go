@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"backend/internal/middlewares"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type mfaRequest struct {
+	MFAToken   string `json:"mfa_token"`
+	Code       string `json:"code"`
+	Password   string `json:"password"`
+	GuestToken string `json:"guest_token"`
+}
+
+func writeMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrMFAChallenge):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, service.ErrMFAInvalidCode), errors.Is(err, service.ErrMFANotEnabled), errors.Is(err, service.ErrMFANotStarted):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrMFARequiredByPolicy):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// mfaSubject: user đang đăng nhập (JWT) hoặc đang giữa bước login bị bắt đăng ký MFA (mfa_token)
+func mfaSubject(r *http.Request, mfaToken string) (*models.User, bool, error) {
+	if claims := middlewares.GetUserFromContext(r); claims != nil {
+		user, err := repository.GetUserByID(claims.UserID)
+		return user, false, err
+	}
+	user, claims, err := service.ParseMFAToken(mfaToken)
+	if err != nil {
+		return nil, false, err
+	}
+	if !claims.Enroll {
+		return nil, false, service.ErrMFAChallenge
+	}
+	return user, true, nil
+}
+
+// POST /api/auth/mfa/verify  body: {"mfa_token", "code"} — code là mã 6 số hoặc mã dự phòng
+func VerifyMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var req mfaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	user, claims, err := service.ParseMFAToken(req.MFAToken)
+	if err != nil || claims.Enroll {
+		writeMFAError(w, service.ErrMFAChallenge)
+		return
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(*user.LockedUntil).Seconds()+0.5)))
+		http.Error(w, "Account is temporarily locked, please try again later", http.StatusLocked)
+		return
+	}
+
+	if err := service.VerifyMFACode(user.ID, req.Code); err != nil {
+		if errors.Is(err, service.ErrMFAInvalidCode) {
+			// mã sai tính chung vào khoá tài khoản như sai mật khẩu
+			if _, err := repository.RecordLoginFailure(user.ID, loginLockoutPolicy()); err != nil {
+				log.Println("Record login failure failed:", err)
+			}
+			writeLoginLog(r, user, user.Email, "failed", "Invalid MFA code")
+		}
+		writeMFAError(w, err)
+		return
+	}
+
+	completeLogin(w, r, user, req.GuestToken, nil)
+}
+
+// GET /api/auth/mfa (JWT) — trạng thái MFA của tài khoản
+func GetMFAStatusHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	m, err := repository.GetUserMFA(claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	required, err := repository.IsMFARequired(claims.Role)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	remaining, err := repository.CountRecoveryCodes(claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var enabledAt *time.Time
+	if m != nil {
+		enabledAt = m.EnabledAt
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":                  enabledAt != nil,
+		"enabled_at":               enabledAt,
+		"required":                 required,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// POST /api/auth/mfa/setup (JWT hoặc body {"mfa_token"} khi bị bắt đăng ký lúc login)
+// trả về secret + otpauth_url để app authenticator quét QR
+func SetupMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var req mfaRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	user, _, err := mfaSubject(r, req.MFAToken)
+	if err != nil {
+		writeMFAError(w, service.ErrMFAChallenge)
+		return
+	}
+	secret, uri, err := service.BeginMFAEnrollment(user)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"secret":      secret,
+		"otpauth_url": uri,
+	})
+}
+
+// POST /api/auth/mfa/activate  body: {"code", "mfa_token"?}
+// bật MFA, trả về mã dự phòng; nếu đang đăng nhập dở (mfa_token) thì trả luôn phiên đăng nhập
+func ActivateMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var req mfaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	user, viaChallenge, err := mfaSubject(r, req.MFAToken)
+	if err != nil {
+		writeMFAError(w, service.ErrMFAChallenge)
+		return
+	}
+	codes, err := service.ActivateMFA(user, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	if viaChallenge {
+		completeLogin(w, r, user, req.GuestToken, map[string]interface{}{"recovery_codes": codes})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"recovery_codes": codes})
+}
+
+// POST /api/auth/mfa/recovery-codes (JWT)  body: {"code"} — cấp lại bộ mã dự phòng
+func RegenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req mfaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	codes, err := service.RegenerateRecoveryCodes(claims.UserID, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"recovery_codes": codes})
+}
+
+// DELETE /api/auth/mfa (JWT)  body: {"password", "code"}
+func DisableMFAHandler(w http.ResponseWriter, r *http.Request) {
+	claims := middlewares.GetUserFromContext(r)
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req mfaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	user, err := repository.GetUserByID(claims.UserID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if !utils.CheckPasswordHash(user.PasswordHash, req.Password) {
+		http.Error(w, "Password is incorrect", http.StatusBadRequest)
+		return
+	}
+	if err := service.DisableMFA(user, req.Code); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "MFA disabled"})
+}
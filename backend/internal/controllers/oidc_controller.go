@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"backend/internal/repository"
+	"backend/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const oidcStateCookie = "oidc_state"
+
+// oidcRedirect trả kết quả về trang login của frontend qua fragment (#...) để token không lọt vào log server/Referer
+func oidcRedirect(w http.ResponseWriter, r *http.Request, values url.Values) {
+	http.Redirect(w, r, os.Getenv("FRONTEND_URL")+"/login#"+values.Encode(), http.StatusSeeOther)
+}
+
+func oidcFail(w http.ResponseWriter, r *http.Request, reason string) {
+	oidcRedirect(w, r, url.Values{"oauth_error": {reason}})
+}
+
+// GET /api/auth/oidc/providers — danh sách nút đăng nhập ngoài
+func GetOIDCProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	list := []map[string]string{}
+	for _, p := range service.OIDCProviders() {
+		list = append(list, map[string]string{"name": p.Name, "display_name": p.DisplayName})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// GET /api/auth/oidc/{provider}/login?guest_token= — chuyển hướng sang trang đăng nhập của provider
+func OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := service.GetOIDCProvider(mux.Vars(r)["provider"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	authURL, state, err := service.OIDCAuthURL(p, r.URL.Query().Get("guest_token"))
+	if err != nil {
+		http.Error(w, "Failed to start login", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/auth/oidc",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// GET /api/auth/oidc/{provider}/callback?code=&state=
+func OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := service.GetOIDCProvider(mux.Vars(r)["provider"])
+	if err != nil {
+		oidcFail(w, r, "provider")
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("error") != "" {
+		oidcFail(w, r, "cancelled")
+		return
+	}
+
+	// state phải khớp cookie của chính trình duyệt đã bắt đầu đăng nhập
+	cookie, err := r.Cookie(oidcStateCookie)
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/api/auth/oidc", MaxAge: -1})
+	if err != nil || cookie.Value == "" || cookie.Value != q.Get("state") {
+		oidcFail(w, r, "state")
+		return
+	}
+	state, err := service.ParseOIDCState(p, q.Get("state"))
+	if err != nil {
+		oidcFail(w, r, "state")
+		return
+	}
+
+	identity, err := service.ExchangeOIDCCode(p, q.Get("code"), state)
+	if err != nil {
+		log.Printf("OIDC %s exchange failed: %v", p.Name, err)
+		oidcFail(w, r, "provider")
+		return
+	}
+	user, created, err := service.ResolveOIDCUser(identity)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrIdentityEmailUnverified):
+			oidcFail(w, r, "email_unverified")
+		case errors.Is(err, repository.ErrIdentityEmailMissing):
+			oidcFail(w, r, "email_missing")
+		case errors.Is(err, repository.ErrEmailTaken):
+			oidcFail(w, r, "email_taken")
+		case errors.Is(err, repository.ErrIdentityLinkRequired):
+			oidcFail(w, r, "link_required")
+		default:
+			log.Printf("OIDC %s resolve user failed: %v", p.Name, err)
+			oidcFail(w, r, "error")
+		}
+		return
+	}
+
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		writeLoginLog(r, user, user.Email, "blocked", "Account locked ("+p.Name+")")
+		oidcFail(w, r, "locked")
+		return
+	}
+
+	// đăng nhập ngoài thay bước mật khẩu, vẫn phải qua MFA nếu có
+	mfa, err := mfaChallenge(user)
+	if err != nil {
+		oidcFail(w, r, "error")
+		return
+	}
+	if mfa != nil {
+		writeLoginLog(r, user, user.Email, "mfa_pending", "Signed in with "+p.Name+", waiting for MFA")
+		oidcRedirect(w, r, url.Values{
+			"mfa_token":               {fmt.Sprint(mfa["mfa_token"])},
+			"mfa_required":            {fmt.Sprint(mfa["mfa_required"])},
+			"mfa_enrollment_required": {fmt.Sprint(mfa["mfa_enrollment_required"])},
+		})
+		return
+	}
+
+	message := "Login via " + p.Name
+	if created {
+		message = "Account created via " + p.Name
+	}
+	resp, err := startLogin(r, user, state.GuestToken, message)
+	if err != nil {
+		oidcFail(w, r, "error")
+		return
+	}
+	oidcRedirect(w, r, url.Values{
+		"token":         {fmt.Sprint(resp["token"])},
+		"refresh_token": {fmt.Sprint(resp["refresh_token"])},
+		"expires_in":    {fmt.Sprint(resp["expires_in"])},
+	})
+}
@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"backend/internal/models"
+	"backend/internal/service"
+	"backend/internal/utils"
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// maxAuditBody: chỉ giữ tối đa chừng này byte response để đọc id bản ghi mới tạo
+const maxAuditBody = 1 << 20
+
+// auditRecorder ghi lại status và (khi cần) body của response
+type auditRecorder struct {
+	http.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	capture bool
+}
+
+func (w *auditRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.capture && w.body.Len()+len(b) <= maxAuditBody {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Audit ghi lại ai làm gì trên bản ghi nào (trước/sau) cho route admin có path template là path
+func Audit(method, path string) mux.MiddlewareFunc {
+	rt := service.NewAuditRoute(method, path)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			entityID := vars[rt.IDVar]
+			before := rt.Entity.Snapshot(entityID)
+
+			rec := &auditRecorder{ResponseWriter: w, capture: rt.IDVar == ""}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			entityType := rt.Entity.Type
+			var after interface{}
+			if rec.status < 400 {
+				if entityID == "" {
+					entityID = service.ResponseID(rec.body.Bytes())
+				}
+				if entityID == "" && rt.Parent != nil {
+					entityType, entityID = rt.Parent.Type, vars[rt.ParentIDVar]
+					after = rt.Parent.Snapshot(entityID)
+				} else {
+					after = rt.Entity.Snapshot(entityID)
+				}
+			}
+
+			entry := &models.AuditLog{
+				Action:     rt.Action,
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				EntityType: entityType,
+				EntityID:   entityID,
+				Status:     rec.status,
+				IP:         utils.ClientIP(r),
+				UserAgent:  r.UserAgent(),
+			}
+			if claims := GetUserFromContext(r); claims != nil {
+				entry.ActorID = claims.UserID
+				entry.ActorName = claims.Username
+				entry.ActorRole = claims.Role
+			}
+			service.RecordAudit(entry, before, after)
+		})
+	}
+}
+
+// AuditWrites: Audit cho mọi route ghi (khác GET) của subrouter đăng ký bằng Use,
+// path template lấy từ route đã khớp (bỏ prefix, vd "/api/admin")
+func AuditWrites(prefix string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := mux.CurrentRoute(r)
+			if r.Method == http.MethodGet || route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			tpl, err := route.GetPathTemplate()
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			Audit(r.Method, strings.TrimPrefix(tpl, prefix))(next).ServeHTTP(w, r)
+		})
+	}
+}
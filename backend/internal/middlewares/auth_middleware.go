@@ -1,8 +1,10 @@
 package middlewares
 
 import (
+	"backend/internal/repository"
 	"backend/internal/service"
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -22,6 +24,28 @@ func GetUserFromContext(r *http.Request) *service.Claims {
 	return claims
 }
 
+// LookupTokenState tra role và token_version hiện tại của user (biến để test thay thế, không cần DB)
+var LookupTokenState = repository.GetUserTokenState
+
+// currentClaims parse token và đối chiếu token_version với DB:
+// user bị xoá, đổi role hoặc đăng xuất mọi thiết bị thì token cũ không còn dùng được
+func currentClaims(tokenStr string) (*service.Claims, error) {
+	claims, err := service.ParseToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	role, version, err := LookupTokenState(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if version != claims.TokenVersion || role != claims.Role {
+		return nil, errTokenRevoked
+	}
+	return claims, nil
+}
+
+var errTokenRevoked = errors.New("token revoked")
+
 func JWTMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -31,7 +55,7 @@ func JWTMiddleware(next http.Handler) http.Handler {
 		}
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 
-		claims, err := service.ParseToken(tokenStr)
+		claims, err := currentClaims(tokenStr)
 		if err != nil {
 			http.Error(w, `{"error":"Invalid token"}`, http.StatusUnauthorized)
 			return
@@ -43,6 +67,41 @@ func JWTMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// OptionalJWTMiddleware gắn claims nếu có token hợp lệ, không có token vẫn cho qua
+// (route public: catalog, giỏ hàng khách vãng lai)
+func OptionalJWTMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader != "" {
+			if claims, err := currentClaims(strings.TrimPrefix(authHeader, "Bearer ")); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), userContextKey, claims))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WebSocketJWTMiddleware như JWTMiddleware nhưng nhận thêm token qua ?token=
+// (WebSocket trên trình duyệt không gửi được header Authorization)
+func WebSocketJWTMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenStr := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if tokenStr == "" {
+			tokenStr = r.URL.Query().Get("token")
+		}
+		if tokenStr == "" {
+			http.Error(w, `{"error":"Missing token"}`, http.StatusUnauthorized)
+			return
+		}
+		claims, err := currentClaims(tokenStr)
+		if err != nil {
+			http.Error(w, `{"error":"Invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, claims)))
+	})
+}
+
 func RoleMiddleware(roles ...string) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -61,3 +120,21 @@ func RoleMiddleware(roles ...string) mux.MiddlewareFunc {
 		})
 	}
 }
+
+// RequirePermission chỉ cho qua khi role trong JWT được cấp quyền permission
+func RequirePermission(permission string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetUserFromContext(r)
+			if claims == nil {
+				http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			if !service.HasPermission(claims.Role, permission) {
+				http.Error(w, `{"error":"Forbidden"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
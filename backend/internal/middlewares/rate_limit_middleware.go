@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"backend/internal/ratelimit"
+	"backend/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// RateLimitByIP giới hạn số request theo IP client, vượt quá trả 429 kèm Retry-After
+func RateLimitByIP(limiter ratelimit.Limiter) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ok, retry := limiter.Allow(utils.ClientIP(r)); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds()+0.5)))
+				http.Error(w, `{"error":"Too many requests"}`, http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Attribute: thuộc tính có kiểu (material, fit, season, gender, brand...)
+type Attribute struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Code      string    `gorm:"type:varchar(50);unique;not null" json:"code"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Type      string    `gorm:"type:enum('single','multiple');default:'single'" json:"type"`
+	SortOrder int       `gorm:"default:0" json:"sort_order"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Values []AttributeValue `gorm:"foreignKey:AttributeID" json:"values,omitempty"`
+}
+
+type AttributeValue struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	AttributeID uint   `gorm:"index;not null" json:"attribute_id"`
+	Value       string `gorm:"type:varchar(100);not null" json:"value"`
+	Slug        string `gorm:"type:varchar(120);not null;index" json:"slug"`
+	SortOrder   int    `gorm:"default:0" json:"sort_order"`
+
+	Attribute *Attribute `gorm:"foreignKey:AttributeID" json:"attribute,omitempty"`
+}
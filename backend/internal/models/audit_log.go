@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog: một thao tác ghi (POST/PUT/PATCH/DELETE) của admin/staff trên /api/admin
+type AuditLog struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	ActorID    uint            `gorm:"index" json:"actor_id"`
+	ActorName  string          `gorm:"type:varchar(255)" json:"actor_name"`
+	ActorRole  string          `gorm:"type:varchar(20)" json:"actor_role"`
+	Action     string          `gorm:"type:varchar(100);index" json:"action"` // vd: product.update, user.restore
+	Method     string          `gorm:"type:varchar(10)" json:"method"`
+	Path       string          `gorm:"type:varchar(255)" json:"path"`
+	EntityType string          `gorm:"type:varchar(50);index:idx_audit_entity" json:"entity_type"`
+	EntityID   string          `gorm:"type:varchar(64);index:idx_audit_entity" json:"entity_id"`
+	Before     json.RawMessage `gorm:"type:longtext" json:"before,omitempty"` // JSON bản ghi trước khi sửa
+	After      json.RawMessage `gorm:"type:longtext" json:"after,omitempty"`  // JSON bản ghi sau khi sửa
+	Diff       json.RawMessage `gorm:"type:longtext" json:"diff,omitempty"`   // JSON {field: {from, to}} các field thay đổi
+	Status     int             `json:"status"`                                // HTTP status trả về
+	IP         string          `gorm:"type:varchar(64)" json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	CreatedAt  time.Time       `gorm:"index" json:"created_at"`
+}
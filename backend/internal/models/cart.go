@@ -5,6 +5,7 @@ import "time"
 type CartItem struct {
     ID              uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
     UserID          uint64    `gorm:"index;not null" json:"userId"`          
+    SessionID       *uint     `gorm:"index" json:"-"` // giỏ của khách vãng lai (UserID = 0)
     ProductName     string    `gorm:"not null" json:"productName"`    
 	VariantID  uint64    `gorm:"not null" json:"variantId"`        
     Quantity        int       `gorm:"not null;default:1" json:"quantity"`
@@ -1,13 +1,22 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Category struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"unique;not null" json:"name"`
-	Slug      string    `gorm:"unique;not null" json:"slug"`
-	GroupName string    `gorm:"type:enum('Quần Nam','Áo Nam','Đồ Thể Thao','Đồ Bộ','Phụ Kiện');not null;default:'Đồ nam'" json:"group_name"`
-	CreatedAt time.Time `json:"created_at"`
+	Name      string    `gorm:"type:varchar(255);unique;not null" json:"name"`
+	Slug      string    `gorm:"type:varchar(255);unique;not null" json:"slug"`
+	ParentID  *uint     `gorm:"index" json:"parent_id"`
+	SortOrder int       `gorm:"default:0" json:"sort_order"`
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 
-	Products []Product `gorm:"foreignKey:CategoryID"`
+	Parent     *Category   `gorm:"foreignKey:ParentID" json:"-"`
+	Children   []Category  `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+	Products   []Product   `gorm:"foreignKey:CategoryID"`
+	Attributes []Attribute `gorm:"many2many:category_attributes;joinForeignKey:CategoryID;joinReferences:AttributeID" json:"attributes,omitempty"`
 }
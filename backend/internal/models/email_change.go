@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// EmailChangeRequest: yêu cầu đổi email, chỉ áp dụng khi bấm link gửi tới email mới
+type EmailChangeRequest struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	NewEmail    string     `gorm:"type:varchar(255);not null" json:"new_email"`
+	TokenHash   string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
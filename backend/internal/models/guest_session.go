@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// GuestSession: phiên của khách chưa đăng nhập, token gửi qua header X-Guest-Token.
+// Giỏ hàng của khách là các cart_items có session_id trỏ tới phiên này.
+type GuestSession struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	Token     string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"token"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"-"`
+}
@@ -6,6 +6,7 @@ type LoginLog struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	UserID    uint      `json:"user_id"`
 	Role      string    `json:"role"`
+	Email     string    `gorm:"type:varchar(255);index" json:"email"` // email đã nhập, kể cả khi không có tài khoản
 	IP        string    `json:"ip"`
 	UserAgent string    `json:"user_agent"`
 	Status    string    `json:"status"` 
@@ -4,7 +4,7 @@ import "time"
 
 type Order struct {
 	ID            uint        `gorm:"primaryKey" json:"id"`
-	CustomerID    uint        `json:"customer_id"`
+	CustomerID    *uint       `json:"customer_id"` // nil = đơn của khách vãng lai
 	StaffID       *uint       `json:"staff_id"` 
 	Status        string      `gorm:"type:enum('pending','confirmed','shipped','completed','cancelled');default:'pending'" json:"status"`
 	PaymentMethod string      `gorm:"type:enum('cod','vnpay');default:'cod'" json:"payment_method"`
@@ -12,6 +12,13 @@ type Order struct {
 	Total         float64     `json:"total"`
 	CreatedAt     time.Time   `json:"created_at"`
 
+	// Thông tin giao hàng của khách vãng lai (không có customer_addresses)
+	GuestName      string `gorm:"type:varchar(255)" json:"guest_name,omitempty"`
+	GuestEmail     string `gorm:"type:varchar(255);index" json:"guest_email,omitempty"`
+	GuestPhone     string `gorm:"type:varchar(20)" json:"guest_phone,omitempty"`
+	GuestAddress   string `gorm:"type:varchar(255)" json:"guest_address,omitempty"`
+	GuestSessionID *uint  `gorm:"index" json:"-"`
+
 	Customer User         `gorm:"foreignKey:CustomerID"` 
 	Staff    *User        `gorm:"foreignKey:StaffID"`    
 	Items    []OrderItem  `gorm:"foreignKey:OrderID"`   
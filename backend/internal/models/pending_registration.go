@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// PendingRegistration: đăng ký chờ xác nhận email. Giữ lại sau khi xác nhận (ConfirmedAt)
+// để link cũ báo "đã kích hoạt" thay vì lỗi chung chung.
+type PendingRegistration struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Username     string     `gorm:"type:varchar(255);not null;index" json:"username"`
+	Email        string     `gorm:"type:varchar(255);not null;uniqueIndex" json:"email"`
+	Phone        string     `gorm:"type:varchar(20)" json:"phone"`
+	Address      string     `gorm:"type:varchar(255)" json:"address"`
+	PasswordHash string     `gorm:"not null" json:"-"`
+	TokenHash    string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
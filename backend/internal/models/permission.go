@@ -0,0 +1,35 @@
+package models
+
+// Quyền thao tác trong trang quản trị, gán cho role qua bảng role_permissions
+const (
+	PermUsersManage       = "users:manage"
+	PermSuppliersManage   = "suppliers:manage"
+	PermPurchasesManage   = "purchases:manage"
+	PermCatalogRead       = "catalog:read"
+	PermCatalogWrite      = "catalog:write"
+	PermInventoryRead     = "inventory:read"
+	PermInventoryAdjust   = "inventory:adjust"
+	PermOrdersRead        = "orders:read"
+	PermOrdersWrite       = "orders:write"
+	PermReviewsModerate   = "reviews:moderate"
+	PermSearchManage      = "search:manage"
+	PermReportsExport     = "reports:export"
+	PermMessagesManage    = "messages:manage"
+	PermPermissionsManage = "permissions:manage"
+	PermAuditRead         = "audit:read"
+)
+
+// AllPermissions: danh sách đầy đủ, dùng để validate khi cấu hình
+var AllPermissions = []string{
+	PermUsersManage, PermSuppliersManage, PermPurchasesManage,
+	PermCatalogRead, PermCatalogWrite, PermInventoryRead, PermInventoryAdjust,
+	PermOrdersRead, PermOrdersWrite, PermReviewsModerate, PermSearchManage,
+	PermReportsExport, PermMessagesManage, PermPermissionsManage, PermAuditRead,
+}
+
+// RolePermission: một quyền được cấp cho một role (admin luôn có toàn quyền, không phụ thuộc bảng này)
+type RolePermission struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	Role       string `gorm:"type:enum('admin','staff','customer');not null;uniqueIndex:idx_role_permission" json:"role"`
+	Permission string `gorm:"size:64;not null;uniqueIndex:idx_role_permission" json:"permission"`
+}
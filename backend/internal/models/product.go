@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Product struct {
 	ID              uint    `gorm:"primaryKey" json:"id"`
@@ -13,8 +17,19 @@ type Product struct {
 	Slug            string  `gorm:"unique;not null" json:"slug"`
 	DiscountedPrice float64 `json:"discounted_price" gorm:"->"`
 
-	CreatedAt time.Time `json:"created_at"`
+	// Tổng hợp từ review đã duyệt, cập nhật khi admin duyệt / xoá review
+	RatingAvg   float64 `gorm:"type:decimal(3,2);default:0" json:"rating_avg"`
+	RatingCount int     `gorm:"default:0" json:"rating_count"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"` // lưu trữ: ẩn với khách, đơn cũ vẫn hiển thị
 
 	Category Category         `gorm:"foreignKey:CategoryID"`
 	Variants []ProductVariant `gorm:"foreignKey:ProductID"`
+	Images   []ProductImage   `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+
+	// Đường dẫn category từ gốc tới category của sản phẩm, không lưu DB
+	Breadcrumb []Category `gorm:"-" json:"breadcrumb,omitempty"`
+
+	AttributeValues []AttributeValue `gorm:"many2many:product_attribute_values;joinForeignKey:ProductID;joinReferences:AttributeValueID" json:"attributes,omitempty"`
 }
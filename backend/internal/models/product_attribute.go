@@ -0,0 +1,13 @@
+package models
+
+// CategoryAttribute: thuộc tính nào áp dụng cho category nào
+type CategoryAttribute struct {
+	CategoryID  uint `gorm:"primaryKey" json:"category_id"`
+	AttributeID uint `gorm:"primaryKey" json:"attribute_id"`
+}
+
+// ProductAttributeValue: giá trị thuộc tính gán cho sản phẩm
+type ProductAttributeValue struct {
+	ProductID        uint `gorm:"primaryKey" json:"product_id"`
+	AttributeValueID uint `gorm:"primaryKey" json:"attribute_value_id"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type ProductImage struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ProductID    uint      `gorm:"index;not null" json:"product_id"`
+	StorageKey   string    `gorm:"type:varchar(255);not null" json:"-"`
+	URL          string    `gorm:"type:varchar(500);not null" json:"url"`
+	ThumbnailURL string    `gorm:"type:varchar(500)" json:"thumbnail_url"`
+	WebPURL      string    `gorm:"column:webp_url;type:varchar(500)" json:"webp_url"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	SortOrder    int       `gorm:"default:0" json:"sort_order"`
+	CreatedAt    time.Time `json:"created_at"`
+}
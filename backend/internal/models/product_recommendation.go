@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+const (
+	RecommendBoughtTogether = "bought_together"
+	RecommendSimilar        = "similar"
+)
+
+// ProductRecommendation: sản phẩm liên quan đã tính sẵn bởi job nền
+type ProductRecommendation struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	ProductID        uint      `gorm:"not null;index:idx_product_recommendation" json:"product_id"`
+	Kind             string    `gorm:"type:enum('bought_together','similar');not null;index:idx_product_recommendation" json:"kind"`
+	RelatedProductID uint      `gorm:"not null" json:"related_product_id"`
+	Score            float64   `gorm:"not null" json:"score"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -1,15 +1,22 @@
 package models
 
+import "gorm.io/gorm"
+
 type ProductVariant struct {
 	ID        uint    `gorm:"primaryKey" json:"id"`
 	ProductID uint    `json:"product_id"`
 	Size      string  `json:"size"`
 	Color     string  `json:"color"`
+	SizeID    *uint   `gorm:"index" json:"size_id"`
+	ColorID   *uint   `gorm:"index" json:"color_id"`
 	Price     float64 `json:"price"`
 	Stock     int     `json:"stock"`
 	SKU       string  `json:"sku"`
     Image       string    `json:"image"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 	Product Product `gorm:"foreignKey:ProductID"`
+	SizeOption    *SizeOption    `gorm:"foreignKey:SizeID" json:"size_option,omitempty"`
+	ColorOption   *ColorOption   `gorm:"foreignKey:ColorID" json:"color_option,omitempty"`
 	OrderItems    []OrderItem    `gorm:"foreignKey:VariantID"`
 	Purchases     []Purchase     `gorm:"foreignKey:VariantID"`
 	InventoryLogs []InventoryLog `gorm:"foreignKey:VariantID"`
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RefreshToken: chỉ lưu sha256 của token. Mỗi lần refresh sinh token mới cùng FamilyID,
+// token cũ bị thu hồi; dùng lại token đã thu hồi => thu hồi cả family.
+type RefreshToken struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID     string     `gorm:"type:char(32);not null;index" json:"family_id"`
+	TokenHash    string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uint      `json:"replaced_by_id"`
+	IP           string     `gorm:"type:varchar(64)" json:"ip"`
+	UserAgent    string     `gorm:"type:varchar(255)" json:"user_agent"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Review: đánh giá của khách đã mua hàng (có đơn completed chứa variant của sản phẩm)
+type Review struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ProductID    uint      `gorm:"not null;uniqueIndex:idx_review_product_user;index" json:"product_id"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_review_product_user" json:"user_id"`
+	OrderID      uint      `gorm:"not null" json:"order_id"`
+	Rating       int       `gorm:"type:tinyint;not null" json:"rating"`
+	Content      string    `gorm:"type:text" json:"content"`
+	Status       string    `gorm:"type:enum('pending','approved','rejected');default:'pending';index" json:"status"`
+	HelpfulCount int       `gorm:"not null;default:0" json:"helpful_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	Reviewer string        `gorm:"-" json:"reviewer"`
+	Verified bool          `gorm:"-" json:"verified_purchase"`
+	User     *User         `gorm:"foreignKey:UserID" json:"-"`
+	Product  *Product      `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Images   []ReviewImage `gorm:"foreignKey:ReviewID" json:"images"`
+	Replies  []ReviewReply `gorm:"foreignKey:ReviewID" json:"replies"`
+}
+
+type ReviewImage struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	ReviewID     uint   `gorm:"index;not null" json:"review_id"`
+	StorageKey   string `gorm:"type:varchar(255)" json:"-"`
+	URL          string `gorm:"type:varchar(500);not null" json:"url"`
+	ThumbnailURL string `gorm:"type:varchar(500)" json:"thumbnail_url"`
+	SortOrder    int    `gorm:"default:0" json:"sort_order"`
+}
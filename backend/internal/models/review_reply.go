@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ReviewReply: phản hồi của nhân viên cho 1 review
+type ReviewReply struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ReviewID  uint      `gorm:"index;not null" json:"review_id"`
+	StaffID   uint      `gorm:"not null" json:"staff_id"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+
+	StaffName string `gorm:"-" json:"staff_name"`
+	Staff     *User  `gorm:"foreignKey:StaffID" json:"-"`
+}
+
+// ReviewVote: mỗi user chỉ vote "hữu ích" 1 lần cho 1 review
+type ReviewVote struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ReviewID  uint      `gorm:"not null;uniqueIndex:idx_review_vote" json:"review_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_review_vote" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
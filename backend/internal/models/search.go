@@ -1,8 +1,26 @@
 package models
 
-type SearchResult struct {
-    ID   int    `json:"id"`
-    Name string `json:"name"`
-    Type string `json:"type"`
-    Slug string `json:"slug"`
+// AdminSearchHit: 1 kết quả trong tìm kiếm toàn cục của admin
+type AdminSearchHit struct {
+	ID       uint                   `json:"id"`
+	Type     string                 `json:"type"`
+	Name     string                 `json:"name"`
+	Subtitle string                 `json:"subtitle,omitempty"`
+	Slug     string                 `json:"slug,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// AdminSearchGroup: kết quả theo từng loại, phân trang riêng
+type AdminSearchGroup struct {
+	Type  string           `json:"type"`
+	Total int64            `json:"total"`
+	Page  int              `json:"page"`
+	Limit int              `json:"limit"`
+	Items []AdminSearchHit `json:"items"`
+}
+
+type AdminSearchResponse struct {
+	Query    string             `json:"query"`
+	Detected []string           `json:"detected"`
+	Groups   []AdminSearchGroup `json:"groups"`
 }
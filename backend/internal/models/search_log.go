@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	SearchSourceSearch  = "search"
+	SearchSourceSuggest = "suggest"
+	SearchSourceChatbot = "chatbot"
+)
+
+// SearchLog ghi lại mỗi lần khách tìm kiếm để thống kê
+type SearchLog struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Query           string    `gorm:"type:varchar(255);not null" json:"query"`
+	NormalizedQuery string    `gorm:"type:varchar(255);not null;index" json:"normalized_query"`
+	Source          string    `gorm:"type:enum('search','suggest','chatbot');not null;index" json:"source"`
+	ResultCount     int       `gorm:"not null;default:0" json:"result_count"`
+	CreatedAt       time.Time `gorm:"index" json:"created_at"`
+}
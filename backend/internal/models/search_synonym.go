@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// SearchSynonym: nhóm từ đồng nghĩa, vd ["quần bò", "jeans"]. Mọi từ trong nhóm tương đương nhau.
+type SearchSynonym struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Terms     []string  `gorm:"type:text;serializer:json;not null" json:"terms"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SlugHistory lưu slug cũ của product/category/supplier để redirect link đã chia sẻ
+type SlugHistory struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EntityType string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_slug_history_type_slug;index:idx_slug_history_entity" json:"entity_type"`
+	EntityID   uint      `gorm:"not null;index:idx_slug_history_entity" json:"entity_id"`
+	Slug       string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_slug_history_type_slug" json:"slug"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const (
+	SlugEntityProduct  = "product"
+	SlugEntityCategory = "category"
+	SlugEntitySupplier = "supplier"
+)
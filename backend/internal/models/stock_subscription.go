@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// StockSubscription: "báo khi có hàng" cho 1 variant đang hết hàng.
+// NotifiedAt != nil nghĩa là đã gửi thông báo, đăng ký lại sẽ reset về nil.
+type StockSubscription struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;uniqueIndex:idx_stock_sub_user_variant" json:"user_id"`
+	VariantID  uint       `gorm:"not null;uniqueIndex:idx_stock_sub_user_variant;index" json:"variant_id"`
+	NotifiedAt *time.Time `gorm:"index" json:"notified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Variant *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Supplier struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
@@ -9,7 +13,8 @@ type Supplier struct {
 	Email     string    `json:"email"`
 	Address   string    `json:"address"`
 	Slug      string    `gorm:"unique;not null" json:"slug"`
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 
 	Purchases []Purchase `gorm:"foreignKey:SupplierID"`
 }
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
@@ -10,7 +14,14 @@ type User struct {
 	Email        string    `gorm:"type:varchar(255);unique;not null" json:"email"`
 	Phone        string    `gorm:"type:varchar(20)" json:"phone"`
 	Address      string    `gorm:"type:varchar(255)" json:"address"`
+	// tăng lên mỗi khi cần vô hiệu hoá mọi access token đã cấp (đổi role, đăng xuất mọi thiết bị)
+	TokenVersion uint      `gorm:"not null;default:0" json:"-"`
+	// đăng nhập sai liên tiếp, đủ ngưỡng thì khoá tới LockedUntil (thời gian khoá tăng dần)
+	FailedLogins int        `gorm:"not null;default:0" json:"failed_logins"`
+	LockedUntil  *time.Time `json:"locked_until"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	// xoá mềm: đơn hàng, phiếu nhập cũ vẫn tham chiếu được
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 	CustomerAddresses []CustomerAddress `gorm:"foreignKey:UserID"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// UserMFA: secret TOTP (mã hoá AES-GCM) của user. EnabledAt nil = đang đăng ký, chưa xác nhận mã đầu tiên.
+type UserMFA struct {
+	UserID       uint       `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	Secret       string     `gorm:"type:varchar(255);not null" json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	LastUsedStep int64      `gorm:"not null;default:0" json:"-"` // bước TOTP đã dùng gần nhất, chống dùng lại mã
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// MFARecoveryCode: mã dự phòng dùng một lần khi mất thiết bị, chỉ lưu sha256
+type MFARecoveryCode struct {
+	ID       uint       `gorm:"primaryKey" json:"id"`
+	UserID   uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// RoleMFAPolicy: role nào bắt buộc bật MFA
+type RoleMFAPolicy struct {
+	Role     string `gorm:"primaryKey;type:varchar(20)" json:"role"`
+	Required bool   `gorm:"not null;default:false" json:"required"`
+}
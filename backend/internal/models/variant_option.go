@@ -0,0 +1,15 @@
+package models
+
+// SizeOption / ColorOption chuẩn hoá size, màu của ProductVariant
+type SizeOption struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	Name      string `gorm:"type:varchar(50);unique;not null" json:"name"`
+	SortOrder int    `gorm:"default:0" json:"sort_order"`
+}
+
+type ColorOption struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	Name      string `gorm:"type:varchar(50);unique;not null" json:"name"`
+	Hex       string `gorm:"type:varchar(7)" json:"hex"`
+	SortOrder int    `gorm:"default:0" json:"sort_order"`
+}
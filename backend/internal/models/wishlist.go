@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// WishlistItem: sản phẩm khách lưu lại để mua sau
+type WishlistItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_wishlist_user_product" json:"user_id"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_wishlist_user_product;index" json:"product_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	storeMu sync.RWMutex
+	store   Store
+)
+
+// SetStore thay backend lưu trữ (mặc định in-memory)
+func SetStore(s Store) {
+	storeMu.Lock()
+	store = s
+	storeMu.Unlock()
+}
+
+func currentStore() Store {
+	storeMu.RLock()
+	s := store
+	storeMu.RUnlock()
+	if s != nil {
+		return s
+	}
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if store == nil {
+		store = NewMemoryStore(24 * time.Hour)
+	}
+	return store
+}
+
+// Limiter: tối đa Limit lần trong Window cho mỗi key
+type Limiter struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// Allow ghi nhận một lần gọi; bị chặn thì trả về thời gian cần chờ.
+// Store lỗi thì cho qua (fail open) để không khoá cả hệ thống đăng nhập.
+func (l Limiter) Allow(key string) (bool, time.Duration) {
+	if l.Limit <= 0 {
+		return true, 0
+	}
+	count, oldest, err := currentStore().Hit(l.Name+":"+key, l.Window, l.Limit)
+	if err != nil {
+		log.Printf("Rate limit store error (%s): %v", l.Name, err)
+		return true, 0
+	}
+	if count <= l.Limit {
+		return true, 0
+	}
+	retry := time.Until(oldest.Add(l.Window))
+	if retry < time.Second {
+		retry = time.Second
+	}
+	return false, retry
+}
+
+// Reset xoá bộ đếm của key
+func (l Limiter) Reset(key string) {
+	if err := currentStore().Reset(l.Name + ":" + key); err != nil {
+		log.Printf("Rate limit reset error (%s): %v", l.Name, err)
+	}
+}
+
+// FromEnv tạo limiter với Limit đọc từ biến môi trường env (không có thì dùng def, 0 = tắt)
+func FromEnv(name, env string, def int, window time.Duration) Limiter {
+	limit := def
+	if v, err := strconv.Atoi(os.Getenv(env)); err == nil && v >= 0 {
+		limit = v
+	}
+	return Limiter{Name: name, Limit: limit, Window: window}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func useMemoryStore(t *testing.T) *MemoryStore {
+	t.Helper()
+	s := NewMemoryStore(time.Hour)
+	SetStore(s)
+	t.Cleanup(func() { SetStore(nil) })
+	return s
+}
+
+func TestLimiterSlidingWindow(t *testing.T) {
+	useMemoryStore(t)
+	l := Limiter{Name: "test", Limit: 3, Window: 200 * time.Millisecond}
+
+	for i := 1; i <= 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("hit %d blocked", i)
+		}
+	}
+	ok, retry := l.Allow("a")
+	if ok {
+		t.Fatal("4th hit allowed")
+	}
+	if retry < time.Second {
+		t.Errorf("retry = %v, want at least 1s", retry)
+	}
+	// key khác có bộ đếm riêng
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("other key blocked")
+	}
+
+	// hết cửa sổ thì được gọi lại
+	time.Sleep(250 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("hit after window blocked")
+	}
+}
+
+func TestLimiterReset(t *testing.T) {
+	useMemoryStore(t)
+	l := Limiter{Name: "test", Limit: 1, Window: time.Minute}
+	l.Allow("a")
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("2nd hit allowed")
+	}
+	l.Reset("a")
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("hit after Reset blocked")
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	useMemoryStore(t)
+	l := Limiter{Name: "test", Limit: 0, Window: time.Minute}
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatal("limit 0 should disable the limiter")
+		}
+	}
+}
+
+// lần bị chặn không được ghi vào log: log không vượt limit, spam không kéo dài thời gian chặn
+func TestMemoryStoreDoesNotLogRejectedHits(t *testing.T) {
+	s := useMemoryStore(t)
+	window := 200 * time.Millisecond
+
+	first := time.Now()
+	for i := 0; i < 2; i++ {
+		s.Hit("k", window, 2)
+	}
+	for i := 0; i < 100; i++ {
+		count, oldest, _ := s.Hit("k", window, 2)
+		if count != 3 {
+			t.Fatalf("rejected hit count = %d, want 3", count)
+		}
+		if oldest.Before(first) {
+			t.Fatalf("oldest %v before first hit %v", oldest, first)
+		}
+	}
+	s.mu.Lock()
+	n := len(s.hits["k"])
+	s.mu.Unlock()
+	if n != 2 {
+		t.Errorf("log has %d entries, want 2", n)
+	}
+
+	// hai lần đầu hết hạn là gọi được, không bị kéo dài bởi các lần bị chặn
+	time.Sleep(250 * time.Millisecond)
+	if count, _, _ := s.Hit("k", window, 2); count != 1 {
+		t.Errorf("count after window = %d, want 1", count)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("TEST_RATE_LIMIT", "")
+	if l := FromEnv("x", "TEST_RATE_LIMIT", 30, time.Minute); l.Limit != 30 {
+		t.Errorf("default limit = %d, want 30", l.Limit)
+	}
+	t.Setenv("TEST_RATE_LIMIT", "0")
+	if l := FromEnv("x", "TEST_RATE_LIMIT", 30, time.Minute); l.Limit != 0 {
+		t.Errorf("limit = %d, want 0 (disabled)", l.Limit)
+	}
+	t.Setenv("TEST_RATE_LIMIT", "-1")
+	if l := FromEnv("x", "TEST_RATE_LIMIT", 30, time.Minute); l.Limit != 30 {
+		t.Errorf("negative limit = %d, want default 30", l.Limit)
+	}
+}
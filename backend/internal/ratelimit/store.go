@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Store lưu log các lần gọi theo key (sliding window log).
+// Bản in-memory dùng cho 1 instance; chạy nhiều instance thì cài Store trên Redis
+// (ZREMRANGEBYSCORE + ZCARD, chưa đủ limit thì ZADD, ZRANGE 0 0) rồi gắn bằng SetStore.
+type Store interface {
+	// Hit ghi nhận một lần gọi, trả về số lần trong cửa sổ (tính cả lần này) và thời điểm lần cũ nhất còn trong cửa sổ.
+	// Lần bị chặn (đã đủ limit) không được ghi vào log, để log không vượt quá limit và spam không kéo dài thời gian chặn.
+	Hit(key string, window time.Duration, limit int) (count int, oldest time.Time, err error)
+	// Reset xoá log của key (vd đăng nhập thành công)
+	Reset(key string) error
+}
+
+type MemoryStore struct {
+	mu   sync.Mutex
+	hits map[string][]time.Time
+}
+
+// NewMemoryStore tạo store trong bộ nhớ, tự dọn key không dùng quá maxIdle
+func NewMemoryStore(maxIdle time.Duration) *MemoryStore {
+	s := &MemoryStore{hits: map[string][]time.Time{}}
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.sweep(maxIdle)
+		}
+	}()
+	return s
+}
+
+func (s *MemoryStore) Hit(key string, window time.Duration, limit int) (int, time.Time, error) {
+	now := time.Now()
+	cutoff := now.Add(-window)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log := s.hits[key]
+	i := 0
+	for i < len(log) && !log[i].After(cutoff) {
+		i++
+	}
+	log = log[i:]
+	if len(log) >= limit && len(log) > 0 {
+		s.hits[key] = log
+		return len(log) + 1, log[0], nil
+	}
+	log = append(log, now)
+	s.hits[key] = log
+	return len(log), log[0], nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	delete(s.hits, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) sweep(maxIdle time.Duration) {
+	cutoff := time.Now().Add(-maxIdle)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, log := range s.hits {
+		if len(log) == 0 || log[len(log)-1].Before(cutoff) {
+			delete(s.hits, key)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package admin
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"backend/internal/search"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Xoá mềm (lưu trữ): User, Product, ProductVariant, Category, Supplier có DeletedAt.
+// Bản ghi còn được đơn hàng / phiếu nhập / log kho tham chiếu thì chỉ lưu trữ, không còn gì tham chiếu thì xoá hẳn.
+var (
+	ErrCategoryInUse  = errors.New("category still has active subcategories or products")
+	ErrParentArchived = errors.New("parent record is archived, restore it first")
+	ErrLastAdmin      = errors.New("cannot delete the last active admin")
+)
+
+// countRefs đếm số dòng của từng bảng tham chiếu tới id qua cột column
+func countRefs(tx *gorm.DB, id interface{}, refs map[string]string) (int64, error) {
+	var total int64
+	for table, column := range refs {
+		var n int64
+		if err := tx.Table(table).Where(column+" IN (?)", id).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// variantHistoryRefs: bảng lịch sử phải giữ nguyên variant (giỏ hàng, đăng ký báo hàng thì không)
+var variantHistoryRefs = map[string]string{
+	"order_items":    "variant_id",
+	"purchases":      "variant_id",
+	"inventory_logs": "variant_id",
+}
+
+// releaseCarts xoá các dòng giỏ hàng (cả giỏ khách vãng lai) khớp điều kiện và trả lại stock chúng đang giữ
+// (giỏ đã trừ stock lúc thêm vào). Trả về các variant được cộng stock.
+func releaseCarts(tx *gorm.DB, query string, args ...interface{}) ([]uint, error) {
+	var held []struct {
+		VariantID uint64
+		Quantity  int
+	}
+	if err := tx.Model(&models.CartItem{}).Select("variant_id, SUM(quantity) AS quantity").
+		Where(query, args...).Group("variant_id").Scan(&held).Error; err != nil {
+		return nil, err
+	}
+	released := make([]uint, 0, len(held))
+	for _, h := range held {
+		if err := tx.Unscoped().Model(&models.ProductVariant{}).Where("id = ?", h.VariantID).
+			UpdateColumn("stock", gorm.Expr("stock + ?", h.Quantity)).Error; err != nil {
+			return nil, err
+		}
+		released = append(released, uint(h.VariantID))
+	}
+	if err := tx.Where(query, args...).Delete(&models.CartItem{}).Error; err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+// dropVariantsFromCarts bỏ variant đã lưu trữ khỏi giỏ hàng (trả stock để khôi phục không bị hụt) và danh sách "báo khi có hàng"
+func dropVariantsFromCarts(tx *gorm.DB, variantIDs interface{}) error {
+	if _, err := releaseCarts(tx, "variant_id IN (?)", variantIDs); err != nil {
+		return err
+	}
+	return tx.Where("variant_id IN (?)", variantIDs).Delete(&models.StockSubscription{}).Error
+}
+
+// MigrateSupplierSoftDelete thêm cột deleted_at (kèm index) cho bảng suppliers — bảng này không nằm trong AutoMigrate
+func MigrateSupplierSoftDelete() error {
+	m := configs.DB.Migrator()
+	if !m.HasColumn(&models.Supplier{}, "DeletedAt") {
+		if err := m.AddColumn(&models.Supplier{}, "DeletedAt"); err != nil {
+			return err
+		}
+	}
+	if !m.HasIndex(&models.Supplier{}, "DeletedAt") {
+		return m.CreateIndex(&models.Supplier{}, "DeletedAt")
+	}
+	return nil
+}
+
+// ================= ARCHIVED LISTS =================
+
+func GetArchivedUsers() ([]models.User, error) {
+	var users []models.User
+	err := configs.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&users).Error
+	return users, err
+}
+
+func GetArchivedProducts() ([]models.Product, error) {
+	var products []models.Product
+	err := configs.DB.Unscoped().
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&products).Error
+	return products, err
+}
+
+// GetArchivedVariants: variant bị lưu trữ riêng lẻ của sản phẩm đang bán
+func GetArchivedVariants(productID uint) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	err := configs.DB.Unscoped().Where("product_id = ? AND deleted_at IS NOT NULL", productID).
+		Order("deleted_at DESC").Find(&variants).Error
+	return variants, err
+}
+
+func GetArchivedCategories() ([]models.Category, error) {
+	var categories []models.Category
+	err := configs.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&categories).Error
+	return categories, err
+}
+
+func GetArchivedSuppliers() ([]models.Supplier, error) {
+	var suppliers []models.Supplier
+	err := configs.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&suppliers).Error
+	return suppliers, err
+}
+
+// ================= RESTORE =================
+
+// findArchived lấy bản ghi đang bị lưu trữ, không có thì gorm.ErrRecordNotFound
+func findArchived(tx *gorm.DB, dest interface{}, id uint) error {
+	return tx.Unscoped().Where("deleted_at IS NOT NULL").First(dest, id).Error
+}
+
+// isActive: bản ghi tồn tại và chưa bị lưu trữ
+func isActive(tx *gorm.DB, model interface{}, id uint) (bool, error) {
+	var n int64
+	err := tx.Model(model).Where("id = ?", id).Count(&n).Error
+	return n > 0, err
+}
+
+func RestoreUser(id uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := findArchived(tx, &user, id); err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&user).Update("deleted_at", nil).Error
+	})
+}
+
+// RestoreProduct khôi phục sản phẩm cùng các variant bị lưu trữ theo sản phẩm (cùng thời điểm)
+func RestoreProduct(id uint) error {
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var p models.Product
+		if err := findArchived(tx, &p, id); err != nil {
+			return err
+		}
+		if p.CategoryID != 0 {
+			ok, err := isActive(tx, &models.Category{}, p.CategoryID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrParentArchived
+			}
+		}
+		if err := tx.Unscoped().Model(&models.ProductVariant{}).
+			Where("product_id = ? AND deleted_at = ?", id, p.DeletedAt.Time).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&p).Update("deleted_at", nil).Error
+	})
+	if err == nil {
+		search.ReindexProduct(id)
+	}
+	return err
+}
+
+func RestoreVariant(productID, variantID uint) error {
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var v models.ProductVariant
+		if err := tx.Unscoped().Where("product_id = ? AND deleted_at IS NOT NULL", productID).First(&v, variantID).Error; err != nil {
+			return err
+		}
+		ok, err := isActive(tx, &models.Product{}, productID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrParentArchived
+		}
+		return tx.Unscoped().Model(&v).Update("deleted_at", nil).Error
+	})
+	if err == nil {
+		search.ReindexProduct(productID)
+	}
+	return err
+}
+
+func RestoreCategory(id uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var c models.Category
+		if err := findArchived(tx, &c, id); err != nil {
+			return err
+		}
+		if c.ParentID != nil {
+			ok, err := isActive(tx, &models.Category{}, *c.ParentID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrParentArchived
+			}
+		}
+		return tx.Unscoped().Model(&c).Update("deleted_at", nil).Error
+	})
+}
+
+func RestoreSupplier(id uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var s models.Supplier
+		if err := findArchived(tx, &s, id); err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&s).Update("deleted_at", nil).Error
+	})
+}
+
+// archiveAt đặt deleted_at cho 1 bản ghi (dùng chung mốc thời gian khi lưu trữ dây chuyền)
+func archiveAt(tx *gorm.DB, model interface{}, id uint, at time.Time) error {
+	return tx.Model(model).Where("id = ?", id).Update("deleted_at", at).Error
+}
+
+// withArchived: preload cho dữ liệu lịch sử (đơn hàng, phiếu nhập, log kho) vẫn thấy bản ghi đã lưu trữ
+func withArchived(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
@@ -0,0 +1,390 @@
+package admin
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+	"strings"
+
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+// ================= ATTRIBUTES =================
+
+func GetAllAttributes() ([]models.Attribute, error) {
+	var attrs []models.Attribute
+	err := configs.DB.
+		Preload("Values", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc, id asc") }).
+		Order("sort_order asc, id asc").
+		Find(&attrs).Error
+	return attrs, err
+}
+
+func CreateAttribute(a *models.Attribute) (*models.Attribute, error) {
+	a.Code = slug.Make(a.Code)
+	if a.Code == "" {
+		a.Code = slug.Make(a.Name)
+	}
+	if a.Code == "" {
+		return nil, errors.New("code is required")
+	}
+	if a.Type == "" {
+		a.Type = "single"
+	}
+	values := a.Values
+	a.Values = nil
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(a).Error; err != nil {
+			return err
+		}
+		for i := range values {
+			values[i].ID = 0
+			values[i].AttributeID = a.ID
+			values[i].Slug = slug.Make(values[i].Value)
+			if err := tx.Create(&values[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.Values = values
+	return a, nil
+}
+
+func UpdateAttribute(id uint, newData *models.Attribute) (*models.Attribute, error) {
+	var a models.Attribute
+	if err := configs.DB.First(&a, id).Error; err != nil {
+		return nil, err
+	}
+	a.Name = newData.Name
+	if newData.Type != "" {
+		a.Type = newData.Type
+	}
+	a.SortOrder = newData.SortOrder
+	if err := configs.DB.Save(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteAttribute xoá thuộc tính cùng giá trị và các liên kết category/product
+func DeleteAttribute(id uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		valueIDs := tx.Model(&models.AttributeValue{}).Select("id").Where("attribute_id = ?", id)
+		if err := tx.Where("attribute_value_id IN (?)", valueIDs).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("attribute_id = ?", id).Delete(&models.CategoryAttribute{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("attribute_id = ?", id).Delete(&models.AttributeValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Attribute{}, id).Error
+	})
+}
+
+func CreateAttributeValue(attributeID uint, v *models.AttributeValue) (*models.AttributeValue, error) {
+	var count int64
+	configs.DB.Model(&models.Attribute{}).Where("id = ?", attributeID).Count(&count)
+	if count == 0 {
+		return nil, errors.New("attribute not found")
+	}
+	v.ID = 0
+	v.AttributeID = attributeID
+	v.Value = strings.TrimSpace(v.Value)
+	v.Slug = slug.Make(v.Value)
+	if v.Slug == "" {
+		return nil, errors.New("value is required")
+	}
+	configs.DB.Model(&models.AttributeValue{}).
+		Where("attribute_id = ? AND slug = ?", attributeID, v.Slug).
+		Count(&count)
+	if count > 0 {
+		return nil, errors.New("value already exists")
+	}
+	if err := configs.DB.Create(v).Error; err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func UpdateAttributeValue(attributeID, valueID uint, newData *models.AttributeValue) (*models.AttributeValue, error) {
+	var v models.AttributeValue
+	if err := configs.DB.Where("id = ? AND attribute_id = ?", valueID, attributeID).First(&v).Error; err != nil {
+		return nil, err
+	}
+	v.Value = strings.TrimSpace(newData.Value)
+	v.Slug = slug.Make(v.Value)
+	v.SortOrder = newData.SortOrder
+	if err := configs.DB.Save(&v).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func DeleteAttributeValue(attributeID, valueID uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND attribute_id = ?", valueID, attributeID).Delete(&models.AttributeValue{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("attribute_value_id = ?", valueID).Delete(&models.ProductAttributeValue{}).Error
+	})
+}
+
+// ================= CATEGORY / PRODUCT ASSIGNMENT =================
+
+func GetCategoryAttributes(categoryID uint) ([]models.Attribute, error) {
+	var attrs []models.Attribute
+	err := configs.DB.
+		Joins("JOIN category_attributes ca ON ca.attribute_id = attributes.id").
+		Where("ca.category_id = ?", categoryID).
+		Preload("Values", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc, id asc") }).
+		Order("attributes.sort_order asc, attributes.id asc").
+		Find(&attrs).Error
+	return attrs, err
+}
+
+// SetCategoryAttributes thay toàn bộ danh sách thuộc tính của category
+func SetCategoryAttributes(categoryID uint, attributeIDs []uint) ([]models.Attribute, error) {
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.Category{}).Where("id = ?", categoryID).Count(&count)
+		if count == 0 {
+			return errors.New("category not found")
+		}
+		if len(attributeIDs) > 0 {
+			tx.Model(&models.Attribute{}).Where("id IN ?", attributeIDs).Count(&count)
+			if int(count) != len(attributeIDs) {
+				return errors.New("invalid attribute id")
+			}
+		}
+		if err := tx.Where("category_id = ?", categoryID).Delete(&models.CategoryAttribute{}).Error; err != nil {
+			return err
+		}
+		for _, aid := range attributeIDs {
+			if err := tx.Create(&models.CategoryAttribute{CategoryID: categoryID, AttributeID: aid}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetCategoryAttributes(categoryID)
+}
+
+// SetProductAttributes gán giá trị thuộc tính cho sản phẩm. Nếu category đã cấu hình
+// thuộc tính thì chỉ cho phép các thuộc tính đó; thuộc tính "single" chỉ 1 giá trị.
+func SetProductAttributes(productID uint, valueIDs []uint) ([]models.AttributeValue, error) {
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.First(&product, productID).Error; err != nil {
+			return errors.New("product not found")
+		}
+
+		var values []models.AttributeValue
+		if len(valueIDs) > 0 {
+			if err := tx.Preload("Attribute").Where("id IN ?", valueIDs).Find(&values).Error; err != nil {
+				return err
+			}
+			if len(values) != len(valueIDs) {
+				return errors.New("invalid attribute value id")
+			}
+		}
+
+		var allowed []uint
+		tx.Model(&models.CategoryAttribute{}).Where("category_id = ?", product.CategoryID).Pluck("attribute_id", &allowed)
+		allowedSet := map[uint]bool{}
+		for _, id := range allowed {
+			allowedSet[id] = true
+		}
+		perAttr := map[uint]int{}
+		for _, v := range values {
+			if len(allowedSet) > 0 && !allowedSet[v.AttributeID] {
+				return errors.New("attribute " + v.Attribute.Code + " is not assigned to this category")
+			}
+			perAttr[v.AttributeID]++
+			if v.Attribute.Type == "single" && perAttr[v.AttributeID] > 1 {
+				return errors.New("attribute " + v.Attribute.Code + " accepts only one value")
+			}
+		}
+
+		if err := tx.Where("product_id = ?", productID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		for _, v := range values {
+			if err := tx.Create(&models.ProductAttributeValue{ProductID: productID, AttributeValueID: v.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var values []models.AttributeValue
+	err = configs.DB.Preload("Attribute").
+		Joins("JOIN product_attribute_values pav ON pav.attribute_value_id = attribute_values.id").
+		Where("pav.product_id = ?", productID).
+		Find(&values).Error
+	return values, err
+}
+
+// ================= SIZE / COLOR OPTIONS =================
+
+func GetSizeOptions() ([]models.SizeOption, error) {
+	var sizes []models.SizeOption
+	err := configs.DB.Order("sort_order asc, id asc").Find(&sizes).Error
+	return sizes, err
+}
+
+func CreateSizeOption(s *models.SizeOption) (*models.SizeOption, error) {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := configs.DB.Create(s).Error; err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UpdateSizeOption đổi tên option và đồng bộ cột size của các variant đang dùng
+func UpdateSizeOption(id uint, newData *models.SizeOption) (*models.SizeOption, error) {
+	var s models.SizeOption
+	if err := configs.DB.First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	s.Name = strings.TrimSpace(newData.Name)
+	s.SortOrder = newData.SortOrder
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&s).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ProductVariant{}).Where("size_id = ?", id).Update("size", s.Name).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func DeleteSizeOption(id uint) error {
+	var count int64
+	configs.DB.Unscoped().Model(&models.ProductVariant{}).Where("size_id = ?", id).Count(&count)
+	if count > 0 {
+		return errors.New("size is used by variants")
+	}
+	return configs.DB.Delete(&models.SizeOption{}, id).Error
+}
+
+func GetColorOptions() ([]models.ColorOption, error) {
+	var colors []models.ColorOption
+	err := configs.DB.Order("sort_order asc, id asc").Find(&colors).Error
+	return colors, err
+}
+
+func CreateColorOption(c *models.ColorOption) (*models.ColorOption, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := configs.DB.Create(c).Error; err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func UpdateColorOption(id uint, newData *models.ColorOption) (*models.ColorOption, error) {
+	var c models.ColorOption
+	if err := configs.DB.First(&c, id).Error; err != nil {
+		return nil, err
+	}
+	c.Name = strings.TrimSpace(newData.Name)
+	c.Hex = newData.Hex
+	c.SortOrder = newData.SortOrder
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&c).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ProductVariant{}).Where("color_id = ?", id).Update("color", c.Name).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func DeleteColorOption(id uint) error {
+	var count int64
+	configs.DB.Unscoped().Model(&models.ProductVariant{}).Where("color_id = ?", id).Count(&count)
+	if count > 0 {
+		return errors.New("color is used by variants")
+	}
+	return configs.DB.Delete(&models.ColorOption{}, id).Error
+}
+
+// resolveVariantOptions gắn SizeID/ColorID theo tên (tạo option mới nếu chưa có),
+// hoặc ngược lại điền Size/Color từ ID nếu client gửi ID.
+func resolveVariantOptions(tx *gorm.DB, v *models.ProductVariant) error {
+	if v.SizeID != nil && *v.SizeID != 0 {
+		var s models.SizeOption
+		if err := tx.First(&s, *v.SizeID).Error; err != nil {
+			return errors.New("size option not found")
+		}
+		v.Size = s.Name
+	} else if name := strings.TrimSpace(v.Size); name != "" {
+		s := models.SizeOption{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&s).Error; err != nil {
+			return err
+		}
+		v.Size, v.SizeID = s.Name, &s.ID
+	} else {
+		v.SizeID = nil
+	}
+
+	if v.ColorID != nil && *v.ColorID != 0 {
+		var c models.ColorOption
+		if err := tx.First(&c, *v.ColorID).Error; err != nil {
+			return errors.New("color option not found")
+		}
+		v.Color = c.Name
+	} else if name := strings.TrimSpace(v.Color); name != "" {
+		c := models.ColorOption{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&c).Error; err != nil {
+			return err
+		}
+		v.Color, v.ColorID = c.Name, &c.ID
+	} else {
+		v.ColorID = nil
+	}
+	return nil
+}
+
+// BackfillVariantOptions tạo size/color option cho các variant cũ chỉ có chuỗi size/color
+func BackfillVariantOptions() error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		stmts := []string{
+			`INSERT IGNORE INTO size_options (name) SELECT DISTINCT TRIM(size) FROM product_variants WHERE size_id IS NULL AND TRIM(size) <> ''`,
+			`UPDATE product_variants pv JOIN size_options so ON so.name = TRIM(pv.size) SET pv.size_id = so.id WHERE pv.size_id IS NULL`,
+			`INSERT IGNORE INTO color_options (name) SELECT DISTINCT TRIM(color) FROM product_variants WHERE color_id IS NULL AND TRIM(color) <> ''`,
+			`UPDATE product_variants pv JOIN color_options co ON co.name = TRIM(pv.color) SET pv.color_id = co.id WHERE pv.color_id IS NULL`,
+		}
+		for _, s := range stmts {
+			if err := tx.Exec(s).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
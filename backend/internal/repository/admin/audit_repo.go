@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter: lọc audit log cho trang quản trị
+type AuditLogFilter struct {
+	ActorID    uint
+	Action     string // khớp tiền tố: "product" → product.update, product.restore...
+	EntityType string
+	EntityID   string
+	IP         string
+	Query      string // tìm trong path và tên người thao tác
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	Limit      int
+}
+
+func GetAuditLogs(f AuditLogFilter) ([]models.AuditLog, int64, error) {
+	base := func() *gorm.DB {
+		q := configs.DB.Model(&models.AuditLog{})
+		if f.ActorID != 0 {
+			q = q.Where("actor_id = ?", f.ActorID)
+		}
+		if f.Action != "" {
+			q = q.Where("action LIKE ?", f.Action+"%")
+		}
+		if f.EntityType != "" {
+			q = q.Where("entity_type = ?", f.EntityType)
+		}
+		if f.EntityID != "" {
+			q = q.Where("entity_id = ?", f.EntityID)
+		}
+		if f.IP != "" {
+			q = q.Where("ip = ?", f.IP)
+		}
+		if f.Query != "" {
+			like := "%" + f.Query + "%"
+			q = q.Where("path LIKE ? OR actor_name LIKE ?", like, like)
+		}
+		if f.From != nil {
+			q = q.Where("created_at >= ?", *f.From)
+		}
+		if f.To != nil {
+			q = q.Where("created_at < ?", *f.To)
+		}
+		return q
+	}
+
+	var total int64
+	if err := base().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	logs := []models.AuditLog{}
+	err := base().Order("created_at DESC, id DESC").
+		Offset((f.Page - 1) * f.Limit).Limit(f.Limit).
+		Find(&logs).Error
+	return logs, total, err
+}
+
+func GetAuditLog(id uint) (*models.AuditLog, error) {
+	var entry models.AuditLog
+	if err := configs.DB.First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -3,55 +3,250 @@ package admin
 import (
 	"backend/configs"
 	"backend/internal/models"
-	 "github.com/gosimple/slug"
+	"backend/internal/repository"
+	"backend/internal/search"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
 )
 
-// GetAllCategories trả về danh sách (không include soft-deleted)
+var ErrCategoryCycle = errors.New("category cannot be moved under itself or its descendants")
+
+// GetAllCategories trả về danh sách phẳng (có parent_id) theo thứ tự hiển thị
 func GetAllCategories() ([]models.Category, error) {
-	var categories []models.Category
-	if err := configs.DB.Find(&categories).Error; err != nil {
+	return repository.LoadCategories()
+}
+
+// GetCategoryTree trả về cây category lồng nhau
+func GetCategoryTree() ([]models.Category, error) {
+	cats, err := repository.LoadCategories()
+	if err != nil {
 		return nil, err
 	}
-	return categories, nil
+	return repository.BuildCategoryTree(cats, nil), nil
 }
 
-// GetCategoryDetail lấy 1 category kèm products (nếu cần)
+// GetCategoryDetail lấy 1 category kèm products và các category con trực tiếp
 func GetCategoryDetail(id uint) (*models.Category, error) {
 	var category models.Category
-	if err := configs.DB.Preload("Products").First(&category, id).Error; err != nil {
+	if err := configs.DB.Preload("Products").
+		Preload("Children", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc, id asc") }).
+		First(&category, id).Error; err != nil {
 		return nil, err
 	}
 	return &category, nil
 }
 
-// CreateCategory tạo mới
+// validateParent: parent phải tồn tại và không nằm trong cây con của id (id = 0 khi tạo mới)
+func validateParent(id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	var count int64
+	configs.DB.Model(&models.Category{}).Where("id = ?", *parentID).Count(&count)
+	if count == 0 {
+		return errors.New("parent category not found")
+	}
+	if id == 0 {
+		return nil
+	}
+	inside, err := repository.IsCategoryDescendant(id, *parentID)
+	if err != nil {
+		return err
+	}
+	if inside {
+		return ErrCategoryCycle
+	}
+	return nil
+}
+
+// CreateCategory tạo mới, thêm vào cuối danh sách anh em
 func CreateCategory(c *models.Category) (*models.Category, error) {
-    c.Slug = slug.Make(c.Name) 
-    if err := configs.DB.Create(c).Error; err != nil {
-        return nil, err
-    }
-    return c, nil
+	if c.ParentID != nil && *c.ParentID == 0 {
+		c.ParentID = nil
+	}
+	if err := validateParent(0, c.ParentID); err != nil {
+		return nil, err
+	}
+	newSlug, err := repository.UniqueSlug(configs.DB, models.SlugEntityCategory, c.Name, 0)
+	if err != nil {
+		return nil, err
+	}
+	c.Slug = newSlug
+	c.SortOrder = nextSiblingOrder(configs.DB, c.ParentID)
+	if err := configs.DB.Create(c).Error; err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
-// UpdateCategory cập nhật tên (hoặc các trường khác)
+// UpdateCategory cập nhật tên và vị trí trong cây
 func UpdateCategory(id uint, newData *models.Category) (*models.Category, error) {
 	var category models.Category
 	if err := configs.DB.First(&category, id).Error; err != nil {
 		return nil, err
 	}
+	if newData.ParentID != nil && *newData.ParentID == 0 {
+		newData.ParentID = nil
+	}
+	if err := validateParent(id, newData.ParentID); err != nil {
+		return nil, err
+	}
 
-	// Cập nhật những field cho phép
-	category.Name = newData.Name
-	category.GroupName = newData.GroupName
-    category.Slug = slug.Make(newData.Name)
-	if err := configs.DB.Save(&category).Error; err != nil {
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if !sameParent(category.ParentID, newData.ParentID) {
+			category.SortOrder = nextSiblingOrder(tx, newData.ParentID)
+		}
+		if category.Name != newData.Name {
+			newSlug, err := repository.UniqueSlug(tx, models.SlugEntityCategory, newData.Name, category.ID)
+			if err != nil {
+				return err
+			}
+			if err := repository.RecordSlugChange(tx, models.SlugEntityCategory, category.ID, category.Slug, newSlug); err != nil {
+				return err
+			}
+			category.Slug = newSlug
+		}
+		category.Name = newData.Name
+		category.ParentID = newData.ParentID
+		return tx.Save(&category).Error
+	})
+	if err != nil {
 		return nil, err
 	}
+	search.ReindexCategory(category.ID)
 	return &category, nil
 }
 
+// ReorderCategories đặt parent và thứ tự cho danh sách category (kéo thả trong cây)
+func ReorderCategories(parentID *uint, ids []uint) error {
+	if parentID != nil && *parentID == 0 {
+		parentID = nil
+	}
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			if err := validateParent(id, parentID); err != nil {
+				return err
+			}
+			res := tx.Model(&models.Category{}).Where("id = ?", id).
+				Updates(map[string]interface{}{"parent_id": parentID, "sort_order": i})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				var count int64
+				tx.Model(&models.Category{}).Where("id = ?", id).Count(&count)
+				if count == 0 {
+					return errors.New("category not found")
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteCategory từ chối khi còn category con hoặc sản phẩm đang bán; chỉ còn bản ghi đã lưu trữ
+// tham chiếu thì lưu trữ, không còn gì thì xoá hẳn. Trả về true nếu chỉ lưu trữ.
+func DeleteCategory(id uint) (bool, error) {
+	archived := false
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Category{}, id).Error; err != nil {
+			return err
+		}
+		var active int64
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Count(&active).Error; err != nil {
+			return err
+		}
+		if active == 0 {
+			if err := tx.Model(&models.Product{}).Where("category_id = ?", id).Count(&active).Error; err != nil {
+				return err
+			}
+		}
+		if active > 0 {
+			return ErrCategoryInUse
+		}
+		refs, err := countRefs(tx, id, map[string]string{"categories": "parent_id", "products": "category_id"})
+		if err != nil {
+			return err
+		}
+		if refs > 0 {
+			archived = true
+			return archiveAt(tx, &models.Category{}, id, time.Now())
+		}
+		if err := repository.DeleteSlugHistory(tx, models.SlugEntityCategory, id); err != nil {
+			return err
+		}
+		if err := tx.Where("category_id = ?", id).Delete(&models.CategoryAttribute{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Category{}, id).Error
+	})
+	return archived, err
+}
 
-// DeleteCategory xóa (soft-delete). Nếu muốn hard-delete dùng Unscoped().Delete(...)
-func DeleteCategory(id uint) error {
-	return configs.DB.Delete(&models.Category{}, id).Error
+func nextSiblingOrder(tx *gorm.DB, parentID *uint) int {
+	var max struct{ Max *int }
+	q := tx.Model(&models.Category{}).Select("MAX(sort_order) as max")
+	if parentID == nil {
+		q = q.Where("parent_id IS NULL")
+	} else {
+		q = q.Where("parent_id = ?", *parentID)
+	}
+	q.Scan(&max)
+	if max.Max == nil {
+		return 0
+	}
+	return *max.Max + 1
+}
+
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// MigrateCategoryGroups chuyển cột enum group_name cũ thành category gốc + parent_id,
+// sau đó xoá cột. Chạy một lần lúc khởi động, bỏ qua nếu cột không còn.
+func MigrateCategoryGroups() error {
+	if !configs.DB.Migrator().HasColumn(&models.Category{}, "group_name") {
+		return nil
+	}
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var groups []string
+		if err := tx.Raw("SELECT DISTINCT group_name FROM categories WHERE group_name IS NOT NULL AND group_name <> ''").
+			Scan(&groups).Error; err != nil {
+			return err
+		}
+		for i, g := range groups {
+			var root models.Category
+			err := tx.Where("name = ? OR slug = ?", g, slug.Make(g)).First(&root).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Exec("INSERT INTO categories (name, slug, group_name, sort_order, created_at) VALUES (?, ?, ?, ?, NOW())",
+					g, slug.Make(g), g, i).Error; err != nil {
+					return err
+				}
+				err = tx.Where("slug = ?", slug.Make(g)).First(&root).Error
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE categories SET parent_id = ? WHERE group_name = ? AND id <> ? AND parent_id IS NULL",
+				root.ID, g, root.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE categories SET parent_id = NULL WHERE id = ?", root.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Println("Migrated category group_name to parent/child tree")
+	return configs.DB.Migrator().DropColumn(&models.Category{}, "group_name")
 }
@@ -0,0 +1,234 @@
+package admin
+
+import (
+	"backend/configs"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportFilter: khoảng thời gian [From, To) và status (tuỳ loại dữ liệu)
+type ExportFilter struct {
+	From   *time.Time
+	To     *time.Time
+	Status string
+}
+
+// ExportRowFunc nhận từng dòng đã scan, trả lỗi để dừng export
+type ExportRowFunc func(values []interface{}) error
+
+var (
+	ProductExportHeaders = []string{
+		"product_id", "product_name", "slug", "category", "price", "discount",
+		"variant_id", "sku", "size", "color", "variant_price", "stock", "created_at",
+	}
+	OrderExportHeaders = []string{
+		"order_id", "created_at", "status", "payment_method", "txn_ref", "total",
+		"customer_id", "customer_name", "customer_email", "guest_name", "guest_email",
+		"item_id", "product_name", "variant_id", "sku", "size", "color", "quantity", "price",
+	}
+	PurchaseExportHeaders = []string{
+		"purchase_id", "created_at", "supplier_id", "supplier_name", "staff_id", "staff_name",
+		"variant_id", "sku", "quantity", "cost_price", "total",
+	}
+	InventoryLogExportHeaders = []string{
+		"log_id", "created_at", "variant_id", "sku", "change_type", "quantity", "note",
+	}
+	CustomerExportHeaders = []string{
+		"user_id", "username", "email", "phone", "address", "role", "created_at",
+	}
+)
+
+func applyDateRange(q *gorm.DB, column string, f ExportFilter) *gorm.DB {
+	if f.From != nil {
+		q = q.Where(column+" >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where(column+" < ?", *f.To)
+	}
+	return q
+}
+
+// streamRows chạy query bằng cursor, mỗi dòng scan vào dest rồi gọi fn
+func streamRows(q *gorm.DB, fn ExportRowFunc, dest ...interface{}) error {
+	rows, err := q.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		values := make([]interface{}, len(dest))
+		for i, d := range dest {
+			values[i] = derefScan(d)
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func derefScan(d interface{}) interface{} {
+	switch v := d.(type) {
+	case *uint:
+		return *v
+	case *int:
+		return *v
+	case *float64:
+		return *v
+	case *string:
+		return *v
+	case *time.Time:
+		return *v
+	case *sql.NullInt64:
+		if v.Valid {
+			return v.Int64
+		}
+		return nil
+	case *sql.NullFloat64:
+		if v.Valid {
+			return v.Float64
+		}
+		return nil
+	case *sql.NullString:
+		if v.Valid {
+			return v.String
+		}
+		return nil
+	case *sql.NullTime:
+		if v.Valid {
+			return v.Time
+		}
+		return nil
+	}
+	return d
+}
+
+// ExportProducts: mỗi dòng là 1 variant (sản phẩm chưa có variant vẫn có 1 dòng)
+func ExportProducts(f ExportFilter, fn ExportRowFunc) error {
+	q := configs.DB.Table("products").
+		Select(`products.id, products.name, products.slug, categories.name,
+			products.price, products.discount,
+			product_variants.id, product_variants.sku, product_variants.size, product_variants.color,
+			product_variants.price, product_variants.stock, products.created_at`).
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Joins("LEFT JOIN product_variants ON product_variants.product_id = products.id").
+		Order("products.id, product_variants.id")
+	q = applyDateRange(q, "products.created_at", f)
+
+	var (
+		id               uint
+		name, slug       string
+		category         sql.NullString
+		price, discount  float64
+		variantID, stock sql.NullInt64
+		sku, size, color sql.NullString
+		variantPrice     sql.NullFloat64
+		createdAt        time.Time
+	)
+	return streamRows(q, fn, &id, &name, &slug, &category, &price, &discount,
+		&variantID, &sku, &size, &color, &variantPrice, &stock, &createdAt)
+}
+
+// ExportOrders: mỗi dòng là 1 order item kèm thông tin order, status lọc theo orders.status
+func ExportOrders(f ExportFilter, fn ExportRowFunc) error {
+	q := configs.DB.Table("orders").
+		Select(`orders.id, orders.created_at, orders.status, orders.payment_method, orders.txn_ref, orders.total,
+			orders.customer_id, users.username, users.email, orders.guest_name, orders.guest_email,
+			order_items.id, order_items.product_name, order_items.variant_id, order_items.sku,
+			order_items.size, order_items.color, order_items.quantity, order_items.price`).
+		Joins("LEFT JOIN users ON users.id = orders.customer_id").
+		Joins("LEFT JOIN order_items ON order_items.order_id = orders.id").
+		Order("orders.id, order_items.id")
+	q = applyDateRange(q, "orders.created_at", f)
+	if f.Status != "" {
+		q = q.Where("orders.status = ?", f.Status)
+	}
+
+	var (
+		id                            uint
+		customerID                    sql.NullInt64 // NULL với đơn khách vãng lai
+		createdAt                     time.Time
+		status, payment, txnRef       string
+		total                         float64
+		username, email               sql.NullString
+		guestName, guestEmail         sql.NullString
+		itemID, variantID, quantity   sql.NullInt64
+		productName, sku, size, color sql.NullString
+		price                         sql.NullFloat64
+	)
+	return streamRows(q, fn, &id, &createdAt, &status, &payment, &txnRef, &total,
+		&customerID, &username, &email, &guestName, &guestEmail,
+		&itemID, &productName, &variantID, &sku, &size, &color, &quantity, &price)
+}
+
+// ExportPurchases: phiếu nhập kèm supplier, staff, variant
+func ExportPurchases(f ExportFilter, fn ExportRowFunc) error {
+	q := configs.DB.Table("purchases").
+		Select(`purchases.id, purchases.created_at, purchases.supplier_id, suppliers.name,
+			purchases.staff_id, users.username, purchases.variant_id, product_variants.sku,
+			purchases.quantity, purchases.cost_price, purchases.quantity * purchases.cost_price`).
+		Joins("LEFT JOIN suppliers ON suppliers.id = purchases.supplier_id").
+		Joins("LEFT JOIN users ON users.id = purchases.staff_id").
+		Joins("LEFT JOIN product_variants ON product_variants.id = purchases.variant_id").
+		Order("purchases.id")
+	q = applyDateRange(q, "purchases.created_at", f)
+
+	var (
+		id, supplierID, staffID, variantID uint
+		createdAt                          time.Time
+		supplierName, staffName, sku       sql.NullString
+		quantity                           int
+		costPrice, total                   float64
+	)
+	return streamRows(q, fn, &id, &createdAt, &supplierID, &supplierName,
+		&staffID, &staffName, &variantID, &sku, &quantity, &costPrice, &total)
+}
+
+// ExportInventoryLogs: status lọc theo change_type (import/sale/return/adjust)
+func ExportInventoryLogs(f ExportFilter, fn ExportRowFunc) error {
+	q := configs.DB.Table("inventory_logs").
+		Select(`inventory_logs.id, inventory_logs.created_at, inventory_logs.variant_id, product_variants.sku,
+			inventory_logs.change_type, inventory_logs.quantity, inventory_logs.note`).
+		Joins("LEFT JOIN product_variants ON product_variants.id = inventory_logs.variant_id").
+		Order("inventory_logs.id")
+	q = applyDateRange(q, "inventory_logs.created_at", f)
+	if f.Status != "" {
+		q = q.Where("inventory_logs.change_type = ?", f.Status)
+	}
+
+	var (
+		id, variantID    uint
+		createdAt        time.Time
+		sku              sql.NullString
+		changeType, note sql.NullString
+		quantity         int
+	)
+	return streamRows(q, fn, &id, &createdAt, &variantID, &sku, &changeType, &quantity, &note)
+}
+
+// ExportCustomers: mặc định chỉ role customer, status cho phép chọn role khác
+func ExportCustomers(f ExportFilter, fn ExportRowFunc) error {
+	role := f.Status
+	if role == "" {
+		role = "customer"
+	}
+	q := configs.DB.Table("users").
+		Select("id, username, email, phone, address, role, created_at").
+		Where("role = ?", role).
+		Order("id")
+	q = applyDateRange(q, "created_at", f)
+
+	var (
+		id                 uint
+		username, email, r string
+		phone, address     sql.NullString
+		createdAt          time.Time
+	)
+	return streamRows(q, fn, &id, &username, &email, &phone, &address, &r, &createdAt)
+}
@@ -9,14 +9,14 @@ import (
 // GET ALL INVENTORY LOGS
 func GetAllInventoryLogs() ([]models.InventoryLog, error) {
 	var logs []models.InventoryLog
-	err := configs.DB.Preload("Variant").Find(&logs).Error
+	err := configs.DB.Preload("Variant", withArchived).Find(&logs).Error
 	return logs, err
 }
 
 // GET INVENTORY LOG DETAIL
 func GetInventoryLogDetail(id uint) (*models.InventoryLog, error) {
 	var log models.InventoryLog
-	err := configs.DB.Preload("Variant").First(&log, id).Error
+	err := configs.DB.Preload("Variant", withArchived).First(&log, id).Error
 	return &log, err
 }
 
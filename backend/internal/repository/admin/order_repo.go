@@ -3,6 +3,7 @@ package admin
 import (
 	"backend/configs"
 	"backend/internal/models"
+	"backend/internal/repository"
 	"gorm.io/gorm"
 )
 
@@ -10,10 +11,10 @@ import (
 func GetAllOrders() ([]models.Order, error) {
 	var orders []models.Order
 	err := configs.DB.
-		Preload("Customer").
-		Preload("Staff").
+		Preload("Customer", withArchived).
+		Preload("Staff", withArchived).
 		Preload("Items", func(db *gorm.DB) *gorm.DB {
-			return db.Order("id asc").Preload("Variant")
+			return db.Order("id asc").Preload("Variant", withArchived)
 		}).
 		Find(&orders).Error
 	return orders, err
@@ -23,22 +24,16 @@ func GetAllOrders() ([]models.Order, error) {
 func GetOrderDetail(id uint) (*models.Order, error) {
 	var order models.Order
 	err := configs.DB.
-		Preload("Customer").
-		Preload("Staff").
+		Preload("Customer", withArchived).
+		Preload("Staff", withArchived).
 		Preload("Items").
 		First(&order, id).Error
 	if err != nil {
 		return nil, err
 	}
 
-	// Lấy địa chỉ của khách hàng
-	var address models.CustomerAddress
-	err = configs.DB.
-		Where("user_id = ? AND is_default = ?", order.CustomerID, true).
-		First(&address).Error
-	if err == nil {
-		order.CustomerAddress = &address 
-	}
+	// Lấy địa chỉ của khách hàng (hoặc thông tin giao hàng của khách vãng lai)
+	order.CustomerAddress = repository.OrderAddress(&order, true)
 
 	return &order, nil
 }
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+func GetProductImages(productID uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := configs.DB.Where("product_id = ?", productID).
+		Order("sort_order asc, id asc").
+		Find(&images).Error
+	return images, err
+}
+
+// AddProductImages thêm ảnh vào cuối danh sách và đồng bộ products.image = ảnh đầu tiên
+func AddProductImages(productID uint, images []models.ProductImage) ([]models.ProductImage, error) {
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.First(&product, productID).Error; err != nil {
+			return errors.New("product not found")
+		}
+
+		var maxOrder struct{ Max *int }
+		tx.Model(&models.ProductImage{}).Select("MAX(sort_order) as max").
+			Where("product_id = ?", productID).Scan(&maxOrder)
+		next := 0
+		if maxOrder.Max != nil {
+			next = *maxOrder.Max + 1
+		}
+		for i := range images {
+			images[i].ProductID = productID
+			images[i].SortOrder = next + i
+			if err := tx.Create(&images[i]).Error; err != nil {
+				return err
+			}
+		}
+		return syncProductCover(tx, productID)
+	})
+	return images, err
+}
+
+// ReorderProductImages: imageIDs theo thứ tự mới, phải đủ toàn bộ ảnh của product
+func ReorderProductImages(productID uint, imageIDs []uint) ([]models.ProductImage, error) {
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.ProductImage{}).
+			Where("product_id = ? AND id IN ?", productID, imageIDs).
+			Count(&count)
+		var total int64
+		tx.Model(&models.ProductImage{}).Where("product_id = ?", productID).Count(&total)
+		if int(count) != len(imageIDs) || count != total {
+			return errors.New("image list does not match product images")
+		}
+		for i, id := range imageIDs {
+			if err := tx.Model(&models.ProductImage{}).
+				Where("id = ?", id).
+				Update("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+		return syncProductCover(tx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetProductImages(productID)
+}
+
+// DeleteProductImage xoá bản ghi, trả về image để controller xoá file trong storage
+func DeleteProductImage(productID, imageID uint) (*models.ProductImage, error) {
+	var img models.ProductImage
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND product_id = ?", imageID, productID).First(&img).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&img).Error; err != nil {
+			return err
+		}
+		return syncProductCover(tx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// syncProductCover: products.image luôn là ảnh có sort_order nhỏ nhất (tương thích frontend cũ)
+func syncProductCover(tx *gorm.DB, productID uint) error {
+	var first models.ProductImage
+	err := tx.Where("product_id = ?", productID).Order("sort_order asc, id asc").First(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).Update("image", first.URL).Error
+}
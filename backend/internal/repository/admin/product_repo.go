@@ -3,8 +3,12 @@ package admin
 import (
 	"backend/configs"
 	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/search"
 	"errors"
-	"github.com/gosimple/slug"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // PRODUCTS
@@ -16,16 +20,24 @@ func GetAllProducts() ([]models.Product, error) {
 
 func GetProductDetail(id uint) (*models.Product, error) {
 	var product models.Product
-	err := configs.DB.Preload("Variants").First(&product, id).Error
+	err := configs.DB.Preload("Variants").
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc, id asc") }).
+		Preload("AttributeValues.Attribute").
+		First(&product, id).Error
 	return &product, err
 }
 
 func CreateProduct(p *models.Product) (*models.Product, error) {
-    p.Slug = slug.Make(p.Name)
-    if err := configs.DB.Create(p).Error; err != nil {
-        return nil, err
-    }
-    return p, nil
+	s, err := repository.UniqueSlug(configs.DB, models.SlugEntityProduct, p.Name, 0)
+	if err != nil {
+		return nil, err
+	}
+	p.Slug = s
+	if err := configs.DB.Create(p).Error; err != nil {
+		return nil, err
+	}
+	search.ReindexProduct(p.ID)
+	return p, nil
 }
 
 
@@ -34,21 +46,57 @@ func UpdateProduct(id uint, newData *models.Product) (*models.Product, error) {
 	if err := configs.DB.First(&p, id).Error; err != nil {
 		return nil, err
 	}
-	p.Name = newData.Name
-	p.Description = newData.Description
-	p.CategoryID = newData.CategoryID
-	p.Image = newData.Image
-	p.Price = newData.Price
-	p.Discount = newData.Discount
-	p.Slug = slug.Make(newData.Name)
-	if err := configs.DB.Save(&p).Error; err != nil {
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		// chỉ đổi slug khi đổi tên, slug cũ được giữ trong lịch sử để redirect
+		if p.Name != newData.Name {
+			newSlug, err := repository.UniqueSlug(tx, models.SlugEntityProduct, newData.Name, p.ID)
+			if err != nil {
+				return err
+			}
+			if err := repository.RecordSlugChange(tx, models.SlugEntityProduct, p.ID, p.Slug, newSlug); err != nil {
+				return err
+			}
+			p.Slug = newSlug
+		}
+		p.Name = newData.Name
+		p.Description = newData.Description
+		p.CategoryID = newData.CategoryID
+		p.Image = newData.Image
+		p.Price = newData.Price
+		p.Discount = newData.Discount
+		return tx.Save(&p).Error
+	})
+	if err != nil {
 		return nil, err
 	}
+	search.ReindexProduct(p.ID)
 	return &p, nil
 }
 
+// DeleteProduct lưu trữ sản phẩm cùng các variant đang bán (cùng mốc thời gian để khôi phục cùng nhau).
+// Giữ lịch sử slug để khôi phục lại đúng link; giỏ hàng, wishlist, đăng ký báo hàng bị gỡ.
 func DeleteProduct(id uint) error {
-	return configs.DB.Delete(&models.Product{}, id).Error
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Product{}, id).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		variantIDs := tx.Unscoped().Model(&models.ProductVariant{}).Select("id").Where("product_id = ?", id)
+		if err := dropVariantsFromCarts(tx, variantIDs); err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", id).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return archiveAt(tx, &models.Product{}, id, now)
+	})
+	if err == nil {
+		search.RemoveProduct(id)
+	}
+	return err
 }
 
 // VARIANTS
@@ -68,13 +116,17 @@ func GetAllVariants() ([]models.ProductVariant, error) {
 }
 func CreateVariant(v *models.ProductVariant) (*models.ProductVariant, error) {
 	var count int64
-	configs.DB.Model(&models.ProductVariant{}).Where("sku = ?", v.SKU).Count(&count)
+	configs.DB.Unscoped().Model(&models.ProductVariant{}).Where("sku = ?", v.SKU).Count(&count)
 	if count > 0 {
 		return nil, errors.New("SKU already exists")
 	}
+	if err := resolveVariantOptions(configs.DB, v); err != nil {
+		return nil, err
+	}
 	if err := configs.DB.Create(v).Error; err != nil {
 		return nil, err
 	}
+	search.ReindexProduct(v.ProductID)
 	return v, nil
 }
 
@@ -84,14 +136,19 @@ func UpdateVariant(id uint, newData *models.ProductVariant) (*models.ProductVari
 		return nil, err
 	}
 	var count int64
-	configs.DB.Model(&models.ProductVariant{}).
+	configs.DB.Unscoped().Model(&models.ProductVariant{}).
 		Where("sku = ? AND id <> ?", newData.SKU, id).
 		Count(&count)
 	if count > 0 {
 		return nil, errors.New("SKU already exists")
 	}
+	if err := resolveVariantOptions(configs.DB, newData); err != nil {
+		return nil, err
+	}
 	v.Size = newData.Size
 	v.Color = newData.Color
+	v.SizeID = newData.SizeID
+	v.ColorID = newData.ColorID
 	v.Price = newData.Price
 	v.Stock = newData.Stock
 	v.SKU = newData.SKU
@@ -99,10 +156,36 @@ func UpdateVariant(id uint, newData *models.ProductVariant) (*models.ProductVari
 	if err := configs.DB.Save(&v).Error; err != nil {
 		return nil, err
 	}
+	search.ReindexProduct(v.ProductID)
 	return &v, nil
 }
 
-func DeleteVariant(id uint) error {
-	return configs.DB.Unscoped().Delete(&models.ProductVariant{}, id).Error
+// DeleteVariant: variant đã có đơn hàng / phiếu nhập / log kho thì lưu trữ, chưa có thì xoá hẳn.
+// Trả về true nếu chỉ lưu trữ.
+func DeleteVariant(id uint) (bool, error) {
+	var v models.ProductVariant
+	archived := false
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&v, id).Error; err != nil {
+			return err
+		}
+		if err := dropVariantsFromCarts(tx, []uint{id}); err != nil {
+			return err
+		}
+		refs, err := countRefs(tx, id, variantHistoryRefs)
+		if err != nil {
+			return err
+		}
+		if refs > 0 {
+			archived = true
+			return archiveAt(tx, &models.ProductVariant{}, id, time.Now())
+		}
+		return tx.Unscoped().Delete(&models.ProductVariant{}, id).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	search.ReindexProduct(v.ProductID)
+	return archived, nil
 }
 
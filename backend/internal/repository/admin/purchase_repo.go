@@ -11,14 +11,14 @@ import (
 // Get all purchases (global)
 func GetAllPurchases() ([]models.Purchase, error) {
 	var purchases []models.Purchase
-	err := configs.DB.Preload("Supplier").Preload("Staff").Preload("Variant").Find(&purchases).Error
+	err := configs.DB.Preload("Supplier", withArchived).Preload("Staff", withArchived).Preload("Variant", withArchived).Find(&purchases).Error
 	return purchases, err
 }
 
 // Get purchases by supplier
 func GetPurchasesBySupplier(supplierID uint) ([]models.Purchase, error) {
 	var purchases []models.Purchase
-	err := configs.DB.Preload("Variant").Preload("Staff").Where("supplier_id = ?", supplierID).Find(&purchases).Error
+	err := configs.DB.Preload("Variant", withArchived).Preload("Staff", withArchived).Where("supplier_id = ?", supplierID).Find(&purchases).Error
 	return purchases, err
 }
 
@@ -0,0 +1,179 @@
+package admin
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	customerRepo "backend/internal/repository/customer"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidReviewStatus = errors.New("status must be pending, approved or rejected")
+
+type ReviewFilter struct {
+	Status    string
+	ProductID uint
+	Rating    int
+	Page      int
+	Limit     int
+}
+
+// MigrateProductRatingColumns thêm rating_avg / rating_count vào bảng products nếu chưa có
+func MigrateProductRatingColumns() error {
+	m := configs.DB.Migrator()
+	for _, field := range []string{"RatingAvg", "RatingCount"} {
+		if !m.HasColumn(&models.Product{}, field) {
+			if err := m.AddColumn(&models.Product{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecalculateProductRating tính lại điểm trung bình / số review đã duyệt của sản phẩm
+func RecalculateProductRating(tx *gorm.DB, productID uint) error {
+	var stat struct {
+		Avg   float64
+		Count int
+	}
+	if err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS avg, COUNT(*) AS count").
+		Where("product_id = ? AND status = ?", productID, "approved").
+		Scan(&stat).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).
+		Updates(map[string]interface{}{"rating_avg": stat.Avg, "rating_count": stat.Count}).Error
+}
+
+// preloadReview: quan hệ giống trang khách hàng, thêm sản phẩm để hiển thị ở trang kiểm duyệt
+func preloadReview(db *gorm.DB) *gorm.DB {
+	return customerRepo.PreloadReviewRelations(db).
+		Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select("id, name, slug") })
+}
+
+// GetReviews: danh sách review cho trang kiểm duyệt
+func GetReviews(f ReviewFilter) ([]models.Review, int64, error) {
+	base := func() *gorm.DB {
+		q := configs.DB.Model(&models.Review{})
+		if f.Status != "" {
+			q = q.Where("status = ?", f.Status)
+		}
+		if f.ProductID != 0 {
+			q = q.Where("product_id = ?", f.ProductID)
+		}
+		if f.Rating >= 1 && f.Rating <= 5 {
+			q = q.Where("rating = ?", f.Rating)
+		}
+		return q
+	}
+
+	var total int64
+	if err := base().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	reviews := []models.Review{}
+	if err := preloadReview(base()).
+		Order("created_at DESC").
+		Offset((f.Page - 1) * f.Limit).Limit(f.Limit).
+		Find(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+	customerRepo.FillReviewNames(reviews)
+	return reviews, total, nil
+}
+
+func GetReviewByID(id uint) (*models.Review, error) {
+	reviews := make([]models.Review, 1)
+	if err := preloadReview(configs.DB).First(&reviews[0], id).Error; err != nil {
+		return nil, err
+	}
+	customerRepo.FillReviewNames(reviews)
+	return &reviews[0], nil
+}
+
+// UpdateReviewStatus duyệt / từ chối review và cập nhật điểm sản phẩm
+func UpdateReviewStatus(id uint, status string) (*models.Review, error) {
+	switch status {
+	case "pending", "approved", "rejected":
+	default:
+		return nil, ErrInvalidReviewStatus
+	}
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.Select("id, product_id").First(&review, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&review).Update("status", status).Error; err != nil {
+			return err
+		}
+		return RecalculateProductRating(tx, review.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetReviewByID(id)
+}
+
+// DeleteReview xoá review cùng ảnh, vote, phản hồi; trả về ảnh để controller xoá file
+func DeleteReview(id uint) ([]models.ReviewImage, error) {
+	var images []models.ReviewImage
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.Select("id, product_id").First(&review, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("review_id = ?", id).Find(&images).Error; err != nil {
+			return err
+		}
+		for _, m := range []interface{}{&models.ReviewImage{}, &models.ReviewVote{}, &models.ReviewReply{}} {
+			if err := tx.Where("review_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(&models.Review{}, id).Error; err != nil {
+			return err
+		}
+		return RecalculateProductRating(tx, review.ProductID)
+	})
+	return images, err
+}
+
+// AddReviewReply: nhân viên phản hồi review
+func AddReviewReply(reviewID, staffID uint, content string) (*models.ReviewReply, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("content is required")
+	}
+	var count int64
+	if err := configs.DB.Model(&models.Review{}).Where("id = ?", reviewID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	reply := models.ReviewReply{ReviewID: reviewID, StaffID: staffID, Content: content}
+	if err := configs.DB.Create(&reply).Error; err != nil {
+		return nil, err
+	}
+	var staff models.User
+	if err := configs.DB.Select("id, username").First(&staff, staffID).Error; err == nil {
+		reply.StaffName = staff.Username
+	}
+	return &reply, nil
+}
+
+func DeleteReviewReply(reviewID, replyID uint) error {
+	res := configs.DB.Where("id = ? AND review_id = ?", replyID, reviewID).Delete(&models.ReviewReply{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
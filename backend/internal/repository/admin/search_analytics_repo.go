@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"backend/internal/search"
+	"errors"
+	"time"
+)
+
+// QueryStat: thống kê 1 truy vấn (đã chuẩn hoá) trong khoảng thời gian
+type QueryStat struct {
+	Query        string    `json:"query"`
+	Example      string    `json:"example"`
+	Searches     int64     `json:"searches"`
+	ZeroResults  int64     `json:"zero_results"`
+	AvgResults   float64   `json:"avg_results"`
+	LastSearched time.Time `json:"last_searched"`
+}
+
+func queryStats(since time.Time, source string, zeroOnly bool, limit int) ([]QueryStat, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+	q := configs.DB.Model(&models.SearchLog{}).
+		Select(`normalized_query AS query, MAX(query) AS example, COUNT(*) AS searches,
+			SUM(CASE WHEN result_count = 0 THEN 1 ELSE 0 END) AS zero_results,
+			AVG(result_count) AS avg_results, MAX(created_at) AS last_searched`).
+		Where("created_at >= ? AND normalized_query <> ''", since)
+	if source != "" {
+		q = q.Where("source = ?", source)
+	}
+	if zeroOnly {
+		q = q.Where("result_count = 0")
+	}
+	stats := []QueryStat{}
+	err := q.Group("normalized_query").Order("searches DESC, last_searched DESC").Limit(limit).Scan(&stats).Error
+	return stats, err
+}
+
+// TopQueries: truy vấn được tìm nhiều nhất
+func TopQueries(since time.Time, source string, limit int) ([]QueryStat, error) {
+	return queryStats(since, source, false, limit)
+}
+
+// ZeroResultQueries: truy vấn không có kết quả, để bổ sung sản phẩm / từ đồng nghĩa
+func ZeroResultQueries(since time.Time, source string, limit int) ([]QueryStat, error) {
+	return queryStats(since, source, true, limit)
+}
+
+// SYNONYMS
+func GetSearchSynonyms() ([]models.SearchSynonym, error) {
+	var rows []models.SearchSynonym
+	err := configs.DB.Order("id asc").Find(&rows).Error
+	return rows, err
+}
+
+func cleanSynonymTerms(terms []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range terms {
+		n := search.Normalize(t)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, t)
+	}
+	if len(out) < 2 {
+		return nil, errors.New("synonym group needs at least 2 distinct terms")
+	}
+	return out, nil
+}
+
+func CreateSearchSynonym(terms []string) (*models.SearchSynonym, error) {
+	cleaned, err := cleanSynonymTerms(terms)
+	if err != nil {
+		return nil, err
+	}
+	row := models.SearchSynonym{Terms: cleaned}
+	if err := configs.DB.Create(&row).Error; err != nil {
+		return nil, err
+	}
+	return &row, search.LoadSynonyms()
+}
+
+func UpdateSearchSynonym(id uint, terms []string) (*models.SearchSynonym, error) {
+	var row models.SearchSynonym
+	if err := configs.DB.First(&row, id).Error; err != nil {
+		return nil, err
+	}
+	cleaned, err := cleanSynonymTerms(terms)
+	if err != nil {
+		return nil, err
+	}
+	row.Terms = cleaned
+	if err := configs.DB.Save(&row).Error; err != nil {
+		return nil, err
+	}
+	return &row, search.LoadSynonyms()
+}
+
+func DeleteSearchSynonym(id uint) error {
+	if err := configs.DB.Delete(&models.SearchSynonym{}, id).Error; err != nil {
+		return err
+	}
+	return search.LoadSynonyms()
+}
@@ -1,48 +1,459 @@
 package admin
 
 import (
-    "backend/configs"
-    "backend/internal/models"
-    "fmt"
+	"backend/configs"
+	"backend/internal/models"
+	"backend/internal/search"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Các loại kết quả, theo thứ tự hiển thị
+const (
+	SearchTypeOrder    = "order"
+	SearchTypeUser     = "user"
+	SearchTypeProduct  = "product"
+	SearchTypeVariant  = "variant"
+	SearchTypeCategory = "category"
+	SearchTypeSupplier = "supplier"
+	SearchTypePurchase = "purchase"
+)
+
+var AdminSearchTypes = []string{
+	SearchTypeOrder, SearchTypeUser, SearchTypeProduct, SearchTypeVariant,
+	SearchTypeCategory, SearchTypeSupplier, SearchTypePurchase,
+}
+
+type AdminSearchOptions struct {
+	Types       []string // rỗng = tự chọn theo pattern
+	Page        int
+	Limit       int    // số kết quả mỗi loại
+	OrderStatus string // lọc đơn hàng theo trạng thái
+}
+
+var (
+	orderNumberRe    = regexp.MustCompile(`^(?i)(?:#|dh|don|order)\s*#?\s*(\d{1,10})$`)
+	purchaseNumberRe = regexp.MustCompile(`^(?i)(?:pn|po|purchase)\s*#?\s*(\d{1,10})$`)
+	plainNumberRe    = regexp.MustCompile(`^\d{1,10}$`)
+	txnRefRe         = regexp.MustCompile(`^\d{10,}-\d+$`)
+	emailRe          = regexp.MustCompile(`^[^@\s]*@[^@\s]*$`)
+	phoneRe          = regexp.MustCompile(`^(?:\+?84|0)\d{8,10}$`)
+	skuRe            = regexp.MustCompile(`^[A-Za-z0-9]+(?:[-_.][A-Za-z0-9]+)+$`)
 )
 
-func SearchAll(keyword string) ([]models.SearchResult, error) {
-    var results []models.SearchResult
-
-    // Sản phẩm
-    var products []struct{ ID int; Name string; Slug string }
-    configs.DB.Raw("SELECT id, name,slug FROM products WHERE name LIKE ?", "%"+keyword+"%").Scan(&products)
-    for _, p := range products {
-        results = append(results, models.SearchResult{ID: p.ID, Name: p.Name,Slug: p.Slug, Type: "product"})
-    }
-
-    // Nhà cung cấp
-    var suppliers []struct{ ID int; Name string; Slug string }
-    configs.DB.Raw("SELECT id, name,slug FROM suppliers WHERE name LIKE ?", "%"+keyword+"%").Scan(&suppliers)
-    for _, s := range suppliers {
-        results = append(results, models.SearchResult{ID: s.ID, Name: s.Name,Slug: s.Slug, Type: "supplier"})
-    }
-
-    // Loại (category)
-    var categories []struct{ ID int; Name string; Slug string }
-    configs.DB.Raw("SELECT id, name,slug FROM categories WHERE name LIKE ?", "%"+keyword+"%").Scan(&categories)
-    for _, c := range categories {
-        results = append(results, models.SearchResult{ID: c.ID, Name: c.Name,Slug: c.Slug, Type: "category"})
-    }
-
-    // Đơn hàng (order)
-    var orders []struct{ ID int }
-    configs.DB.Raw("SELECT id FROM orders WHERE id LIKE ?", "%"+keyword+"%").Scan(&orders)
-    for _, o := range orders {
-        results = append(results, models.SearchResult{ID: o.ID, Name: fmt.Sprintf("Order #%d", o.ID), Type: "order"})
-    }
-
-    // Phiếu nhập (purchase)
-    var purchases []struct{ ID int }
-    configs.DB.Raw("SELECT id FROM purchases WHERE id LIKE ?", "%"+keyword+"%").Scan(&purchases)
-    for _, p := range purchases {
-        results = append(results, models.SearchResult{ID: p.ID, Name: fmt.Sprintf("Purchase #%d", p.ID), Type: "purchase"})
-    }
-
-    return results, nil
-}
\ No newline at end of file
+// searchIntent: những gì nhận diện được từ chuỗi tìm kiếm
+type searchIntent struct {
+	Text       string
+	OrderID    uint
+	PurchaseID uint
+	TxnRef     string
+	Email      string
+	Phone      string // dạng 0xxxxxxxxx
+	SKU        string
+	Number     bool
+}
+
+func detectSearchIntent(q string) (searchIntent, []string) {
+	in := searchIntent{Text: q}
+	var detected []string
+	compact := strings.NewReplacer(" ", "", ".", "", "-", "", "(", "", ")", "").Replace(q)
+
+	switch {
+	case txnRefRe.MatchString(q):
+		in.TxnRef = q
+		detected = append(detected, "txn_ref")
+	case orderNumberRe.MatchString(q):
+		id, _ := strconv.ParseUint(orderNumberRe.FindStringSubmatch(q)[1], 10, 64)
+		in.OrderID = uint(id)
+		detected = append(detected, "order_number")
+	case purchaseNumberRe.MatchString(q):
+		id, _ := strconv.ParseUint(purchaseNumberRe.FindStringSubmatch(q)[1], 10, 64)
+		in.PurchaseID = uint(id)
+		detected = append(detected, "purchase_number")
+	case strings.Contains(q, "@") && emailRe.MatchString(q):
+		in.Email = strings.ToLower(q)
+		detected = append(detected, "email")
+	case phoneRe.MatchString(compact):
+		in.Phone = compact
+		if strings.HasPrefix(in.Phone, "+84") {
+			in.Phone = "0" + in.Phone[3:]
+		} else if strings.HasPrefix(in.Phone, "84") {
+			in.Phone = "0" + in.Phone[2:]
+		}
+		detected = append(detected, "phone")
+	case plainNumberRe.MatchString(q):
+		id, _ := strconv.ParseUint(q, 10, 64)
+		in.OrderID, in.PurchaseID, in.Number = uint(id), uint(id), true
+		detected = append(detected, "number")
+	}
+	if skuRe.MatchString(q) && in.TxnRef == "" {
+		in.SKU = q
+		detected = append(detected, "sku")
+	}
+	return in, detected
+}
+
+// typesFor chọn loại cần tìm khi admin không chỉ định
+func typesFor(in searchIntent) []string {
+	switch {
+	case in.TxnRef != "":
+		return []string{SearchTypeOrder}
+	case in.OrderID != 0 && !in.Number:
+		return []string{SearchTypeOrder}
+	case in.PurchaseID != 0 && !in.Number:
+		return []string{SearchTypePurchase}
+	case in.Email != "", in.Phone != "":
+		return []string{SearchTypeUser, SearchTypeOrder}
+	case in.SKU != "":
+		return []string{SearchTypeVariant, SearchTypeProduct, SearchTypePurchase}
+	}
+	return AdminSearchTypes
+}
+
+// SearchAll tìm kiếm toàn cục cho admin, phân trang theo từng loại
+func SearchAll(keyword string, opt AdminSearchOptions) (*models.AdminSearchResponse, error) {
+	keyword = strings.TrimSpace(keyword)
+	if opt.Limit <= 0 || opt.Limit > 50 {
+		opt.Limit = 5
+	}
+	if opt.Page <= 0 {
+		opt.Page = 1
+	}
+	in, detected := detectSearchIntent(keyword)
+	types := opt.Types
+	if len(types) == 0 {
+		types = typesFor(in)
+	}
+
+	res := &models.AdminSearchResponse{Query: keyword, Detected: detected, Groups: []models.AdminSearchGroup{}}
+	if detected == nil {
+		res.Detected = []string{}
+	}
+	for _, t := range types {
+		var (
+			group *models.AdminSearchGroup
+			err   error
+		)
+		switch t {
+		case SearchTypeOrder:
+			group, err = searchOrders(in, opt)
+		case SearchTypeUser:
+			group, err = searchUsers(in, opt)
+		case SearchTypeProduct:
+			group, err = searchProducts(in, opt)
+		case SearchTypeVariant:
+			group, err = searchVariants(in, opt)
+		case SearchTypeCategory:
+			group, err = searchNamed(in, opt, SearchTypeCategory, "categories")
+		case SearchTypeSupplier:
+			group, err = searchNamed(in, opt, SearchTypeSupplier, "suppliers")
+		case SearchTypePurchase:
+			group, err = searchPurchases(in, opt)
+		default:
+			return nil, fmt.Errorf("unknown search type %q", t)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("search %s: %w", t, err)
+		}
+		res.Groups = append(res.Groups, *group)
+	}
+	return res, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func like(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+func prefix(s string) string {
+	return likeEscaper.Replace(s) + "%"
+}
+
+// paginate đếm tổng rồi lấy trang hiện tại
+func paginate(q *gorm.DB, opt AdminSearchOptions, t string, dest interface{}) (*models.AdminSearchGroup, error) {
+	g := &models.AdminSearchGroup{Type: t, Page: opt.Page, Limit: opt.Limit, Items: []models.AdminSearchHit{}}
+	if err := q.Session(&gorm.Session{}).Count(&g.Total).Error; err != nil {
+		return nil, err
+	}
+	if g.Total == 0 {
+		return g, nil
+	}
+	if err := q.Offset((opt.Page - 1) * opt.Limit).Limit(opt.Limit).Scan(dest).Error; err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func searchOrders(in searchIntent, opt AdminSearchOptions) (*models.AdminSearchGroup, error) {
+	q := configs.DB.Table("orders").
+		Joins("LEFT JOIN users ON users.id = orders.customer_id").
+		Select("orders.id, orders.status, orders.payment_method, orders.txn_ref, orders.total, orders.created_at, COALESCE(users.username, '') AS username, COALESCE(users.email, '') AS email, COALESCE(users.phone, '') AS phone")
+	switch {
+	case in.TxnRef != "":
+		q = q.Where("orders.txn_ref = ?", in.TxnRef)
+	case in.OrderID != 0:
+		q = q.Where("orders.id = ?", in.OrderID)
+	case in.Email != "":
+		q = q.Where("users.email LIKE ?", like(in.Email))
+	case in.Phone != "":
+		q = q.Where("users.phone LIKE ?", like(in.Phone[1:]))
+	default:
+		q = q.Where("users.username LIKE ? OR orders.txn_ref LIKE ?", like(in.Text), prefix(in.Text))
+	}
+	if opt.OrderStatus != "" {
+		q = q.Where("orders.status = ?", opt.OrderStatus)
+	}
+	q = q.Order("orders.created_at DESC")
+
+	var rows []struct {
+		ID            uint
+		Status        string
+		PaymentMethod string
+		TxnRef        string
+		Total         float64
+		CreatedAt     time.Time
+		Username      string
+		Email         string
+		Phone         string
+	}
+	g, err := paginate(q, opt, SearchTypeOrder, &rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range rows {
+		g.Items = append(g.Items, models.AdminSearchHit{
+			ID:       o.ID,
+			Type:     SearchTypeOrder,
+			Name:     fmt.Sprintf("Order #%d", o.ID),
+			Subtitle: fmt.Sprintf("%s · %s · %.0f", o.Username, o.Status, o.Total),
+			Data: map[string]interface{}{
+				"status": o.Status, "payment_method": o.PaymentMethod, "txn_ref": o.TxnRef,
+				"total": o.Total, "created_at": o.CreatedAt,
+				"customer": map[string]string{"username": o.Username, "email": o.Email, "phone": o.Phone},
+			},
+		})
+	}
+	return g, nil
+}
+
+func searchUsers(in searchIntent, opt AdminSearchOptions) (*models.AdminSearchGroup, error) {
+	q := configs.DB.Table("users").Select("id, username, email, COALESCE(phone, '') AS phone, role, created_at")
+	switch {
+	case in.Email != "":
+		q = q.Where("email LIKE ?", like(in.Email))
+	case in.Phone != "":
+		q = q.Where("phone LIKE ?", like(in.Phone[1:]))
+	case in.Number:
+		q = q.Where("phone LIKE ? OR id = ?", like(in.Text), in.OrderID)
+	default:
+		q = q.Where("username LIKE ? OR email LIKE ? OR phone LIKE ?", like(in.Text), like(in.Text), like(in.Text))
+	}
+	q = q.Order("id DESC")
+
+	var rows []struct {
+		ID        uint
+		Username  string
+		Email     string
+		Phone     string
+		Role      string
+		CreatedAt time.Time
+	}
+	g, err := paginate(q, opt, SearchTypeUser, &rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range rows {
+		g.Items = append(g.Items, models.AdminSearchHit{
+			ID:       u.ID,
+			Type:     SearchTypeUser,
+			Name:     u.Username,
+			Subtitle: strings.Trim(u.Email+" · "+u.Phone, " ·"),
+			Data: map[string]interface{}{
+				"email": u.Email, "phone": u.Phone, "role": u.Role, "created_at": u.CreatedAt,
+			},
+		})
+	}
+	return g, nil
+}
+
+// searchProducts dùng search index (bỏ dấu, sửa lỗi gõ) rồi phân trang theo thứ hạng
+func searchProducts(in searchIntent, opt AdminSearchOptions) (*models.AdminSearchGroup, error) {
+	g := &models.AdminSearchGroup{Type: SearchTypeProduct, Page: opt.Page, Limit: opt.Limit, Items: []models.AdminSearchHit{}}
+	matches := search.Default.Search(in.Text, true)
+	g.Total = int64(len(matches))
+
+	start := min((opt.Page-1)*opt.Limit, len(matches))
+	end := min(start+opt.Limit, len(matches))
+	if start == end {
+		return g, nil
+	}
+	ids := make([]uint, 0, end-start)
+	for _, m := range matches[start:end] {
+		ids = append(ids, m.ProductID)
+	}
+
+	var products []models.Product
+	if err := configs.DB.Preload("Category").Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	byID := map[uint]models.Product{}
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	for _, id := range ids {
+		p, ok := byID[id]
+		if !ok {
+			continue
+		}
+		g.Items = append(g.Items, models.AdminSearchHit{
+			ID:       p.ID,
+			Type:     SearchTypeProduct,
+			Name:     p.Name,
+			Subtitle: p.Category.Name,
+			Slug:     p.Slug,
+			Data: map[string]interface{}{
+				"price": p.Price, "discount": p.Discount, "image": p.Image,
+				"category_id": p.CategoryID, "category": p.Category.Name,
+			},
+		})
+	}
+	return g, nil
+}
+
+func searchVariants(in searchIntent, opt AdminSearchOptions) (*models.AdminSearchGroup, error) {
+	q := configs.DB.Table("product_variants").
+		Joins("JOIN products ON products.id = product_variants.product_id").
+		Select(`product_variants.id, product_variants.product_id, product_variants.sku, product_variants.size,
+			product_variants.color, product_variants.price, product_variants.stock, products.name AS product_name, products.slug`).
+		Where("product_variants.sku LIKE ?", like(in.Text)).
+		Order(exactFirst("product_variants.sku", in.Text)).
+		Order("product_variants.sku")
+
+	var rows []struct {
+		ID          uint
+		ProductID   uint
+		SKU         string `gorm:"column:sku"`
+		Size        string
+		Color       string
+		Price       float64
+		Stock       int
+		ProductName string
+		Slug        string
+	}
+	g, err := paginate(q, opt, SearchTypeVariant, &rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range rows {
+		g.Items = append(g.Items, models.AdminSearchHit{
+			ID:       v.ID,
+			Type:     SearchTypeVariant,
+			Name:     v.SKU,
+			Subtitle: strings.Trim(fmt.Sprintf("%s · %s/%s", v.ProductName, v.Size, v.Color), " ·/"),
+			Slug:     v.Slug,
+			Data: map[string]interface{}{
+				"product_id": v.ProductID, "product_name": v.ProductName, "sku": v.SKU,
+				"size": v.Size, "color": v.Color, "price": v.Price, "stock": v.Stock,
+			},
+		})
+	}
+	return g, nil
+}
+
+// exactFirst: đưa kết quả khớp chính xác / khớp đầu lên trước
+func exactFirst(column, text string) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:  fmt.Sprintf("CASE WHEN %s = ? THEN 0 WHEN %s LIKE ? THEN 1 ELSE 2 END", column, column),
+		Vars: []interface{}{text, prefix(text)},
+	}}
+}
+
+// searchNamed: category và supplier tìm theo tên hoặc slug (không dấu)
+func searchNamed(in searchIntent, opt AdminSearchOptions, t, table string) (*models.AdminSearchGroup, error) {
+	cols := "id, name, slug"
+	if table == "suppliers" {
+		cols += ", COALESCE(phone, '') AS phone, COALESCE(email, '') AS email"
+	} else {
+		cols += ", parent_id"
+	}
+	q := configs.DB.Table(table).Select(cols)
+	if s := slug.Make(in.Text); s != "" {
+		q = q.Where("name LIKE ? OR slug LIKE ?", like(in.Text), like(s))
+	} else {
+		q = q.Where("name LIKE ?", like(in.Text))
+	}
+	q = q.Order(exactFirst("name", in.Text)).Order("name")
+
+	var rows []struct {
+		ID       uint
+		Name     string
+		Slug     string
+		Phone    string
+		Email    string
+		ParentID *uint
+	}
+	g, err := paginate(q, opt, t, &rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		hit := models.AdminSearchHit{ID: r.ID, Type: t, Name: r.Name, Slug: r.Slug}
+		if table == "suppliers" {
+			hit.Subtitle = strings.Trim(r.Phone+" · "+r.Email, " ·")
+			hit.Data = map[string]interface{}{"phone": r.Phone, "email": r.Email}
+		} else {
+			hit.Data = map[string]interface{}{"parent_id": r.ParentID}
+		}
+		g.Items = append(g.Items, hit)
+	}
+	return g, nil
+}
+
+func searchPurchases(in searchIntent, opt AdminSearchOptions) (*models.AdminSearchGroup, error) {
+	q := configs.DB.Table("purchases").
+		Joins("LEFT JOIN suppliers ON suppliers.id = purchases.supplier_id").
+		Joins("LEFT JOIN product_variants ON product_variants.id = purchases.variant_id").
+		Select(`purchases.id, purchases.quantity, purchases.cost_price, purchases.created_at,
+			COALESCE(suppliers.name, '') AS supplier_name, COALESCE(product_variants.sku, '') AS sku`)
+	switch {
+	case in.PurchaseID != 0:
+		q = q.Where("purchases.id = ?", in.PurchaseID)
+	default:
+		q = q.Where("suppliers.name LIKE ? OR product_variants.sku LIKE ?", like(in.Text), like(in.Text))
+	}
+	q = q.Order("purchases.created_at DESC")
+
+	var rows []struct {
+		ID           uint
+		Quantity     int
+		CostPrice    float64
+		CreatedAt    time.Time
+		SupplierName string
+		SKU          string `gorm:"column:sku"`
+	}
+	g, err := paginate(q, opt, SearchTypePurchase, &rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range rows {
+		g.Items = append(g.Items, models.AdminSearchHit{
+			ID:       p.ID,
+			Type:     SearchTypePurchase,
+			Name:     fmt.Sprintf("Purchase #%d", p.ID),
+			Subtitle: strings.Trim(fmt.Sprintf("%s · %s x%d", p.SupplierName, p.SKU, p.Quantity), " ·"),
+			Data: map[string]interface{}{
+				"supplier": p.SupplierName, "sku": p.SKU, "quantity": p.Quantity,
+				"cost_price": p.CostPrice, "total": p.CostPrice * float64(p.Quantity), "created_at": p.CreatedAt,
+			},
+		})
+	}
+	return g, nil
+}
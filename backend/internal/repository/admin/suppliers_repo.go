@@ -3,7 +3,10 @@ package admin
 import (
 	"backend/configs"
 	"backend/internal/models"
-	"github.com/gosimple/slug"
+	"backend/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // Supplier CRUD
@@ -20,7 +23,11 @@ func GetSupplierDetail(id uint) (*models.Supplier, error) {
 }
 
 func CreateSupplier(s *models.Supplier) (*models.Supplier, error) {
-	s.Slug = slug.Make(s.Name)
+	newSlug, err := repository.UniqueSlug(configs.DB, models.SlugEntitySupplier, s.Name, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.Slug = newSlug
 	if err := configs.DB.Create(s).Error; err != nil {
 		return nil, err
 	}
@@ -33,21 +40,55 @@ func UpdateSupplier(id uint, newData *models.Supplier) (*models.Supplier, error)
         return nil, err
     }
 
-    if s.Name != newData.Name {
-        s.Name = newData.Name
-        s.Slug = slug.Make(newData.Name)
-    }
-    s.Phone = newData.Phone
-    s.Email = newData.Email
-    s.Address = newData.Address
-
-    if err := configs.DB.Model(&s).Updates(s).Error; err != nil {
+    err := configs.DB.Transaction(func(tx *gorm.DB) error {
+        if s.Name != newData.Name {
+            newSlug, err := repository.UniqueSlug(tx, models.SlugEntitySupplier, newData.Name, s.ID)
+            if err != nil {
+                return err
+            }
+            if err := repository.RecordSlugChange(tx, models.SlugEntitySupplier, s.ID, s.Slug, newSlug); err != nil {
+                return err
+            }
+            s.Name = newData.Name
+            s.Slug = newSlug
+        }
+        s.Phone = newData.Phone
+        s.Email = newData.Email
+        s.Address = newData.Address
+        return tx.Model(&s).Updates(s).Error
+    })
+    if err != nil {
         return nil, err
     }
     return &s, nil
 }
 
+// GetSupplierBySlug tìm supplier theo slug hiện tại
+func GetSupplierBySlug(slug string) (*models.Supplier, error) {
+	var supplier models.Supplier
+	err := configs.DB.Preload("Purchases").Where("slug = ?", slug).First(&supplier).Error
+	return &supplier, err
+}
 
-func DeleteSupplier(id uint) error {
-	return configs.DB.Delete(&models.Supplier{}, id).Error
+// DeleteSupplier: còn phiếu nhập thì lưu trữ, chưa có thì xoá hẳn. Trả về true nếu chỉ lưu trữ.
+func DeleteSupplier(id uint) (bool, error) {
+	archived := false
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Supplier{}, id).Error; err != nil {
+			return err
+		}
+		refs, err := countRefs(tx, id, map[string]string{"purchases": "supplier_id"})
+		if err != nil {
+			return err
+		}
+		if refs > 0 {
+			archived = true
+			return archiveAt(tx, &models.Supplier{}, id, time.Now())
+		}
+		if err := repository.DeleteSlugHistory(tx, models.SlugEntitySupplier, id); err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Supplier{}, id).Error
+	})
+	return archived, err
 }
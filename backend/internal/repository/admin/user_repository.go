@@ -3,6 +3,9 @@ package admin
 import (
 	"backend/configs"
 	"backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // ================= GET ALL USERS =================
@@ -22,17 +25,31 @@ func UpdateUser(id uint, newData *models.User) (*models.User, error) {
 	user.Email = newData.Email
 	user.Phone = newData.Phone
 	user.Address = newData.Address
+	roleChanged := user.Role != newData.Role
 	user.Role = newData.Role
 	user.UpdatedAt = configs.DB.NowFunc() 
 
-	if err := configs.DB.Model(&user).Updates(map[string]interface{}{
+	updates := map[string]interface{}{
 		"username":   user.Username,
 		"email":      user.Email,
 		"phone":      user.Phone,
 		"address":    user.Address,
 		"role":       user.Role,
 		"updated_at": user.UpdatedAt,
-	}).Error; err != nil {
+	}
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if roleChanged {
+			// đổi role: vô hiệu hoá token đang dùng, refresh token cũng bị thu hồi
+			updates["token_version"] = gorm.Expr("token_version + 1")
+			if err := tx.Model(&models.RefreshToken{}).
+				Where("user_id = ? AND revoked_at IS NULL", user.ID).
+				Update("revoked_at", user.UpdatedAt).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -40,8 +57,40 @@ func UpdateUser(id uint, newData *models.User) (*models.User, error) {
 }
 
 // ================= DELETE USER =================
-func DeleteUser(id uint) error {
-	return configs.DB.Delete(&models.User{}, id).Error
+// DeleteUser luôn xoá mềm (đơn hàng, phiếu nhập, review còn tham chiếu), thu hồi mọi phiên đăng nhập
+// và trả stock giỏ hàng của user; trả về các variant được cộng stock
+func DeleteUser(id uint) ([]uint, error) {
+	var released []uint
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
+		}
+		if user.Role == "admin" {
+			var admins int64
+			if err := tx.Model(&models.User{}).Where("role = ? AND id <> ?", "admin", id).Count(&admins).Error; err != nil {
+				return err
+			}
+			if admins == 0 {
+				return ErrLastAdmin
+			}
+		}
+		now := time.Now()
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+			return err
+		}
+		var err error
+		if released, err = releaseCarts(tx, "user_id = ?", id); err != nil {
+			return err
+		}
+		return archiveAt(tx, &models.User{}, id, now)
+	})
+	return released, err
 }
 func GetAllLoginLogs() ([]models.LoginLog, error) {
 	var logs []models.LoginLog
@@ -54,4 +103,52 @@ func GetLoginLogsByUserID(userID uint) ([]models.LoginLog, error) {
 	var logs []models.LoginLog
 	err := configs.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&logs).Error
 	return logs, err
-}
\ No newline at end of file
+}
+// LoginLogFilter: lọc log đăng nhập cho admin
+type LoginLogFilter struct {
+	UserID uint
+	Status string
+	IP     string
+	Email  string
+	Limit  int
+}
+
+func GetLoginLogs(f LoginLogFilter) ([]models.LoginLog, error) {
+	q := configs.DB.Model(&models.LoginLog{})
+	if f.UserID != 0 {
+		q = q.Where("user_id = ?", f.UserID)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if f.IP != "" {
+		q = q.Where("ip = ?", f.IP)
+	}
+	if f.Email != "" {
+		q = q.Where("email = ?", f.Email)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var logs []models.LoginLog
+	err := q.Order("created_at desc").Find(&logs).Error
+	return logs, err
+}
+
+// GetLockedAccounts: tài khoản đang bị khoá hoặc đang có lần đăng nhập sai
+func GetLockedAccounts() ([]models.User, error) {
+	var users []models.User
+	err := configs.DB.Where("locked_until > ? OR failed_logins > 0", time.Now()).
+		Order("locked_until desc, failed_logins desc").Find(&users).Error
+	return users, err
+}
+
+// UnlockUser mở khoá và xoá bộ đếm đăng nhập sai
+func UnlockUser(id uint) error {
+	var user models.User
+	if err := configs.DB.Select("id").First(&user, id).Error; err != nil {
+		return err
+	}
+	return configs.DB.Model(&user).
+		Updates(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
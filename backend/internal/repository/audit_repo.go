@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"time"
+)
+
+func CreateAuditLog(entry *models.AuditLog) error {
+	return configs.DB.Create(entry).Error
+}
+
+// SnapshotRows đọc các bản ghi có column = key vào rows (con trỏ tới slice model), kể cả bản ghi đã lưu trữ
+func SnapshotRows(rows interface{}, column string, key string) error {
+	return configs.DB.Unscoped().Where(column+" = ?", key).Find(rows).Error
+}
+
+// CleanupAuditLogs xoá audit log cũ hơn before (chính sách lưu giữ)
+func CleanupAuditLogs(before time.Time) (int64, error) {
+	res := configs.DB.Where("created_at < ?", before).Delete(&models.AuditLog{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+)
+
+// Bảng categories nhỏ nên load toàn bộ rồi duyệt cây trong bộ nhớ,
+// tránh phụ thuộc recursive CTE của MySQL 8.
+
+// LoadCategories trả về tất cả category theo thứ tự hiển thị
+func LoadCategories() ([]models.Category, error) {
+	var cats []models.Category
+	err := configs.DB.Order("sort_order asc, id asc").Find(&cats).Error
+	return cats, err
+}
+
+// BuildCategoryTree dựng cây từ danh sách phẳng, rootID = nil để lấy từ gốc
+func BuildCategoryTree(cats []models.Category, rootID *uint) []models.Category {
+	byParent := map[uint][]models.Category{}
+	var roots []models.Category
+	for _, c := range cats {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			byParent[*c.ParentID] = append(byParent[*c.ParentID], c)
+		}
+	}
+
+	var attach func(nodes []models.Category, seen map[uint]bool) []models.Category
+	attach = func(nodes []models.Category, seen map[uint]bool) []models.Category {
+		out := make([]models.Category, 0, len(nodes))
+		for _, n := range nodes {
+			if seen[n.ID] {
+				continue
+			}
+			seen[n.ID] = true
+			n.Children = attach(byParent[n.ID], seen)
+			out = append(out, n)
+		}
+		return out
+	}
+
+	if rootID == nil {
+		return attach(roots, map[uint]bool{})
+	}
+	return attach(byParent[*rootID], map[uint]bool{*rootID: true})
+}
+
+// DescendantCategoryIDs trả về id của category và toàn bộ con cháu
+func DescendantCategoryIDs(rootID uint) ([]uint, error) {
+	var rows []struct {
+		ID       uint
+		ParentID *uint
+	}
+	if err := configs.DB.Model(&models.Category{}).Select("id, parent_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	children := map[uint][]uint{}
+	for _, r := range rows {
+		if r.ParentID != nil {
+			children[*r.ParentID] = append(children[*r.ParentID], r.ID)
+		}
+	}
+
+	ids := []uint{rootID}
+	seen := map[uint]bool{rootID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range children[ids[i]] {
+			if !seen[c] {
+				seen[c] = true
+				ids = append(ids, c)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// DescendantCategoryIDsBySlug giống DescendantCategoryIDs nhưng tìm theo slug
+func DescendantCategoryIDsBySlug(slug string) ([]uint, error) {
+	var cat models.Category
+	if err := configs.DB.Select("id").Where("slug = ?", slug).First(&cat).Error; err != nil {
+		return nil, err
+	}
+	return DescendantCategoryIDs(cat.ID)
+}
+
+// CategoryBreadcrumb trả về đường dẫn từ gốc tới category id
+func CategoryBreadcrumb(id uint) ([]models.Category, error) {
+	var path []models.Category
+	seen := map[uint]bool{}
+	current := &id
+	for current != nil {
+		if seen[*current] {
+			return nil, errors.New("category cycle detected")
+		}
+		seen[*current] = true
+		var c models.Category
+		if err := configs.DB.First(&c, *current).Error; err != nil {
+			return nil, err
+		}
+		path = append([]models.Category{c}, path...)
+		current = c.ParentID
+	}
+	return path, nil
+}
+
+// IsCategoryDescendant: candidate có nằm trong cây con của ancestor (kể cả chính nó) không
+func IsCategoryDescendant(ancestor, candidate uint) (bool, error) {
+	ids, err := DescendantCategoryIDs(ancestor)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if id == candidate {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,298 @@
+package customer
+
+import (
+	"backend/configs"
+	"backend/internal/repository"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BestSeller struct {
+	ProductID    uint    `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	Slug         string  `json:"slug"`
+	CategoryID   uint    `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	TotalSold    int     `json:"total_sold"`
+	OrderCount   int     `json:"order_count"`
+	Revenue      float64 `json:"revenue"`
+	Image        string  `json:"image"`
+	Price        float64 `json:"price"`      // Giá gốc từ bảng products
+	SoldPrice    float64 `json:"sold_price"` // Giá khách mua (đã giảm nếu có)
+	PrevSold     int     `json:"prev_sold,omitempty"`
+	RatingAvg    float64 `json:"rating_avg"`
+	RatingCount  int     `json:"rating_count"`
+}
+
+// BestSellerOptions: Days = 0 là toàn thời gian; CategorySlug gồm cả category con
+type BestSellerOptions struct {
+	Days         int
+	CategorySlug string
+	Limit        int
+}
+
+type CategoryBestSellers struct {
+	CategoryID   uint         `json:"category_id"`
+	CategoryName string       `json:"category_name"`
+	Slug         string       `json:"slug"`
+	Products     []BestSeller `json:"products"`
+}
+
+// soldItems: order_items của đơn đã bán trong [from, to), nối variant -> product
+func soldItems(from, to *time.Time) *gorm.DB {
+	q := configs.DB.Table("order_items").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("JOIN product_variants ON product_variants.id = order_items.variant_id").
+		Joins("JOIN products ON products.id = product_variants.product_id").
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Where("orders.status IN ? AND products.deleted_at IS NULL", SoldOrderStatuses)
+	if from != nil {
+		q = q.Where("orders.created_at >= ?", *from)
+	}
+	if to != nil {
+		q = q.Where("orders.created_at < ?", *to)
+	}
+	return q
+}
+
+func aggregateSold(q *gorm.DB, categoryIDs []uint, limit int) ([]BestSeller, error) {
+	if categoryIDs != nil {
+		q = q.Where("products.category_id IN ?", categoryIDs)
+	}
+	rows := []BestSeller{}
+	err := q.Select(`products.id AS product_id, products.name AS product_name, products.slug, products.image, products.price,
+			products.category_id, products.rating_avg, products.rating_count, COALESCE(categories.name, '') AS category_name,
+			SUM(order_items.quantity) AS total_sold, COUNT(DISTINCT orders.id) AS order_count,
+			SUM(order_items.quantity * order_items.price) AS revenue, MAX(order_items.price) AS sold_price`).
+		Group("products.id, products.name, products.slug, products.image, products.price, products.category_id, products.rating_avg, products.rating_count, categories.name").
+		Order("total_sold DESC, revenue DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func windowStart(days int) *time.Time {
+	if days <= 0 {
+		return nil
+	}
+	t := time.Now().AddDate(0, 0, -days)
+	return &t
+}
+
+func categoryScope(slug string) ([]uint, error) {
+	if slug == "" {
+		return nil, nil
+	}
+	return repository.DescendantCategoryIDsBySlug(slug)
+}
+
+func computeBestSellers(opt BestSellerOptions) ([]BestSeller, error) {
+	ids, err := categoryScope(opt.CategorySlug)
+	if err != nil {
+		return nil, err
+	}
+	return aggregateSold(soldItems(windowStart(opt.Days), nil), ids, opt.Limit)
+}
+
+// computeTrending: so sánh lượng bán kỳ này với kỳ trước cùng độ dài
+func computeTrending(opt BestSellerOptions) ([]BestSeller, error) {
+	ids, err := categoryScope(opt.CategorySlug)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Days <= 0 {
+		opt.Days = 7
+	}
+	now := time.Now()
+	from := now.AddDate(0, 0, -opt.Days)
+	prevFrom := now.AddDate(0, 0, -2*opt.Days)
+
+	current, err := aggregateSold(soldItems(&from, nil), ids, 200)
+	if err != nil {
+		return nil, err
+	}
+	var prev []struct {
+		ProductID uint
+		TotalSold int
+	}
+	pq := soldItems(&prevFrom, &from)
+	if ids != nil {
+		pq = pq.Where("products.category_id IN ?", ids)
+	}
+	if err := pq.Select("products.id AS product_id, SUM(order_items.quantity) AS total_sold").
+		Group("products.id").Scan(&prev).Error; err != nil {
+		return nil, err
+	}
+	prevSold := map[uint]int{}
+	for _, p := range prev {
+		prevSold[p.ProductID] = p.TotalSold
+	}
+
+	for i := range current {
+		current[i].PrevSold = prevSold[current[i].ProductID]
+	}
+	// tăng trưởng tuyệt đối, hoà thì ưu tiên bán nhiều hơn
+	sortBestSellers(current, func(b BestSeller) float64 {
+		return float64(b.TotalSold-b.PrevSold) + float64(b.TotalSold)/1000
+	})
+	if len(current) > opt.Limit {
+		current = current[:opt.Limit]
+	}
+	return current, nil
+}
+
+func sortBestSellers(list []BestSeller, score func(BestSeller) float64) {
+	sort.SliceStable(list, func(i, j int) bool { return score(list[i]) > score(list[j]) })
+}
+
+// computeCategoryRankings: bảng xếp hạng cho từng category gốc (gồm category con)
+func computeCategoryRankings(opt BestSellerOptions) ([]CategoryBestSellers, error) {
+	cats, err := repository.LoadCategories()
+	if err != nil {
+		return nil, err
+	}
+	out := []CategoryBestSellers{}
+	for _, c := range cats {
+		if c.ParentID != nil {
+			continue
+		}
+		ids, err := repository.DescendantCategoryIDs(c.ID)
+		if err != nil {
+			return nil, err
+		}
+		products, err := aggregateSold(soldItems(windowStart(opt.Days), nil), ids, opt.Limit)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, CategoryBestSellers{CategoryID: c.ID, CategoryName: c.Name, Slug: c.Slug, Products: products})
+	}
+	return out, nil
+}
+
+// --- cache ---
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// key cache gồm days/category/limit từ query string, giới hạn số entry để không phình vô hạn
+const (
+	maxBestSellerDays         = 365
+	maxBestSellerCacheEntries = 256
+)
+
+var bestSellerCache = struct {
+	sync.Mutex
+	entries map[string]cacheEntry
+}{entries: map[string]cacheEntry{}}
+
+// BestSellerCacheTTL: BESTSELLER_CACHE_MINUTES, mặc định 10 phút
+func BestSellerCacheTTL() time.Duration {
+	if m, err := strconv.Atoi(os.Getenv("BESTSELLER_CACHE_MINUTES")); err == nil && m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return 10 * time.Minute
+}
+
+func cached(key string, compute func() (interface{}, error)) (interface{}, error) {
+	bestSellerCache.Lock()
+	e, ok := bestSellerCache.entries[key]
+	bestSellerCache.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.value, nil
+	}
+	v, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	bestSellerCache.Lock()
+	if len(bestSellerCache.entries) >= maxBestSellerCacheEntries {
+		for k, e := range bestSellerCache.entries {
+			if !now.Before(e.expires) {
+				delete(bestSellerCache.entries, k)
+			}
+		}
+		// vẫn đầy: bỏ hết, job nền sẽ tính lại các cửa sổ hay dùng
+		if len(bestSellerCache.entries) >= maxBestSellerCacheEntries {
+			bestSellerCache.entries = map[string]cacheEntry{}
+		}
+	}
+	bestSellerCache.entries[key] = cacheEntry{value: v, expires: now.Add(BestSellerCacheTTL())}
+	bestSellerCache.Unlock()
+	return v, nil
+}
+
+func normalizeBestSellerOptions(opt BestSellerOptions) BestSellerOptions {
+	if opt.Limit <= 0 || opt.Limit > 50 {
+		opt.Limit = 6
+	}
+	if opt.Days < 0 {
+		opt.Days = 0
+	}
+	if opt.Days > maxBestSellerDays {
+		opt.Days = maxBestSellerDays
+	}
+	return opt
+}
+
+func (o BestSellerOptions) key(kind string) string {
+	return fmt.Sprintf("%s|%d|%s|%d", kind, o.Days, o.CategorySlug, o.Limit)
+}
+
+// GetBestSellers: sản phẩm bán chạy (đã cache)
+func GetBestSellers(opt BestSellerOptions) ([]BestSeller, error) {
+	opt = normalizeBestSellerOptions(opt)
+	v, err := cached(opt.key("best"), func() (interface{}, error) { return computeBestSellers(opt) })
+	if err != nil {
+		return nil, err
+	}
+	return v.([]BestSeller), nil
+}
+
+// GetTrendingProducts: sản phẩm tăng trưởng mạnh nhất so với kỳ trước (đã cache)
+func GetTrendingProducts(opt BestSellerOptions) ([]BestSeller, error) {
+	opt = normalizeBestSellerOptions(opt)
+	v, err := cached(opt.key("trending"), func() (interface{}, error) { return computeTrending(opt) })
+	if err != nil {
+		return nil, err
+	}
+	return v.([]BestSeller), nil
+}
+
+// GetBestSellersByCategory: xếp hạng bán chạy theo từng category gốc (đã cache)
+func GetBestSellersByCategory(opt BestSellerOptions) ([]CategoryBestSellers, error) {
+	opt = normalizeBestSellerOptions(opt)
+	opt.CategorySlug = ""
+	v, err := cached(opt.key("category"), func() (interface{}, error) { return computeCategoryRankings(opt) })
+	if err != nil {
+		return nil, err
+	}
+	return v.([]CategoryBestSellers), nil
+}
+
+// RefreshBestSellerCache xoá cache và tính sẵn các cửa sổ hay dùng (gọi từ job nền)
+func RefreshBestSellerCache() error {
+	bestSellerCache.Lock()
+	bestSellerCache.entries = map[string]cacheEntry{}
+	bestSellerCache.Unlock()
+
+	for _, days := range []int{0, 7, 30, 90} {
+		opt := BestSellerOptions{Days: days}
+		if _, err := GetBestSellers(opt); err != nil {
+			return err
+		}
+		if _, err := GetBestSellersByCategory(opt); err != nil {
+			return err
+		}
+	}
+	_, err := GetTrendingProducts(BestSellerOptions{Days: 7})
+	return err
+}
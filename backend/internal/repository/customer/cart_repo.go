@@ -23,7 +23,7 @@ func AddToCart(item *models.CartItem) error {
     if item.VariantID == 0 {
         if err := configs.DB.
             Joins("JOIN products ON products.id = product_variants.product_id").
-            Where("products.name = ?", item.ProductName).
+            Where("products.name = ? AND products.deleted_at IS NULL", item.ProductName).
             Order("product_variants.id ASC").
             First(&variant).Error; err != nil {
             return errors.New("Không tìm thấy variant cho sản phẩm: " + item.ProductName)
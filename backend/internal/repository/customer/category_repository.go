@@ -3,28 +3,43 @@ package customer
 import (
 	"backend/configs"
 	"backend/internal/models"
+	"backend/internal/repository"
+
+	"github.com/gosimple/slug"
 )
 
-// Lấy tất cả category theo group_name
-func GetCategoriesByGroup(groupName string) ([]models.Category, error) {
-	var categories []models.Category
-	if err := configs.DB.Where("group_name = ?", groupName).Find(&categories).Error; err != nil {
+// Lấy category gốc theo tên hoặc slug, trả về category đó kèm cây con
+func GetCategoriesByGroup(groupName string) (*models.Category, []models.Category, error) {
+	var root models.Category
+	if err := configs.DB.Where("name = ? OR slug = ?", groupName, slug.Make(groupName)).First(&root).Error; err != nil {
+		return nil, nil, err
+	}
+	cats, err := repository.LoadCategories()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &root, repository.BuildCategoryTree(cats, &root.ID), nil
+}
+
+// Cây category đầy đủ cho menu
+func GetCategoryTree() ([]models.Category, error) {
+	cats, err := repository.LoadCategories()
+	if err != nil {
 		return nil, err
 	}
-	return categories, nil
+	return repository.BuildCategoryTree(cats, nil), nil
 }
 
-// Lấy tất cả sản phẩm theo category_slug
+// Lấy tất cả sản phẩm theo category_slug (bao gồm category con)
 func GetProductsByCategorySlug(slug string) ([]models.Product, error) {
-	var category models.Category
-	if err := configs.DB.Where("slug = ?", slug).First(&category).Error; err != nil {
+	ids, err := repository.DescendantCategoryIDsBySlug(slug)
+	if err != nil {
 		return nil, err
 	}
 
 	var products []models.Product
-	if err := configs.DB.Where("category_id = ?", category.ID).Find(&products).Error; err != nil {
+	if err := configs.DB.Where("category_id IN ?", ids).Find(&products).Error; err != nil {
 		return nil, err
 	}
 	return products, nil
 }
-
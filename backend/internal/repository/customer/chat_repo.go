@@ -28,6 +28,7 @@ func (r *ChatRepository) FindProducts(category string, keywords []string, minPri
 	if category != "" {
 		// Nếu truyền slug (ví dụ "ao-polo") thì match slug; vẫn giữ fallback name LIKE cho những category khác
 		query = query.Joins("JOIN categories ON categories.id = products.category_id").
+			Where("categories.deleted_at IS NULL").
 			Where("categories.slug = ? OR categories.name LIKE ?", category, "%"+category+"%")
 	}
 
@@ -0,0 +1,224 @@
+package customer
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// ProductFilter: điều kiện lọc cho trang danh sách sản phẩm
+type ProductFilter struct {
+	CategorySlug string
+	MinPrice     float64
+	MaxPrice     float64
+	Sizes        []string
+	Colors       []string
+	Attributes   map[string][]string // attribute code -> value slugs
+	Sort         string
+	Page         int
+	Limit        int
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type AttributeFacet struct {
+	Code   string       `json:"code"`
+	Name   string       `json:"name"`
+	Values []FacetValue `json:"values"`
+}
+
+type PriceRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+type ProductFacets struct {
+	Categories []FacetValue     `json:"categories"`
+	Sizes      []FacetValue     `json:"sizes"`
+	Colors     []FacetValue     `json:"colors"`
+	Attributes []AttributeFacet `json:"attributes"`
+	Price      PriceRange       `json:"price"`
+}
+
+type FilterResult struct {
+	Products []models.Product `json:"products"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	Limit    int              `json:"limit"`
+	Facets   ProductFacets    `json:"facets"`
+}
+
+// facet dimension bị bỏ qua khi tính count cho chính nó
+const (
+	facetNone     = ""
+	facetCategory = "category"
+	facetPrice    = "price"
+	facetSize     = "size"
+	facetColor    = "color"
+)
+
+// filteredProducts áp dụng mọi filter trừ dimension skip (hoặc attribute code skip)
+func filteredProducts(f ProductFilter, skip string) *gorm.DB {
+	q := configs.DB.Model(&models.Product{})
+
+	if f.CategorySlug != "" && skip != facetCategory {
+		// bao gồm cả category con
+		ids, err := repository.DescendantCategoryIDsBySlug(f.CategorySlug)
+		if err != nil {
+			ids = []uint{0}
+		}
+		q = q.Where("products.category_id IN ?", ids)
+	}
+	if skip != facetPrice {
+		if f.MinPrice > 0 {
+			q = q.Where("products.price >= ?", f.MinPrice)
+		}
+		if f.MaxPrice > 0 {
+			q = q.Where("products.price <= ?", f.MaxPrice)
+		}
+	}
+	if len(f.Sizes) > 0 && skip != facetSize {
+		q = q.Where(`EXISTS (SELECT 1 FROM product_variants fv JOIN size_options fs ON fs.id = fv.size_id
+			WHERE fv.product_id = products.id AND fv.deleted_at IS NULL AND fs.name IN ?)`, f.Sizes)
+	}
+	if len(f.Colors) > 0 && skip != facetColor {
+		q = q.Where(`EXISTS (SELECT 1 FROM product_variants fv JOIN color_options fc ON fc.id = fv.color_id
+			WHERE fv.product_id = products.id AND fv.deleted_at IS NULL AND fc.name IN ?)`, f.Colors)
+	}
+	for code, slugs := range f.Attributes {
+		if len(slugs) == 0 || skip == "attr:"+code {
+			continue
+		}
+		q = q.Where(`EXISTS (SELECT 1 FROM product_attribute_values fpav
+			JOIN attribute_values fav ON fav.id = fpav.attribute_value_id
+			JOIN attributes fa ON fa.id = fav.attribute_id
+			WHERE fpav.product_id = products.id AND fa.code = ? AND fav.slug IN ?)`, code, slugs)
+	}
+	return q
+}
+
+// FilterProducts trả về sản phẩm đã lọc (phân trang) kèm facet count cho từng giá trị filter
+func FilterProducts(f ProductFilter) (*FilterResult, error) {
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = 20
+	}
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	res := &FilterResult{Page: f.Page, Limit: f.Limit}
+
+	if err := filteredProducts(f, facetNone).Count(&res.Total).Error; err != nil {
+		return nil, err
+	}
+
+	q := filteredProducts(f, facetNone).Preload("Variants").Preload("Category")
+	switch f.Sort {
+	case "price_asc":
+		q = q.Order("products.price ASC")
+	case "price_desc":
+		q = q.Order("products.price DESC")
+	case "discount":
+		q = q.Order("products.discount DESC")
+	default:
+		q = q.Order("products.created_at DESC")
+	}
+	if err := q.Offset((f.Page - 1) * f.Limit).Limit(f.Limit).Find(&res.Products).Error; err != nil {
+		return nil, err
+	}
+
+	facets, err := computeFacets(f)
+	if err != nil {
+		return nil, err
+	}
+	res.Facets = *facets
+	return res, nil
+}
+
+func computeFacets(f ProductFilter) (*ProductFacets, error) {
+	facets := &ProductFacets{
+		Categories: []FacetValue{},
+		Sizes:      []FacetValue{},
+		Colors:     []FacetValue{},
+		Attributes: []AttributeFacet{},
+	}
+
+	if err := filteredProducts(f, facetCategory).
+		Joins("JOIN categories ON categories.id = products.category_id").
+		Select("categories.slug AS value, categories.name AS label, COUNT(DISTINCT products.id) AS count").
+		Group("categories.id, categories.slug, categories.name").
+		Order("categories.name").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+
+	if err := filteredProducts(f, facetSize).
+		Joins("JOIN product_variants ON product_variants.product_id = products.id AND product_variants.deleted_at IS NULL").
+		Joins("JOIN size_options ON size_options.id = product_variants.size_id").
+		Select("size_options.name AS value, size_options.name AS label, COUNT(DISTINCT products.id) AS count").
+		Group("size_options.id, size_options.name, size_options.sort_order").
+		Order("size_options.sort_order, size_options.id").
+		Scan(&facets.Sizes).Error; err != nil {
+		return nil, err
+	}
+
+	if err := filteredProducts(f, facetColor).
+		Joins("JOIN product_variants ON product_variants.product_id = products.id AND product_variants.deleted_at IS NULL").
+		Joins("JOIN color_options ON color_options.id = product_variants.color_id").
+		Select("color_options.name AS value, color_options.name AS label, COUNT(DISTINCT products.id) AS count").
+		Group("color_options.id, color_options.name, color_options.sort_order").
+		Order("color_options.sort_order, color_options.id").
+		Scan(&facets.Colors).Error; err != nil {
+		return nil, err
+	}
+
+	if err := filteredProducts(f, facetPrice).
+		Select("COALESCE(MIN(products.price), 0) AS min, COALESCE(MAX(products.price), 0) AS max").
+		Scan(&facets.Price).Error; err != nil {
+		return nil, err
+	}
+
+	// Thuộc tính: theo category nếu có cấu hình, ngược lại lấy tất cả
+	var attrs []models.Attribute
+	aq := configs.DB.Model(&models.Attribute{}).Order("attributes.sort_order, attributes.id")
+	if f.CategorySlug != "" {
+		var assigned int64
+		sub := configs.DB.Table("category_attributes").
+			Joins("JOIN categories ON categories.id = category_attributes.category_id").
+			Where("categories.slug = ?", f.CategorySlug)
+		sub.Count(&assigned)
+		if assigned > 0 {
+			aq = aq.Where("attributes.id IN (?)", sub.Select("category_attributes.attribute_id"))
+		}
+	}
+	if err := aq.Find(&attrs).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range attrs {
+		values := []FacetValue{}
+		if err := filteredProducts(f, "attr:"+a.Code).
+			Joins("JOIN product_attribute_values ON product_attribute_values.product_id = products.id").
+			Joins("JOIN attribute_values ON attribute_values.id = product_attribute_values.attribute_value_id").
+			Where("attribute_values.attribute_id = ?", a.ID).
+			Select("attribute_values.slug AS value, attribute_values.value AS label, COUNT(DISTINCT products.id) AS count").
+			Group("attribute_values.id, attribute_values.slug, attribute_values.value, attribute_values.sort_order").
+			Order("attribute_values.sort_order, attribute_values.id").
+			Scan(&values).Error; err != nil {
+			return nil, err
+		}
+		facets.Attributes = append(facets.Attributes, AttributeFacet{Code: a.Code, Name: a.Name, Values: values})
+	}
+	return facets, nil
+}
+
+// AttributeCodes: danh sách code để controller nhận diện query param thuộc tính
+func AttributeCodes() ([]string, error) {
+	var codes []string
+	err := configs.DB.Model(&models.Attribute{}).Pluck("code", &codes).Error
+	return codes, err
+}
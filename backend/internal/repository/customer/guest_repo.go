@@ -0,0 +1,499 @@
+package customer
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrGuestSessionInvalid = errors.New("guest session not found or expired")
+	ErrInsufficientStock   = errors.New("Số lượng trong kho không đủ")
+	ErrGuestCartEmpty      = errors.New("giỏ hàng trống")
+)
+
+// MigrateGuestCheckout thêm cột guest_* vào orders và cho phép customer_id NULL (đơn khách vãng lai)
+func MigrateGuestCheckout() error {
+	m := configs.DB.Migrator()
+	for _, field := range []string{"GuestName", "GuestEmail", "GuestPhone", "GuestAddress", "GuestSessionID"} {
+		if !m.HasColumn(&models.Order{}, field) {
+			if err := m.AddColumn(&models.Order{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	cols, err := m.ColumnTypes(&models.Order{})
+	if err != nil {
+		return err
+	}
+	for _, c := range cols {
+		if c.Name() == "customer_id" {
+			if nullable, ok := c.Nullable(); ok && !nullable {
+				return m.AlterColumn(&models.Order{}, "CustomerID")
+			}
+		}
+	}
+	return nil
+}
+
+func newGuestToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateGuestSession tạo phiên khách mới sống trong ttl
+func CreateGuestSession(ttl time.Duration) (*models.GuestSession, error) {
+	token, err := newGuestToken()
+	if err != nil {
+		return nil, err
+	}
+	s := models.GuestSession{Token: token, ExpiresAt: time.Now().Add(ttl)}
+	if err := configs.DB.Create(&s).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// TouchGuestSession kiểm tra token còn hạn và gia hạn thêm ttl (sliding expiry)
+func TouchGuestSession(token string, ttl time.Duration) (*models.GuestSession, error) {
+	if token == "" {
+		return nil, ErrGuestSessionInvalid
+	}
+	var s models.GuestSession
+	if err := configs.DB.Where("token = ? AND expires_at > ?", token, time.Now()).First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGuestSessionInvalid
+		}
+		return nil, err
+	}
+	s.ExpiresAt = time.Now().Add(ttl)
+	if err := configs.DB.Model(&s).Update("expires_at", s.ExpiresAt).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func GetGuestCart(sessionID uint) ([]models.CartItem, error) {
+	items := []models.CartItem{}
+	err := configs.DB.Where("session_id = ?", sessionID).Order("id ASC").Find(&items).Error
+	return items, err
+}
+
+func lockVariant(tx *gorm.DB, variantID uint64) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Product").
+		First(&variant, variantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func strPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// cartLine dựng cart item từ variant hiện tại (giá, ảnh, size/màu mới nhất)
+func cartLine(variant *models.ProductVariant, quantity int) models.CartItem {
+	item := models.CartItem{
+		ProductName: variant.Product.Name,
+		VariantID:   uint64(variant.ID),
+		Quantity:    quantity,
+		Price:       variant.Price,
+		SKU:         strPtr(variant.SKU),
+		Color:       strPtr(variant.Color),
+		Size:        strPtr(variant.Size),
+		Image:       strPtr(variant.Image),
+	}
+	if item.Image == nil {
+		item.Image = strPtr(variant.Product.Image)
+	}
+	if p := variant.Product; p.Price > 0 && p.DiscountedPrice > 0 && p.DiscountedPrice < p.Price {
+		// cùng tỉ lệ giảm của sản phẩm áp cho variant
+		discounted := variant.Price * variant.Product.DiscountedPrice / variant.Product.Price
+		item.DiscountedPrice = &discounted
+	}
+	return item
+}
+
+// SetGuestCartQuantity đặt số lượng của 1 variant trong giỏ khách (0 = xoá), giữ/trả stock theo chênh lệch
+func SetGuestCartQuantity(sessionID uint, variantID uint64, quantity int, add bool) (*models.CartItem, error) {
+	var result *models.CartItem
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		variant, err := lockVariant(tx, variantID)
+		if err != nil {
+			return err
+		}
+
+		var existing models.CartItem
+		found := true
+		if err := tx.Where("session_id = ? AND variant_id = ?", sessionID, variantID).First(&existing).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			found = false
+		}
+
+		target := quantity
+		if add {
+			target = existing.Quantity + quantity
+		}
+		if target < 0 {
+			target = 0
+		}
+		delta := target - existing.Quantity
+		if delta > variant.Stock {
+			return ErrInsufficientStock
+		}
+		if delta != 0 {
+			if err := tx.Model(&models.ProductVariant{}).Where("id = ?", variant.ID).
+				UpdateColumn("stock", gorm.Expr("stock - ?", delta)).Error; err != nil {
+				return err
+			}
+		}
+
+		if target == 0 {
+			if found {
+				return tx.Delete(&existing).Error
+			}
+			return nil
+		}
+
+		line := cartLine(variant, target)
+		line.SessionID = &sessionID
+		if found {
+			line.ID = existing.ID
+			line.CreatedAt = existing.CreatedAt
+		}
+		if err := tx.Save(&line).Error; err != nil {
+			return err
+		}
+		result = &line
+		return nil
+	})
+	return result, err
+}
+
+// returnStock trả lại stock đang giữ cho variant (kể cả variant đã lưu trữ)
+func returnStock(tx *gorm.DB, variantID uint64, quantity int) error {
+	return tx.Unscoped().Model(&models.ProductVariant{}).Where("id = ?", variantID).
+		UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error
+}
+
+// releaseSessionCart trả stock và xoá toàn bộ giỏ của phiên khách, trả về các variant được trả stock
+func releaseSessionCart(tx *gorm.DB, sessionID uint) ([]uint, error) {
+	var items []models.CartItem
+	if err := tx.Where("session_id = ?", sessionID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		if err := returnStock(tx, item.VariantID, item.Quantity); err != nil {
+			return nil, err
+		}
+		ids = append(ids, uint(item.VariantID))
+	}
+	return ids, tx.Where("session_id = ?", sessionID).Delete(&models.CartItem{}).Error
+}
+
+// ClearGuestCart xoá giỏ khách và trả stock (khách bấm "xoá hết")
+func ClearGuestCart(sessionID uint) ([]uint, error) {
+	var ids []uint
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ids, err = releaseSessionCart(tx, sessionID)
+		return err
+	})
+	return ids, err
+}
+
+// GuestCheckoutInput: khách vãng lai chỉ cần email + địa chỉ giao hàng
+type GuestCheckoutInput struct {
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	Phone         string `json:"phone"`
+	Address       string `json:"address"`
+	PaymentMethod string `json:"payment_method"`
+}
+
+// CreateGuestOrder tạo đơn từ giỏ hàng phía server của phiên khách (giá lấy từ giỏ, không tin client).
+// Các dòng giỏ được chuyển hẳn sang đơn (stock đang giữ thuộc về đơn), kể cả đơn VNPay chưa thanh toán:
+// khách không sửa/xoá giỏ để lấy lại stock được nữa; huỷ thanh toán thì CancelGuestOrder trả hàng về giỏ.
+func CreateGuestOrder(sessionID uint, in GuestCheckoutInput) (*models.Order, error) {
+	items, err := GetGuestCart(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrGuestCartEmpty
+	}
+
+	order := models.Order{
+		PaymentMethod:  strings.ToLower(in.PaymentMethod),
+		Status:         "pending",
+		TxnRef:         fmt.Sprintf("%d-g%d", time.Now().UnixNano(), sessionID),
+		GuestName:      strings.TrimSpace(in.Name),
+		GuestEmail:     strings.TrimSpace(in.Email),
+		GuestPhone:     strings.TrimSpace(in.Phone),
+		GuestAddress:   strings.TrimSpace(in.Address),
+		GuestSessionID: &sessionID,
+	}
+	for _, it := range items {
+		price := it.Price
+		if it.DiscountedPrice != nil {
+			price = *it.DiscountedPrice
+		}
+		order.Total += price * float64(it.Quantity)
+		line := models.OrderItem{
+			ProductName: it.ProductName,
+			VariantID:   uint(it.VariantID),
+			Quantity:    it.Quantity,
+			Price:       price,
+		}
+		if it.SKU != nil {
+			line.SKU = *it.SKU
+		}
+		if it.Image != nil {
+			line.Image = *it.Image
+		}
+		if it.Color != nil {
+			line.Color = *it.Color
+		}
+		if it.Size != nil {
+			line.Size = *it.Size
+		}
+		order.Items = append(order.Items, line)
+	}
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Create(&order).Error; err != nil {
+			return err
+		}
+		for i := range order.Items {
+			order.Items[i].OrderID = order.ID
+			if err := tx.Create(&order.Items[i]).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("session_id = ?", sessionID).Delete(&models.CartItem{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// cancelGuestOrder huỷ đơn khách còn pending: nếu phiên còn hạn thì đưa hàng về lại giỏ (vẫn giữ stock),
+// ngược lại trả stock. Đơn không còn pending thì bỏ qua (callback gọi lại nhiều lần).
+func cancelGuestOrder(tx *gorm.DB, orderID uint, backToCart bool) ([]uint, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").
+		Where("id = ? AND status = ?", orderID, "pending").First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := tx.Model(&order).Update("status", "cancelled").Error; err != nil {
+		return nil, err
+	}
+
+	var released []uint
+	for _, it := range order.Items {
+		if backToCart && order.GuestSessionID != nil {
+			variant, err := lockVariant(tx, uint64(it.VariantID))
+			if err == nil {
+				var existing models.CartItem
+				found := tx.Where("session_id = ? AND variant_id = ?", *order.GuestSessionID, it.VariantID).
+					First(&existing).Error == nil
+				line := cartLine(variant, it.Quantity)
+				line.SessionID = order.GuestSessionID
+				if found {
+					line.ID = existing.ID
+					line.CreatedAt = existing.CreatedAt
+					line.Quantity += existing.Quantity
+				}
+				if err := tx.Save(&line).Error; err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if !errors.Is(err, ErrVariantNotFound) {
+				return nil, err
+			}
+		}
+		if err := returnStock(tx, uint64(it.VariantID), it.Quantity); err != nil {
+			return nil, err
+		}
+		released = append(released, it.VariantID)
+	}
+	return released, nil
+}
+
+// CancelGuestOrder huỷ đơn VNPay của khách khi thanh toán thất bại, trả về các variant được trả stock
+func CancelGuestOrder(orderID uint) ([]uint, error) {
+	var released []uint
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Select("id, guest_session_id").First(&order, orderID).Error; err != nil {
+			return err
+		}
+		backToCart := false
+		if order.GuestSessionID != nil {
+			var n int64
+			if err := tx.Model(&models.GuestSession{}).
+				Where("id = ? AND expires_at > ?", *order.GuestSessionID, time.Now()).Count(&n).Error; err != nil {
+				return err
+			}
+			backToCart = n > 0
+		}
+		var err error
+		released, err = cancelGuestOrder(tx, orderID, backToCart)
+		return err
+	})
+	return released, err
+}
+
+// MergeAdjustment: dòng giỏ khách không gộp được đủ số lượng khi đăng nhập
+type MergeAdjustment struct {
+	VariantID   uint64 `json:"variant_id"`
+	ProductName string `json:"product_name"`
+	Requested   int    `json:"requested"`
+	Merged      int    `json:"merged"`
+	Reason      string `json:"reason"`
+}
+
+// MergeGuestCart chuyển giỏ của phiên khách vào cart_items của user.
+// Với từng dòng: trả stock đang giữ, kiểm tra lại tồn kho rồi giữ lại tối đa có thể cho user.
+// Phiên khách bị xoá sau khi gộp.
+func MergeGuestCart(token string, userID uint) ([]MergeAdjustment, error) {
+	adjustments := []MergeAdjustment{}
+	if token == "" {
+		return adjustments, nil
+	}
+	var session models.GuestSession
+	if err := configs.DB.Where("token = ?", token).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return adjustments, nil
+		}
+		return nil, err
+	}
+
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var items []models.CartItem
+		if err := tx.Where("session_id = ?", session.ID).Find(&items).Error; err != nil {
+			return err
+		}
+		for _, g := range items {
+			variant, err := lockVariant(tx, g.VariantID)
+			if errors.Is(err, ErrVariantNotFound) {
+				// variant đã lưu trữ: trả lại phần stock giỏ khách đang giữ
+				if err := returnStock(tx, g.VariantID, g.Quantity); err != nil {
+					return err
+				}
+				adjustments = append(adjustments, MergeAdjustment{VariantID: g.VariantID, ProductName: g.ProductName, Requested: g.Quantity, Reason: "variant removed"})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// stock khả dụng = tồn hiện tại + phần giỏ khách đang giữ
+			available := variant.Stock + g.Quantity
+
+			var existing models.CartItem
+			found := tx.Where("user_id = ? AND variant_id = ? AND session_id IS NULL", userID, g.VariantID).
+				First(&existing).Error == nil
+			want := g.Quantity
+			merged := want
+			if merged > available {
+				merged = available
+			}
+			// phần user đã có trong giỏ vẫn đang được giữ stock riêng
+			newStock := available - merged
+			if err := tx.Model(&models.ProductVariant{}).Where("id = ?", variant.ID).
+				UpdateColumn("stock", newStock).Error; err != nil {
+				return err
+			}
+			if merged < want {
+				adjustments = append(adjustments, MergeAdjustment{VariantID: g.VariantID, ProductName: g.ProductName, Requested: want, Merged: merged, Reason: "insufficient stock"})
+			}
+			if merged == 0 {
+				continue
+			}
+
+			line := cartLine(variant, merged)
+			line.UserID = uint64(userID)
+			if found {
+				line.ID = existing.ID
+				line.CreatedAt = existing.CreatedAt
+				line.Quantity += existing.Quantity
+			}
+			if err := tx.Save(&line).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("session_id = ?", session.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
+
+// CleanupExpiredGuestSessions trả stock của giỏ khách đã hết hạn và của đơn VNPay bỏ dở (huỷ đơn), xoá phiên,
+// trả về các variant vừa được trả stock
+func CleanupExpiredGuestSessions() ([]uint, error) {
+	var sessions []models.GuestSession
+	if err := configs.DB.Where("expires_at <= ?", time.Now()).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	var released []uint
+	for _, s := range sessions {
+		err := configs.DB.Transaction(func(tx *gorm.DB) error {
+			ids, err := releaseSessionCart(tx, s.ID)
+			if err != nil {
+				return err
+			}
+			released = append(released, ids...)
+
+			var pending []uint
+			if err := tx.Model(&models.Order{}).
+				Where("guest_session_id = ? AND status = ? AND payment_method = ?", s.ID, "pending", "vnpay").
+				Pluck("id", &pending).Error; err != nil {
+				return err
+			}
+			for _, orderID := range pending {
+				ids, err := cancelGuestOrder(tx, orderID, false)
+				if err != nil {
+					return err
+				}
+				released = append(released, ids...)
+			}
+			return tx.Delete(&s).Error
+		})
+		if err != nil {
+			return released, err
+		}
+	}
+	return released, nil
+}
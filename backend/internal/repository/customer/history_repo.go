@@ -3,6 +3,7 @@ package customer
 import (
 	"backend/configs"
 	"backend/internal/models"
+	"backend/internal/repository"
 	"errors"          
 	"gorm.io/gorm" 
 )
@@ -13,8 +14,8 @@ func GetCustomerOrdersByStatus(customerID uint, statuses []string) ([]models.Ord
 	err := configs.DB.
 		Where("customer_id = ? AND status IN ?", customerID, statuses).
 		Preload("Items").
-		Preload("Customer").
-		Preload("Staff").
+		Preload("Customer", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Staff", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
 		Order("created_at desc").
 		Find(&orders).Error
 	if err != nil {
@@ -22,10 +23,7 @@ func GetCustomerOrdersByStatus(customerID uint, statuses []string) ([]models.Ord
 	}
 
 	for i := range orders {
-		var address models.CustomerAddress
-		if err := configs.DB.Where("user_id = ? AND is_default = ?", orders[i].CustomerID, true).First(&address).Error; err == nil {
-			orders[i].CustomerAddress = &address
-		}
+		orders[i].CustomerAddress = repository.OrderAddress(&orders[i], true)
 	}
 
 	return orders, nil
@@ -4,6 +4,7 @@ package customer
 import (
     "backend/configs"
     "backend/internal/models"
+    "backend/internal/repository"
     "gorm.io/gorm"
     "log"
 )
@@ -26,7 +27,7 @@ func CreateOrder(order *models.Order, address *models.CustomerAddress, userID ui
         }
 
         // 3. Lưu address mới (dùng user_id)
-        address.UserID = order.CustomerID
+        address.UserID = userID
         address.IsDefault = true
         if err := tx.Create(address).Error; err != nil {
             return err
@@ -48,20 +49,14 @@ func GetOrderByTxnRef(txnRef string) (*models.Order, error) {
     var order models.Order
     if err := configs.DB.
         Preload("Items").
-        Preload("Customer").
+        Preload("Customer", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
         Where("txn_ref = ?", txnRef).
         First(&order).Error; err != nil {
         return nil, err
     }
 
-    // lấy address mới nhất của customer
-    var addr models.CustomerAddress
-    if err := configs.DB.
-        Where("user_id = ?", order.CustomerID).
-        Order("created_at DESC").
-        First(&addr).Error; err == nil {
-        order.CustomerAddress= &addr 
-    }
+    // lấy address mới nhất của customer (đơn khách vãng lai: địa chỉ lưu trên đơn)
+    order.CustomerAddress = repository.OrderAddress(&order, false)
 
     return &order, nil
 }
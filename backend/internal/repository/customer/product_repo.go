@@ -1,11 +1,14 @@
 package customer
 
 import (
+	"errors"
 	"math/rand"
 	"time"
 
 	"backend/configs"
 	"backend/internal/models"
+	"backend/internal/repository"
+	"gorm.io/gorm"
 )
 
 func GetAllProducts() ([]models.Product, error) {
@@ -26,13 +29,19 @@ func GetLatestProducts(limit int) ([]models.Product, error) {
 func GetRandomProducts(group string) ([]models.Product, error) {
     var products []models.Product
 
-    query := configs.DB.Preload("Variants").
-        Joins("JOIN categories ON categories.id = products.category_id")
-
-    if group == "vest" {
-        query = query.Where("categories.name LIKE ?", "%Bộ Vest%")
-    } else if group == "phu-kien" {
-        query = query.Where("categories.group_name LIKE ?", "%Phụ Kiện%")
+    query := configs.DB.Preload("Variants")
+
+    // group = slug category (vd: bo-vest, phu-kien), lấy cả category con
+    // slug không tồn tại → không gợi ý gì, không trả cả catalogue
+    if group != "" {
+        ids, err := repository.DescendantCategoryIDsBySlug(group)
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return []models.Product{}, nil
+        }
+        if err != nil {
+            return nil, err
+        }
+        query = query.Where("products.category_id IN ?", ids)
     }
 
     if err := query.Find(&products).Error; err != nil {
@@ -53,43 +62,16 @@ func GetRandomProducts(group string) ([]models.Product, error) {
 func GetProductBySlug(slug string) (models.Product, error) {
 	var product models.Product
 	err := configs.DB.Preload("Variants").Preload("Category").
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc, id asc") }).
+		Preload("AttributeValues.Attribute").
 		Where("slug = ?", slug).
 		First(&product).Error
+	if err == nil {
+		product.Breadcrumb, _ = repository.CategoryBreadcrumb(product.CategoryID)
+	}
 	return product, err
 }
 
-type BestSeller struct {
-    ProductID   uint    `json:"product_id"`
-    ProductName string  `json:"product_name"`
-    Slug        string  `json:"slug"`
-    TotalSold   int     `json:"total_sold"`
-    Image       string  `json:"image"`
-    Price       float64 `json:"price"`             
-    SoldPrice   float64 `json:"sold_price"`        
-}
-
-func GetBestSellers(limit int) ([]BestSeller, error) {
-    var bestSellers []BestSeller
-
-    err := configs.DB.Table("order_items").
-        Select(`
-            products.id as product_id,
-            products.name as product_name,
-            products.slug as slug,
-            products.image as image,
-            products.price as price,          -- Giá gốc từ bảng products
-            MAX(order_items.price) as sold_price, -- Giá khách mua (đã giảm nếu có)
-            SUM(order_items.quantity) as total_sold
-        `).
-        Joins("JOIN products ON products.name = order_items.product_name").
-        Group("products.id, products.name, products.slug, products.image, products.price").
-        Order("total_sold DESC").
-        Limit(limit).
-        Scan(&bestSellers).Error
-
-    return bestSellers, err
-}
-
 func GetDiscountedProducts() ([]models.Product, error) {
 	var products []models.Product
 
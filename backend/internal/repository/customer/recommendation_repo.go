@@ -0,0 +1,349 @@
+package customer
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"math"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+const (
+	recommendPerProduct = 12
+	// số ứng viên giá gần nhất được chấm điểm cho mỗi sản phẩm
+	similarCandidates = 4 * recommendPerProduct
+	similarPriceBand  = 0.3 // ±30% giá được coi là cùng phân khúc
+)
+
+// Trạng thái đơn được tính là đã bán (bỏ pending và cancelled)
+var SoldOrderStatuses = []string{"confirmed", "shipped", "completed"}
+
+type scoredPair struct {
+	ProductID        uint
+	RelatedProductID uint
+	Score            float64
+}
+
+// topPerProduct giữ tối đa n cặp điểm cao nhất cho mỗi sản phẩm
+func topPerProduct(pairs []scoredPair, n int) []scoredPair {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].ProductID != pairs[j].ProductID {
+			return pairs[i].ProductID < pairs[j].ProductID
+		}
+		return pairs[i].Score > pairs[j].Score
+	})
+	out := pairs[:0]
+	count := map[uint]int{}
+	for _, p := range pairs {
+		if count[p.ProductID] < n {
+			count[p.ProductID]++
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// boughtTogetherPairs: số đơn có cả 2 sản phẩm (theo variant -> product)
+func boughtTogetherPairs() ([]scoredPair, error) {
+	orderProducts := configs.DB.Table("order_items").
+		Select("DISTINCT order_items.order_id, product_variants.product_id").
+		Joins("JOIN product_variants ON product_variants.id = order_items.variant_id").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.status IN ?", SoldOrderStatuses)
+
+	var pairs []scoredPair
+	err := configs.DB.Table("(?) AS a", orderProducts).
+		Select("a.product_id, b.product_id AS related_product_id, COUNT(*) AS score").
+		Joins("JOIN (?) AS b ON b.order_id = a.order_id AND b.product_id <> a.product_id", orderProducts).
+		Group("a.product_id, b.product_id").
+		Scan(&pairs).Error
+	return pairs, err
+}
+
+type similarProduct struct {
+	ID         uint
+	CategoryID uint
+	ParentID   *uint
+	Price      float64
+}
+
+// nearestByPrice: tối đa k sản phẩm giá gần list[i] nhất (list đã sort theo giá), bỏ qua những sản phẩm skip trả về true
+func nearestByPrice(list []similarProduct, i, k int, skip func(similarProduct) bool) []similarProduct {
+	out := make([]similarProduct, 0, k)
+	lo, hi := i-1, i+1
+	for len(out) < k && (lo >= 0 || hi < len(list)) {
+		var c similarProduct
+		if hi >= len(list) || (lo >= 0 && list[i].Price-list[lo].Price <= list[hi].Price-list[i].Price) {
+			c = list[lo]
+			lo--
+		} else {
+			c = list[hi]
+			hi++
+		}
+		if skip == nil || !skip(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// groupByPrice gom sản phẩm theo key, mỗi nhóm sort theo giá
+func groupByPrice(products []similarProduct, key func(similarProduct) (uint, bool)) map[uint][]similarProduct {
+	groups := map[uint][]similarProduct{}
+	for _, p := range products {
+		if k, ok := key(p); ok {
+			groups[k] = append(groups[k], p)
+		}
+	}
+	for _, g := range groups {
+		sort.Slice(g, func(i, j int) bool {
+			if g[i].Price != g[j].Price {
+				return g[i].Price < g[j].Price
+			}
+			return g[i].ID < g[j].ID
+		})
+	}
+	return groups
+}
+
+func similarScore(a, b similarProduct, sameCategory bool, attrs map[uint]map[uint]bool) float64 {
+	score := 1.5
+	if sameCategory {
+		score = 3
+	}
+	if shared, union := jaccard(attrs[a.ID], attrs[b.ID]); union > 0 {
+		score += 3 * float64(shared) / float64(union)
+	}
+	if a.Price > 0 && b.Price > 0 {
+		diff := math.Abs(a.Price-b.Price) / math.Max(a.Price, b.Price)
+		if diff <= similarPriceBand {
+			score += 2 * (1 - diff/similarPriceBand)
+		}
+	}
+	return score
+}
+
+// similarPairs: cùng category (hoặc cùng category cha), thuộc tính chung và giá gần nhau.
+// Mỗi sản phẩm chỉ chấm điểm similarCandidates ứng viên giá gần nhất trong từng nhóm thay vì mọi cặp.
+func similarPairs() ([]scoredPair, error) {
+	var products []similarProduct
+	if err := configs.DB.Table("products").
+		Select("products.id, products.category_id, categories.parent_id, products.price").
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Where("products.deleted_at IS NULL").
+		Scan(&products).Error; err != nil {
+		return nil, err
+	}
+
+	var links []models.ProductAttributeValue
+	if err := configs.DB.Find(&links).Error; err != nil {
+		return nil, err
+	}
+	attrs := map[uint]map[uint]bool{}
+	for _, l := range links {
+		if attrs[l.ProductID] == nil {
+			attrs[l.ProductID] = map[uint]bool{}
+		}
+		attrs[l.ProductID][l.AttributeValueID] = true
+	}
+
+	var pairs []scoredPair
+	byCategory := groupByPrice(products, func(p similarProduct) (uint, bool) { return p.CategoryID, true })
+	for _, list := range byCategory {
+		for i, a := range list {
+			for _, b := range nearestByPrice(list, i, similarCandidates, nil) {
+				pairs = append(pairs, scoredPair{ProductID: a.ID, RelatedProductID: b.ID, Score: similarScore(a, b, true, attrs)})
+			}
+		}
+	}
+	// category anh em (cùng cha); cùng category đã tính ở trên
+	byParent := groupByPrice(products, func(p similarProduct) (uint, bool) {
+		if p.ParentID == nil {
+			return 0, false
+		}
+		return *p.ParentID, true
+	})
+	for _, list := range byParent {
+		for i, a := range list {
+			sameCategory := func(b similarProduct) bool { return b.CategoryID == a.CategoryID }
+			for _, b := range nearestByPrice(list, i, similarCandidates, sameCategory) {
+				pairs = append(pairs, scoredPair{ProductID: a.ID, RelatedProductID: b.ID, Score: similarScore(a, b, false, attrs)})
+			}
+		}
+	}
+	return pairs, nil
+}
+
+func jaccard(a, b map[uint]bool) (shared, union int) {
+	for v := range a {
+		if b[v] {
+			shared++
+		}
+	}
+	return shared, len(a) + len(b) - shared
+}
+
+// RefreshRecommendations tính lại toàn bộ bảng product_recommendations (gọi từ job nền)
+func RefreshRecommendations() error {
+	together, err := boughtTogetherPairs()
+	if err != nil {
+		return err
+	}
+	similar, err := similarPairs()
+	if err != nil {
+		return err
+	}
+
+	var rows []models.ProductRecommendation
+	for _, p := range topPerProduct(together, recommendPerProduct) {
+		rows = append(rows, models.ProductRecommendation{ProductID: p.ProductID, Kind: models.RecommendBoughtTogether, RelatedProductID: p.RelatedProductID, Score: p.Score})
+	}
+	for _, p := range topPerProduct(similar, recommendPerProduct) {
+		rows = append(rows, models.ProductRecommendation{ProductID: p.ProductID, Kind: models.RecommendSimilar, RelatedProductID: p.RelatedProductID, Score: p.Score})
+	}
+
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ProductRecommendation{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+// loadProductsInOrder lấy sản phẩm (kèm variants) theo đúng thứ tự ids
+func loadProductsInOrder(ids []uint) ([]models.Product, error) {
+	products := []models.Product{}
+	if len(ids) == 0 {
+		return products, nil
+	}
+	var found []models.Product
+	if err := configs.DB.Preload("Variants").Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Product, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+// GetRelatedProducts: "thường được mua cùng" hoặc "sản phẩm tương tự" của 1 sản phẩm
+func GetRelatedProducts(productID uint, kind string, limit int) ([]models.Product, error) {
+	var ids []uint
+	if err := configs.DB.Model(&models.ProductRecommendation{}).
+		Where("product_id = ? AND kind = ?", productID, kind).
+		Order("score DESC").Limit(limit).
+		Pluck("related_product_id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	// job chưa chạy / sản phẩm mới: lấy sản phẩm cùng category
+	if len(ids) == 0 && kind == models.RecommendSimilar {
+		var p models.Product
+		if err := configs.DB.Select("id, category_id").First(&p, productID).Error; err != nil {
+			return nil, err
+		}
+		if err := configs.DB.Model(&models.Product{}).
+			Where("category_id = ? AND id <> ?", p.CategoryID, productID).
+			Order("created_at DESC").Limit(limit).
+			Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+	}
+	return loadProductsInOrder(ids)
+}
+
+// GetRecommendationsForUser: "gợi ý cho bạn" từ sản phẩm đã mua và đang có trong giỏ
+func GetRecommendationsForUser(userID uint, limit int) ([]models.Product, error) {
+	seeds := map[uint]float64{}
+	owned := map[uint]bool{}
+
+	var purchased []uint
+	if err := configs.DB.Table("order_items").
+		Select("DISTINCT product_variants.product_id").
+		Joins("JOIN product_variants ON product_variants.id = order_items.variant_id").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.customer_id = ? AND orders.status <> ?", userID, "cancelled").
+		Pluck("product_variants.product_id", &purchased).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range purchased {
+		seeds[id] += 1
+		owned[id] = true
+	}
+
+	var inCart []uint
+	if err := configs.DB.Table("cart_items").
+		Select("DISTINCT product_variants.product_id").
+		Joins("JOIN product_variants ON product_variants.id = cart_items.variant_id").
+		Where("cart_items.user_id = ?", userID).
+		Pluck("product_variants.product_id", &inCart).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range inCart {
+		seeds[id] += 2 // giỏ hàng thể hiện nhu cầu hiện tại
+		owned[id] = true
+	}
+
+	scores := map[uint]float64{}
+	if len(seeds) > 0 {
+		seedIDs := make([]uint, 0, len(seeds))
+		for id := range seeds {
+			seedIDs = append(seedIDs, id)
+		}
+		var recs []models.ProductRecommendation
+		if err := configs.DB.Where("product_id IN ?", seedIDs).Find(&recs).Error; err != nil {
+			return nil, err
+		}
+		for _, rec := range recs {
+			if owned[rec.RelatedProductID] {
+				continue
+			}
+			w := 1.0
+			if rec.Kind == models.RecommendSimilar {
+				w = 0.5
+			}
+			scores[rec.RelatedProductID] += seeds[rec.ProductID] * w * rec.Score
+		}
+	}
+
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] > ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	// chưa đủ: bổ sung sản phẩm mới nhất chưa mua
+	if len(ids) < limit {
+		exclude := append([]uint{0}, ids...)
+		for id := range owned {
+			exclude = append(exclude, id)
+		}
+		var fill []uint
+		if err := configs.DB.Model(&models.Product{}).
+			Where("id NOT IN ?", exclude).
+			Order("created_at DESC").Limit(limit-len(ids)).
+			Pluck("id", &fill).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, fill...)
+	}
+	return loadProductsInOrder(ids)
+}
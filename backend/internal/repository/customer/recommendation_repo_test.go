@@ -0,0 +1,59 @@
+package customer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNearestByPrice(t *testing.T) {
+	list := []similarProduct{
+		{ID: 1, CategoryID: 1, Price: 100},
+		{ID: 2, CategoryID: 2, Price: 180},
+		{ID: 3, CategoryID: 1, Price: 200},
+		{ID: 4, CategoryID: 1, Price: 210},
+		{ID: 5, CategoryID: 2, Price: 400},
+	}
+	ids := func(ps []similarProduct) []uint {
+		out := []uint{}
+		for _, p := range ps {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+
+	cases := []struct {
+		i, k int
+		skip func(similarProduct) bool
+		want []uint
+	}{
+		{2, 2, nil, []uint{4, 2}},
+		{2, 10, nil, []uint{4, 2, 1, 5}},
+		{0, 2, nil, []uint{2, 3}},
+		{4, 1, nil, []uint{4}},
+		{2, 2, func(p similarProduct) bool { return p.CategoryID == 1 }, []uint{2, 5}},
+	}
+	for _, c := range cases {
+		if got := ids(nearestByPrice(list, c.i, c.k, c.skip)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("nearestByPrice(%d, %d) = %v, want %v", c.i, c.k, got, c.want)
+		}
+	}
+}
+
+func TestSimilarCandidatesBounded(t *testing.T) {
+	var products []similarProduct
+	for i := 0; i < 500; i++ {
+		products = append(products, similarProduct{ID: uint(i + 1), CategoryID: 1, Price: float64(100 + i)})
+	}
+	list := groupByPrice(products, func(p similarProduct) (uint, bool) { return p.CategoryID, true })[1]
+	for i, a := range list {
+		got := nearestByPrice(list, i, similarCandidates, nil)
+		if len(got) != similarCandidates {
+			t.Fatalf("product %d: %d candidates, want %d", a.ID, len(got), similarCandidates)
+		}
+		for _, b := range got {
+			if b.ID == a.ID {
+				t.Fatalf("product %d paired with itself", a.ID)
+			}
+		}
+	}
+}
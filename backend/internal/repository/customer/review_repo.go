@@ -0,0 +1,229 @@
+package customer
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReviewNotPurchased  = errors.New("chỉ khách đã nhận hàng (đơn completed) mới được đánh giá")
+	ErrReviewAlreadyExists = errors.New("bạn đã đánh giá sản phẩm này")
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewOwnVote       = errors.New("không thể vote review của chính mình")
+)
+
+type ReviewSummary struct {
+	Average      float64       `json:"average"`
+	Count        int64         `json:"count"`
+	Distribution map[int]int64 `json:"distribution"` // số review theo từng mức sao 1..5
+}
+
+type ReviewPage struct {
+	Reviews []models.Review `json:"reviews"`
+	Total   int64           `json:"total"`
+	Page    int             `json:"page"`
+	Limit   int             `json:"limit"`
+	Summary ReviewSummary   `json:"summary"`
+}
+
+type ReviewListOptions struct {
+	Page   int
+	Limit  int
+	Rating int    // 0 = tất cả
+	Sort   string // newest | helpful | rating_desc | rating_asc
+}
+
+// FillReviewNames gán tên người viết / nhân viên trả lời (không lộ email, sđt ra ngoài)
+func FillReviewNames(reviews []models.Review) {
+	for i := range reviews {
+		if reviews[i].User != nil {
+			reviews[i].Reviewer = reviews[i].User.Username
+		}
+		reviews[i].Verified = reviews[i].OrderID != 0
+		for j := range reviews[i].Replies {
+			if s := reviews[i].Replies[j].Staff; s != nil {
+				reviews[i].Replies[j].StaffName = s.Username
+			}
+		}
+	}
+}
+
+// PreloadReviewRelations: user, ảnh và phản hồi của nhân viên
+func PreloadReviewRelations(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select("id, username") }).
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC, id ASC") }).
+		Preload("Replies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Replies.Staff", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select("id, username") })
+}
+
+// GetReviewSummary: điểm trung bình và phân bố sao của review đã duyệt
+func GetReviewSummary(productID uint) (ReviewSummary, error) {
+	summary := ReviewSummary{Distribution: map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	var rows []struct {
+		Rating int
+		Total  int64
+	}
+	if err := configs.DB.Model(&models.Review{}).
+		Select("rating, COUNT(*) AS total").
+		Where("product_id = ? AND status = ?", productID, "approved").
+		Group("rating").
+		Scan(&rows).Error; err != nil {
+		return summary, err
+	}
+	var sum int64
+	for _, row := range rows {
+		summary.Distribution[row.Rating] = row.Total
+		summary.Count += row.Total
+		sum += int64(row.Rating) * row.Total
+	}
+	if summary.Count > 0 {
+		summary.Average = float64(sum) / float64(summary.Count)
+	}
+	return summary, nil
+}
+
+// GetProductReviews: review đã duyệt của sản phẩm, có phân trang và lọc theo số sao
+func GetProductReviews(productID uint, opt ReviewListOptions) (*ReviewPage, error) {
+	page := &ReviewPage{Reviews: []models.Review{}, Page: opt.Page, Limit: opt.Limit}
+
+	base := func() *gorm.DB {
+		q := configs.DB.Model(&models.Review{}).Where("product_id = ? AND status = ?", productID, "approved")
+		if opt.Rating >= 1 && opt.Rating <= 5 {
+			q = q.Where("rating = ?", opt.Rating)
+		}
+		return q
+	}
+	if err := base().Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	order := "created_at DESC"
+	switch opt.Sort {
+	case "helpful":
+		order = "helpful_count DESC, created_at DESC"
+	case "rating_desc":
+		order = "rating DESC, created_at DESC"
+	case "rating_asc":
+		order = "rating ASC, created_at DESC"
+	}
+	if err := PreloadReviewRelations(base()).
+		Order(order).
+		Offset((opt.Page - 1) * opt.Limit).Limit(opt.Limit).
+		Find(&page.Reviews).Error; err != nil {
+		return nil, err
+	}
+	FillReviewNames(page.Reviews)
+
+	summary, err := GetReviewSummary(productID)
+	if err != nil {
+		return nil, err
+	}
+	page.Summary = summary
+	return page, nil
+}
+
+// FindCompletedOrderForProduct trả về đơn completed gần nhất của user có chứa variant của sản phẩm
+func FindCompletedOrderForProduct(userID, productID uint) (uint, error) {
+	var ids []uint
+	err := configs.DB.Table("orders").
+		Select("orders.id").
+		Joins("JOIN order_items ON order_items.order_id = orders.id").
+		Joins("JOIN product_variants ON product_variants.id = order_items.variant_id").
+		Where("orders.customer_id = ? AND orders.status = ? AND product_variants.product_id = ?", userID, "completed", productID).
+		Order("orders.created_at DESC").
+		Limit(1).
+		Pluck("orders.id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, ErrReviewNotPurchased
+	}
+	return ids[0], nil
+}
+
+// ReviewEligibility: user có thể đánh giá không, và review hiện có (nếu đã viết)
+type ReviewEligibility struct {
+	CanReview bool           `json:"can_review"`
+	Reason    string         `json:"reason,omitempty"`
+	Review    *models.Review `json:"review,omitempty"`
+}
+
+func GetReviewEligibility(userID, productID uint) (*ReviewEligibility, error) {
+	var existing models.Review
+	err := configs.DB.Preload("Images").Where("product_id = ? AND user_id = ?", productID, userID).First(&existing).Error
+	if err == nil {
+		return &ReviewEligibility{Reason: ErrReviewAlreadyExists.Error(), Review: &existing}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if _, err := FindCompletedOrderForProduct(userID, productID); err != nil {
+		if errors.Is(err, ErrReviewNotPurchased) {
+			return &ReviewEligibility{Reason: err.Error()}, nil
+		}
+		return nil, err
+	}
+	return &ReviewEligibility{CanReview: true}, nil
+}
+
+// CreateReview lưu review mới ở trạng thái pending (chờ admin duyệt)
+func CreateReview(review *models.Review) error {
+	orderID, err := FindCompletedOrderForProduct(review.UserID, review.ProductID)
+	if err != nil {
+		return err
+	}
+	review.OrderID = orderID
+	review.Status = "pending"
+	review.HelpfulCount = 0
+
+	var count int64
+	if err := configs.DB.Model(&models.Review{}).
+		Where("product_id = ? AND user_id = ?", review.ProductID, review.UserID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrReviewAlreadyExists
+	}
+	return configs.DB.Create(review).Error
+}
+
+// ToggleReviewHelpful bật/tắt vote "hữu ích", trả về trạng thái mới và tổng vote
+func ToggleReviewHelpful(reviewID, userID uint) (voted bool, total int, err error) {
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.Select("id, user_id").Where("id = ? AND status = ?", reviewID, "approved").First(&review).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReviewNotFound
+			}
+			return err
+		}
+		if review.UserID == userID {
+			return ErrReviewOwnVote
+		}
+
+		res := tx.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&models.ReviewVote{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			if err := tx.Create(&models.ReviewVote{ReviewID: reviewID, UserID: userID}).Error; err != nil {
+				return err
+			}
+			voted = true
+		}
+
+		var count int64
+		if err := tx.Model(&models.ReviewVote{}).Where("review_id = ?", reviewID).Count(&count).Error; err != nil {
+			return err
+		}
+		total = int(count)
+		return tx.Model(&models.Review{}).Where("id = ?", reviewID).Update("helpful_count", total).Error
+	})
+	return voted, total, err
+}
@@ -3,24 +3,103 @@ package customer
 import (
 	"backend/configs"
 	"backend/internal/models"
+	"backend/internal/search"
 )
 
-// Gợi ý sản phẩm (autocomplete)
-func SearchSuggestions(query string, limit int) ([]models.Product, error) {
-	var products []models.Product
-	err := configs.DB.
-		Where("name LIKE ?", "%"+query+"%").
-		Select("id, name, slug, image, price, discount").
-		Limit(limit).
-		Find(&products).Error
-	return products, err
+// SearchHit: sản phẩm kèm điểm xếp hạng và highlight
+type SearchHit struct {
+	models.Product
+	Score     float64          `json:"score"`
+	Highlight search.Highlight `json:"highlight"`
+}
+
+type SearchPage struct {
+	Products []SearchHit `json:"products"`
+	Total    int         `json:"total"`
+	Page     int         `json:"page"`
+	Limit    int         `json:"limit"`
 }
 
-// Tìm kiếm toàn bộ sản phẩm (SearchPage)
-func SearchProducts(query string) ([]models.Product, error) {
+// loadHits lấy sản phẩm theo thứ tự xếp hạng của index
+func loadHits(matches []search.Match, preload bool) ([]SearchHit, error) {
+	hits := []SearchHit{}
+	if len(matches) == 0 {
+		return hits, nil
+	}
+	ids := make([]uint, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ProductID
+	}
+
+	q := configs.DB.Where("id IN ?", ids)
+	if preload {
+		q = q.Preload("Category").Preload("Variants")
+	}
 	var products []models.Product
-	err := configs.DB.Preload("Category").Preload("Variants").
-		Where("name LIKE ?", "%"+query+"%").
-		Find(&products).Error
-	return products, err
+	if err := q.Find(&products).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	for _, m := range matches {
+		p, ok := byID[m.ProductID]
+		if !ok {
+			continue // index chưa kịp cập nhật
+		}
+		hits = append(hits, SearchHit{
+			Product:   p,
+			Score:     m.Score,
+			Highlight: search.Highlighter(p.Name, p.Description, m.Terms),
+		})
+	}
+	return hits, nil
+}
+
+// Gợi ý sản phẩm (autocomplete): mọi từ đều được khớp theo tiền tố
+func SearchSuggestions(query string, limit int) ([]SearchHit, error) {
+	if limit <= 0 || limit > 20 {
+		limit = 5
+	}
+	matches := search.Query(query, true)
+	search.LogQuery(models.SearchSourceSuggest, query, len(matches))
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	hits, err := loadHits(matches, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Highlight.Snippet = ""
+	}
+	return hits, nil
+}
+
+// Tìm kiếm toàn bộ sản phẩm (SearchPage), đã xếp hạng và phân trang
+func SearchProducts(query string, page, limit int) (*SearchPage, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	matches := search.Query(query, false)
+	search.LogQuery(models.SearchSourceSearch, query, len(matches))
+	res := &SearchPage{Total: len(matches), Page: page, Limit: limit}
+
+	start := (page - 1) * limit
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := min(start+limit, len(matches))
+
+	hits, err := loadHits(matches[start:end], true)
+	if err != nil {
+		return nil, err
+	}
+	res.Products = hits
+	return res, nil
 }
@@ -0,0 +1,147 @@
+package customer
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVariantInStock  = errors.New("variant is in stock")
+	ErrVariantNotFound = errors.New("variant not found")
+)
+
+// GetWishlist: sản phẩm đã lưu của user, mới nhất trước
+func GetWishlist(userID uint) ([]models.WishlistItem, error) {
+	items := []models.WishlistItem{}
+	err := configs.DB.Preload("Product.Variants").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&items).Error
+	return items, err
+}
+
+// AddToWishlist idempotent: thêm lần 2 không lỗi
+func AddToWishlist(userID, productID uint) error {
+	var count int64
+	if err := configs.DB.Model(&models.Product{}).Where("id = ?", productID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return configs.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.WishlistItem{UserID: userID, ProductID: productID}).Error
+}
+
+func RemoveFromWishlist(userID, productID uint) error {
+	return configs.DB.Where("user_id = ? AND product_id = ?", userID, productID).
+		Delete(&models.WishlistItem{}).Error
+}
+
+// GetStockSubscriptions: các variant user đang chờ báo có hàng
+func GetStockSubscriptions(userID uint) ([]models.StockSubscription, error) {
+	subs := []models.StockSubscription{}
+	err := configs.DB.Preload("Variant.Product").
+		Where("user_id = ? AND notified_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&subs).Error
+	return subs, err
+}
+
+// SubscribeBackInStock đăng ký "báo khi có hàng"; chỉ cho variant đang hết hàng
+func SubscribeBackInStock(userID, variantID uint) (*models.StockSubscription, error) {
+	var variant models.ProductVariant
+	if err := configs.DB.Select("id, stock").First(&variant, variantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, err
+	}
+	if variant.Stock > 0 {
+		return nil, ErrVariantInStock
+	}
+
+	sub := models.StockSubscription{UserID: userID, VariantID: variantID}
+	// đã từng đăng ký (và đã được báo) -> kích hoạt lại
+	err := configs.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "variant_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"notified_at": nil, "updated_at": time.Now()}),
+	}).Create(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := configs.DB.Where("user_id = ? AND variant_id = ?", userID, variantID).First(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func UnsubscribeBackInStock(userID, variantID uint) error {
+	return configs.DB.Where("user_id = ? AND variant_id = ?", userID, variantID).
+		Delete(&models.StockSubscription{}).Error
+}
+
+// RestockNotice: 1 thông báo cần gửi khi variant có hàng trở lại
+type RestockNotice struct {
+	SubscriptionID uint   `json:"subscription_id"`
+	UserID         uint   `json:"user_id"`
+	Email          string `json:"-"`
+	VariantID      uint   `json:"variant_id"`
+	ProductID      uint   `json:"product_id"`
+	ProductName    string `json:"product_name"`
+	Slug           string `json:"slug"`
+	Size           string `json:"size"`
+	Color          string `json:"color"`
+	Image          string `json:"image"`
+	Stock          int    `json:"stock"`
+}
+
+// ClaimRestockNotices lấy các đăng ký đang chờ của variant đã có hàng (stock > 0) và
+// đánh dấu notified_at. Mỗi đăng ký chỉ được claim 1 lần kể cả khi gọi đồng thời.
+// variantIDs rỗng = quét toàn bộ.
+func ClaimRestockNotices(variantIDs []uint) ([]RestockNotice, error) {
+	q := configs.DB.Table("stock_subscriptions").
+		Select(`stock_subscriptions.id AS subscription_id, stock_subscriptions.user_id, users.email,
+			product_variants.id AS variant_id, products.id AS product_id, products.name AS product_name, products.slug,
+			product_variants.size, product_variants.color, COALESCE(NULLIF(product_variants.image, ''), products.image) AS image,
+			product_variants.stock`).
+		Joins("JOIN product_variants ON product_variants.id = stock_subscriptions.variant_id").
+		Joins("JOIN products ON products.id = product_variants.product_id").
+		Joins("JOIN users ON users.id = stock_subscriptions.user_id").
+		Where("stock_subscriptions.notified_at IS NULL AND product_variants.stock > 0").
+		Where("product_variants.deleted_at IS NULL AND products.deleted_at IS NULL")
+	if len(variantIDs) > 0 {
+		q = q.Where("stock_subscriptions.variant_id IN ?", variantIDs)
+	}
+	var candidates []RestockNotice
+	if err := q.Scan(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	claimed := make([]RestockNotice, 0, len(candidates))
+	now := time.Now()
+	for _, n := range candidates {
+		res := configs.DB.Model(&models.StockSubscription{}).
+			Where("id = ? AND notified_at IS NULL", n.SubscriptionID).
+			Update("notified_at", now)
+		if res.Error != nil {
+			return claimed, res.Error
+		}
+		if res.RowsAffected == 1 {
+			claimed = append(claimed, n)
+		}
+	}
+	return claimed, nil
+}
+
+// OrderVariantIDs: variant trong đơn (dùng để báo có hàng sau khi huỷ đơn trả stock)
+func OrderVariantIDs(orderID uint) ([]uint, error) {
+	var ids []uint
+	err := configs.DB.Model(&models.OrderItem{}).Where("order_id = ?", orderID).Distinct().Pluck("variant_id", &ids).Error
+	return ids, err
+}
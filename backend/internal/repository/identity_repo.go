@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrIdentityEmailUnverified = errors.New("email from provider is not verified")
+	ErrIdentityEmailMissing    = errors.New("provider did not return an email")
+	ErrIdentityLinkRequired    = errors.New("staff accounts cannot be linked automatically")
+)
+
+var usernameStripRe = regexp.MustCompile(`[^a-zA-Z0-9_.]+`)
+
+// uniqueUsername lấy base làm username, bị trùng thì thêm hậu tố ngẫu nhiên
+func uniqueUsername(tx *gorm.DB, base string) (string, error) {
+	base = usernameStripRe.ReplaceAllString(base, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+	name := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", name).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return name, nil
+		}
+		suffix, err := randomHex(3)
+		if err != nil {
+			return "", err
+		}
+		name = base + "_" + suffix
+	}
+	return "", ErrUsernameTaken
+}
+
+// CheckOIDCLink: identity mới (chưa liên kết) có email này được gắn vào existing (user trùng email,
+// nil = chưa có → sẽ tạo mới) không
+func CheckOIDCLink(email string, emailVerified bool, existing *models.User) error {
+	if email == "" {
+		return ErrIdentityEmailMissing
+	}
+	// không xác minh được email thì không cho liên kết hay chiếm email
+	if !emailVerified {
+		return ErrIdentityEmailUnverified
+	}
+	if existing == nil {
+		return nil
+	}
+	if existing.DeletedAt.Valid {
+		// email thuộc tài khoản đã bị xoá mềm, không tự liên kết hay khôi phục
+		return ErrEmailTaken
+	}
+	if existing.Role != "customer" {
+		// tài khoản admin/staff: email trùng không đủ để chiếm quyền quản trị, không tự liên kết
+		return ErrIdentityLinkRequired
+	}
+	return nil
+}
+
+// LinkOrCreateOIDCUser tìm user cho identity ngoài:
+//   - đã liên kết (provider + subject) → user đó
+//   - email đã xác minh trùng customer có sẵn → liên kết vào user đó (admin/staff → ErrIdentityLinkRequired)
+//   - chưa có user → tạo tài khoản customer mới (passwordHash ngẫu nhiên, đăng nhập bằng mật khẩu phải qua quên mật khẩu)
+func LinkOrCreateOIDCUser(identity *models.UserIdentity, emailVerified bool, usernameHint, passwordHash string) (*models.User, bool, error) {
+	var user models.User
+	created := false
+	now := time.Now()
+	identity.Email = strings.ToLower(strings.TrimSpace(identity.Email))
+
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&existing).Error
+		if err == nil {
+			*identity = existing
+			return tx.Model(&existing).Update("last_login_at", now).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := CheckOIDCLink(identity.Email, emailVerified, nil); err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("email = ?", identity.Email).First(&user).Error
+		if err == nil {
+			if err := CheckOIDCLink(identity.Email, emailVerified, &user); err != nil {
+				return err
+			}
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			username, err := uniqueUsername(tx, usernameHint)
+			if err != nil {
+				return err
+			}
+			user = models.User{
+				Username:     username,
+				PasswordHash: passwordHash,
+				Role:         "customer",
+				Email:        identity.Email,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			created = true
+		} else if err != nil {
+			return err
+		}
+
+		identity.UserID = user.ID
+		identity.LastLoginAt = &now
+		return tx.Create(identity).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if user.ID == 0 {
+		if err := configs.DB.First(&user, identity.UserID).Error; err != nil {
+			return nil, false, err
+		}
+	}
+	return &user, created, nil
+}
+
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LockoutPolicy: cứ mỗi Threshold lần sai liên tiếp thì khoá, lần khoá sau gấp đôi lần trước (tối đa Max)
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+func (p LockoutPolicy) duration(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold || failures%p.Threshold != 0 {
+		return 0
+	}
+	d := p.Base
+	for i := 1; i < failures/p.Threshold && d < p.Max; i++ {
+		d *= 2
+	}
+	if d > p.Max {
+		d = p.Max
+	}
+	return d
+}
+
+// RecordLoginFailure tăng số lần sai và khoá tài khoản khi chạm ngưỡng.
+// Trả về thời điểm mở khoá nếu vừa bị khoá.
+func RecordLoginFailure(userID uint, policy LockoutPolicy) (*time.Time, error) {
+	var lockedUntil *time.Time
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var u models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "failed_logins").First(&u, userID).Error; err != nil {
+			return err
+		}
+		failures := u.FailedLogins + 1
+		updates := map[string]interface{}{"failed_logins": failures}
+		if d := policy.duration(failures); d > 0 {
+			until := time.Now().Add(d)
+			lockedUntil = &until
+			updates["locked_until"] = until
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error
+	})
+	return lockedUntil, err
+}
+
+// ResetLoginFailures xoá bộ đếm sau khi đăng nhập thành công
+func ResetLoginFailures(userID uint) error {
+	return configs.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
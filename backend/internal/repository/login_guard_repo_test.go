@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyDuration(t *testing.T) {
+	p := LockoutPolicy{Threshold: 5, Base: time.Minute, Max: 10 * time.Minute}
+	cases := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 0}, // chỉ khoá khi chạm bội số của Threshold
+		{10, 2 * time.Minute},
+		{15, 4 * time.Minute},
+		{20, 8 * time.Minute},
+		{25, 10 * time.Minute}, // 16 phút, chặn ở Max
+		{500, 10 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := p.duration(c.failures); got != c.want {
+			t.Errorf("duration(%d) = %v, want %v", c.failures, got, c.want)
+		}
+	}
+
+	if d := (LockoutPolicy{Threshold: 0, Base: time.Minute, Max: time.Hour}).duration(5); d != 0 {
+		t.Errorf("threshold 0 should disable lockout, got %v", d)
+	}
+}
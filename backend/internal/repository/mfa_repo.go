@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetUserMFA trả về nil nếu user chưa từng đăng ký MFA
+func GetUserMFA(userID uint) (*models.UserMFA, error) {
+	var m models.UserMFA
+	if err := configs.DB.First(&m, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SavePendingMFA lưu secret mới (chưa bật) — chỉ dùng khi MFA chưa được bật
+func SavePendingMFA(userID uint, encryptedSecret string) error {
+	m := models.UserMFA{UserID: userID, Secret: encryptedSecret}
+	return configs.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"secret": encryptedSecret, "enabled_at": nil, "last_used_step": 0, "updated_at": time.Now()}),
+	}).Create(&m).Error
+}
+
+// EnableMFA bật MFA và thay toàn bộ mã dự phòng
+func EnableMFA(userID uint, step int64, recoveryHashes []string) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserMFA{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"enabled_at":     time.Now(),
+			"last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, recoveryHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, hashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.MFARecoveryCode, 0, len(hashes))
+	for _, h := range hashes {
+		rows = append(rows, models.MFARecoveryCode{UserID: userID, CodeHash: h})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+func ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, hashes)
+	})
+}
+
+// ClaimTOTPStep đánh dấu bước TOTP đã dùng; false nếu mã của bước này (hoặc mới hơn) đã được dùng
+func ClaimTOTPStep(userID uint, step int64) (bool, error) {
+	res := configs.DB.Model(&models.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return res.RowsAffected > 0, res.Error
+}
+
+// UseRecoveryCode đánh dấu mã dự phòng đã dùng; false nếu không hợp lệ
+func UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	res := configs.DB.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
+
+// CountRecoveryCodes: số mã dự phòng còn dùng được
+func CountRecoveryCodes(userID uint) (int64, error) {
+	var n int64
+	err := configs.DB.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).Count(&n).Error
+	return n, err
+}
+
+// DisableMFA xoá secret và mã dự phòng
+func DisableMFA(userID uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+	})
+}
+
+// SeedMFAPolicy: mặc định bắt buộc MFA cho admin và staff
+func SeedMFAPolicy() error {
+	rows := []models.RoleMFAPolicy{
+		{Role: "admin", Required: true},
+		{Role: "staff", Required: true},
+		{Role: "customer", Required: false},
+	}
+	return configs.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func GetMFAPolicies() ([]models.RoleMFAPolicy, error) {
+	var rows []models.RoleMFAPolicy
+	err := configs.DB.Order("role").Find(&rows).Error
+	return rows, err
+}
+
+func IsMFARequired(role string) (bool, error) {
+	var p models.RoleMFAPolicy
+	if err := configs.DB.First(&p, "role = ?", role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return p.Required, nil
+}
+
+func SetMFARequired(role string, required bool) error {
+	return configs.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "role"}},
+		DoUpdates: clause.AssignmentColumns([]string{"required"}),
+	}).Create(&models.RoleMFAPolicy{Role: role, Required: required}).Error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+)
+
+// OrderAddress trả về địa chỉ giao hàng của đơn: đơn khách vãng lai lấy từ các cột guest_*,
+// đơn của user lấy địa chỉ mặc định (defaultOnly) hoặc mới nhất trong customer_addresses
+func OrderAddress(order *models.Order, defaultOnly bool) *models.CustomerAddress {
+	if order.CustomerID == nil {
+		if order.GuestEmail == "" {
+			return nil
+		}
+		return &models.CustomerAddress{
+			Name:    order.GuestName,
+			Email:   order.GuestEmail,
+			Phone:   order.GuestPhone,
+			Address: order.GuestAddress,
+		}
+	}
+
+	var addr models.CustomerAddress
+	q := configs.DB.Where("user_id = ?", *order.CustomerID)
+	if defaultOnly {
+		q = q.Where("is_default = ?", true)
+	}
+	if err := q.Order("created_at DESC").First(&addr).Error; err != nil {
+		return nil
+	}
+	return &addr
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrResetTokenInvalid = errors.New("invalid or expired reset token")
+
+// CreatePasswordResetToken sinh token mới, các token chưa dùng trước đó của user bị vô hiệu
+func CreatePasswordResetToken(userID uint, ttl time.Duration) (string, error) {
+	raw, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    userID,
+			TokenHash: hashToken(raw),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	return raw, err
+}
+
+// setPassword đổi mật khẩu và thu hồi mọi phiên đang đăng nhập của user
+func setPassword(tx *gorm.DB, userID uint, passwordHash string) error {
+	now := time.Now()
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password_hash": passwordHash,
+		"token_version": gorm.Expr("token_version + 1"),
+		"updated_at":    now,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// ResetPasswordWithToken dùng token (một lần) để đặt mật khẩu mới
+func ResetPasswordWithToken(raw, passwordHash string) (uint, error) {
+	var userID uint
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var t models.PasswordResetToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashToken(raw), time.Now()).
+			First(&t).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrResetTokenInvalid
+			}
+			return err
+		}
+		if err := tx.Model(&t).Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		userID = t.UserID
+		return setPassword(tx, t.UserID, passwordHash)
+	})
+	return userID, err
+}
+
+// ChangePassword đổi mật khẩu cho user đã đăng nhập (đã kiểm tra mật khẩu cũ ở controller)
+func ChangePassword(userID uint, passwordHash string) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, userID, passwordHash)
+	})
+}
+
+// CleanupPasswordResetTokens xoá token đã hết hạn quá 1 ngày
+func CleanupPasswordResetTokens() (int64, error) {
+	res := configs.DB.Where("expires_at < ?", time.Now().Add(-24*time.Hour)).Delete(&models.PasswordResetToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUnknownPermission = errors.New("unknown permission")
+
+// DefaultStaffPermissions: quyền mặc định của staff, ghi 1 lần khi tạo bảng role_permissions
+var DefaultStaffPermissions = []string{
+	models.PermPurchasesManage,
+	models.PermCatalogRead,
+	models.PermCatalogWrite,
+	models.PermInventoryRead,
+	models.PermInventoryAdjust,
+	models.PermOrdersRead,
+	models.PermOrdersWrite,
+	models.PermReviewsModerate,
+	models.PermMessagesManage,
+}
+
+// RolePermissionsTableExists: gọi trước AutoMigrate để biết có phải lần chạy đầu tiên không
+func RolePermissionsTableExists() bool {
+	return configs.DB.Migrator().HasTable(&models.RolePermission{})
+}
+
+// SeedRolePermissions ghi quyền mặc định cho staff. Chỉ gọi khi bảng role_permissions vừa được tạo:
+// bảng trống sau đó có thể là do admin đã thu hồi hết quyền, không được cấp lại khi khởi động lại.
+func SeedRolePermissions() error {
+	rows := make([]models.RolePermission, 0, len(DefaultStaffPermissions))
+	for _, p := range DefaultStaffPermissions {
+		rows = append(rows, models.RolePermission{Role: "staff", Permission: p})
+	}
+	return configs.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// GetRolePermissions trả về map role -> danh sách quyền
+func GetRolePermissions() (map[string][]string, error) {
+	var rows []models.RolePermission
+	if err := configs.DB.Order("role, permission").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := map[string][]string{}
+	for _, r := range rows {
+		out[r.Role] = append(out[r.Role], r.Permission)
+	}
+	return out, nil
+}
+
+// SetRolePermissions thay toàn bộ quyền của một role
+func SetRolePermissions(role string, permissions []string) error {
+	valid := map[string]bool{}
+	for _, p := range models.AllPermissions {
+		valid[p] = true
+	}
+	rows := make([]models.RolePermission, 0, len(permissions))
+	seen := map[string]bool{}
+	for _, p := range permissions {
+		if !valid[p] {
+			return ErrUnknownPermission
+		}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		rows = append(rows, models.RolePermission{Role: role, Permission: p})
+	}
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEmailChangeInvalid = errors.New("invalid email change link")
+	ErrEmailChangeExpired = errors.New("email change link has expired")
+	ErrOpenOrders         = errors.New("account still has orders in progress, please wait until they are completed or cancelled")
+)
+
+// UpdateProfile cập nhật các trường khách tự sửa được
+func UpdateProfile(userID uint, username, phone, address string) (*models.User, error) {
+	var user models.User
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ? AND id <> ?", username, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUsernameTaken
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"username": username,
+			"phone":    phone,
+			"address":  address,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.First(&user, userID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateEmailChangeRequest sinh token xác nhận cho email mới, yêu cầu cũ chưa xác nhận bị huỷ
+func CreateEmailChangeRequest(userID uint, newEmail string, ttl time.Duration) (string, error) {
+	raw, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("email = ?", newEmail).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrEmailTaken
+		}
+		if err := tx.Where("user_id = ? AND confirmed_at IS NULL", userID).Delete(&models.EmailChangeRequest{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailChangeRequest{
+			UserID:    userID,
+			NewEmail:  newEmail,
+			TokenHash: hashToken(raw),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	return raw, err
+}
+
+// GetPendingEmailChange: yêu cầu đổi email đang chờ xác nhận, nil nếu không có
+func GetPendingEmailChange(userID uint) (*models.EmailChangeRequest, error) {
+	var req models.EmailChangeRequest
+	err := configs.DB.Where("user_id = ? AND confirmed_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("id DESC").First(&req).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// ConfirmEmailChange áp dụng email mới (kiểm tra lại trùng email tại thời điểm xác nhận)
+func ConfirmEmailChange(raw string) (*models.User, error) {
+	if raw == "" {
+		return nil, ErrEmailChangeInvalid
+	}
+	var user models.User
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var req models.EmailChangeRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashToken(raw)).First(&req).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmailChangeInvalid
+			}
+			return err
+		}
+		if req.ConfirmedAt != nil {
+			return ErrEmailChangeInvalid
+		}
+		if !req.ExpiresAt.After(time.Now()) {
+			return ErrEmailChangeExpired
+		}
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("email = ? AND id <> ?", req.NewEmail, req.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrEmailTaken
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", req.UserID).Update("email", req.NewEmail).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&req).Update("confirmed_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.First(&user, req.UserID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func CleanupEmailChangeRequests() (int64, error) {
+	res := configs.DB.Where("expires_at < ?", time.Now().Add(-24*time.Hour)).Delete(&models.EmailChangeRequest{})
+	return res.RowsAffected, res.Error
+}
+
+// DeleteAccount xoá dữ liệu cá nhân của user. Đơn hàng, đánh giá giữ lại cho kế toán/thống kê nhưng
+// trỏ tới bản ghi user đã ẩn danh (không còn email, sđt, địa chỉ, không đăng nhập được).
+// Từ chối khi còn đơn đang xử lý (cần địa chỉ giao hàng); stock giỏ hàng đang giữ được trả lại,
+// trả về các variant vừa được trả stock.
+func DeleteAccount(userID uint, randomPasswordHash string) ([]uint, error) {
+	var released []uint
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		var open int64
+		if err := tx.Model(&models.Order{}).
+			Where("customer_id = ? AND status IN ?", userID, []string{"pending", "confirmed", "shipped"}).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrOpenOrders
+		}
+
+		// giỏ hàng đã trừ stock khi thêm vào, xoá giỏ phải trả lại
+		var cart []models.CartItem
+		if err := tx.Where("user_id = ?", userID).Find(&cart).Error; err != nil {
+			return err
+		}
+		for _, item := range cart {
+			if err := tx.Unscoped().Model(&models.ProductVariant{}).Where("id = ?", item.VariantID).
+				UpdateColumn("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+				return err
+			}
+			released = append(released, uint(item.VariantID))
+		}
+
+		if err := tx.Model(&models.Order{}).Where("customer_id = ?", userID).Updates(map[string]interface{}{
+			"guest_name":    "",
+			"guest_email":   "",
+			"guest_phone":   "",
+			"guest_address": "",
+		}).Error; err != nil {
+			return err
+		}
+
+		byUser := []interface{}{
+			&models.CustomerAddress{}, &models.CartItem{}, &models.WishlistItem{}, &models.StockSubscription{},
+			&models.ReviewVote{}, &models.RefreshToken{}, &models.PasswordResetToken{}, &models.EmailChangeRequest{},
+			&models.MFARecoveryCode{}, &models.UserMFA{}, &models.UserIdentity{}, &models.LoginLog{},
+		}
+		for _, m := range byUser {
+			if err := tx.Where("user_id = ?", userID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("customer_id = ?", userID).Delete(&models.Message{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("email = ?", user.Email).Delete(&models.LoginLog{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("email = ?", user.Email).Delete(&models.PendingRegistration{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"username":      fmt.Sprintf("deleted_user_%d", userID),
+			"email":         fmt.Sprintf("deleted_%d@deleted.invalid", userID),
+			"phone":         "",
+			"address":       "",
+			"password_hash": randomPasswordHash,
+			"token_version": gorm.Expr("token_version + 1"),
+			"failed_logins": 0,
+			"locked_until":  nil,
+			"deleted_at":    time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+// UserDataExport: toàn bộ dữ liệu cá nhân của user (quyền truy cập/di chuyển dữ liệu)
+type UserDataExport struct {
+	ExportedAt         time.Time                  `json:"exported_at"`
+	Profile            models.User                `json:"profile"`
+	Addresses          []models.CustomerAddress   `json:"addresses"`
+	Orders             []models.Order             `json:"orders"`
+	Cancellations      []models.OrderCancellation `json:"order_cancellations"`
+	Reviews            []models.Review            `json:"reviews"`
+	Wishlist           []models.WishlistItem      `json:"wishlist"`
+	Cart               []models.CartItem          `json:"cart"`
+	StockSubscriptions []models.StockSubscription `json:"stock_subscriptions"`
+	Messages           []models.Message           `json:"messages"`
+	LinkedAccounts     []models.UserIdentity      `json:"linked_accounts"`
+	LoginHistory       []models.LoginLog          `json:"login_history"`
+}
+
+func ExportUserData(userID uint) (*UserDataExport, error) {
+	db := configs.DB
+	out := &UserDataExport{ExportedAt: time.Now()}
+	if err := db.First(&out.Profile, userID).Error; err != nil {
+		return nil, err
+	}
+	queries := []struct {
+		q    *gorm.DB
+		dest interface{}
+	}{
+		{db.Where("user_id = ?", userID), &out.Addresses},
+		{db.Preload("Items").Where("customer_id = ?", userID).Order("id"), &out.Orders},
+		{db.Where("customer_id = ?", userID).Order("id"), &out.Cancellations},
+		{db.Preload("Images").Preload("Replies").Where("user_id = ?", userID).Order("id"), &out.Reviews},
+		{db.Where("user_id = ?", userID), &out.Wishlist},
+		{db.Where("user_id = ?", userID), &out.Cart},
+		{db.Where("user_id = ?", userID), &out.StockSubscriptions},
+		{db.Where("customer_id = ?", userID).Order("id"), &out.Messages},
+		{db.Where("user_id = ?", userID), &out.LinkedAccounts},
+		{db.Where("user_id = ?", userID).Order("id DESC").Limit(500), &out.LoginHistory},
+	}
+	for _, item := range queries {
+		if err := item.q.Find(item.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+)
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func createRefreshToken(tx *gorm.DB, userID uint, familyID string, ttl time.Duration, ip, userAgent string) (*models.RefreshToken, string, error) {
+	raw, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	rt := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+		IP:        ip,
+		UserAgent: userAgent,
+	}
+	if err := tx.Create(&rt).Error; err != nil {
+		return nil, "", err
+	}
+	return &rt, raw, nil
+}
+
+// IssueRefreshToken tạo refresh token đầu tiên của một phiên đăng nhập (family mới)
+func IssueRefreshToken(userID uint, ttl time.Duration, ip, userAgent string) (string, error) {
+	familyID, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	_, raw, err := createRefreshToken(configs.DB, userID, familyID, ttl, ip, userAgent)
+	return raw, err
+}
+
+// RotateRefreshToken đổi refresh token cũ lấy token mới cùng family.
+// Token đã bị thu hồi mà vẫn được gửi lên => có thể đã lộ, thu hồi toàn bộ family.
+func RotateRefreshToken(raw string, ttl time.Duration, ip, userAgent string) (*models.User, string, error) {
+	if raw == "" {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+	var (
+		user   models.User
+		newRaw string
+		reused bool
+	)
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var old models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashToken(raw)).First(&old).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+		now := time.Now()
+		if old.RevokedAt != nil {
+			reused = true
+			return revokeFamily(tx, old.FamilyID, now)
+		}
+		if !old.ExpiresAt.After(now) {
+			return ErrRefreshTokenInvalid
+		}
+		if err := tx.First(&user, old.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+		next, r, err := createRefreshToken(tx, old.UserID, old.FamilyID, ttl, ip, userAgent)
+		if err != nil {
+			return err
+		}
+		newRaw = r
+		return tx.Model(&old).Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"replaced_by_id": next.ID,
+		}).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if reused {
+		return nil, "", ErrRefreshTokenReused
+	}
+	return &user, newRaw, nil
+}
+
+func revokeFamily(tx *gorm.DB, familyID string, at time.Time) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+// RevokeRefreshToken thu hồi phiên (cả family) chứa token này, dùng khi logout.
+// Trả về user_id của phiên, 0 nếu token không tồn tại hoặc đã bị thu hồi/hết hạn
+// (token cũ lộ ra không được dùng để đăng xuất mọi thiết bị của user).
+func RevokeRefreshToken(raw string) (uint, error) {
+	var rt models.RefreshToken
+	if err := configs.DB.Where("token_hash = ?", hashToken(raw)).First(&rt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	now := time.Now()
+	if err := revokeFamily(configs.DB, rt.FamilyID, now); err != nil {
+		return 0, err
+	}
+	if rt.RevokedAt != nil || !rt.ExpiresAt.After(now) {
+		return 0, nil
+	}
+	return rt.UserID, nil
+}
+
+// RevokeUserSessions thu hồi mọi refresh token và làm mất hiệu lực mọi access token của user
+func RevokeUserSessions(userID uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("token_version", gorm.Expr("token_version + 1")).Error
+	})
+}
+
+// GetUserTokenState trả về role và token_version hiện tại của user (JWTMiddleware đối chiếu với claims)
+func GetUserTokenState(userID uint) (string, uint, error) {
+	var u models.User
+	if err := configs.DB.Select("id", "role", "token_version").First(&u, userID).Error; err != nil {
+		return "", 0, err
+	}
+	return u.Role, u.TokenVersion, nil
+}
+
+// CleanupRefreshTokens xoá token đã hết hạn quá 1 ngày
+func CleanupRefreshTokens() (int64, error) {
+	res := configs.DB.Where("expires_at < ?", time.Now().Add(-24*time.Hour)).Delete(&models.RefreshToken{})
+	return res.RowsAffected, res.Error
+}
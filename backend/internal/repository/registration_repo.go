@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEmailTaken            = errors.New("email is already registered")
+	ErrUsernameTaken         = errors.New("username is already taken")
+	ErrRegistrationInvalid   = errors.New("invalid confirmation link")
+	ErrRegistrationExpired   = errors.New("confirmation link has expired")
+	ErrRegistrationConfirmed = errors.New("account is already confirmed")
+)
+
+// checkRegistrationConflict: email/username đã có user (kể cả user đã xoá mềm), hoặc username đang được người khác giữ chờ xác nhận
+func checkRegistrationConflict(tx *gorm.DB, username, email string) error {
+	var count int64
+	if err := tx.Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEmailTaken
+	}
+	if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrUsernameTaken
+	}
+	if err := tx.Model(&models.PendingRegistration{}).
+		Where("username = ? AND email <> ? AND confirmed_at IS NULL AND expires_at > ?", username, email, time.Now()).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrUsernameTaken
+	}
+	return nil
+}
+
+// CreatePendingRegistration lưu đăng ký chờ xác nhận, trả về token gửi qua email.
+// Đăng ký lại cùng email (chưa xác nhận) sẽ ghi đè thông tin và token cũ.
+func CreatePendingRegistration(p *models.PendingRegistration, ttl time.Duration) (string, error) {
+	raw, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	p.TokenHash = hashToken(raw)
+	p.ExpiresAt = time.Now().Add(ttl)
+	p.ConfirmedAt = nil
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkRegistrationConflict(tx, p.Username, p.Email); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "email"}},
+			DoUpdates: clause.AssignmentColumns([]string{"username", "phone", "address", "password_hash", "token_hash", "expires_at", "confirmed_at", "updated_at"}),
+		}).Create(p).Error
+	})
+	return raw, err
+}
+
+// RenewPendingRegistration cấp token mới cho đăng ký chưa xác nhận (gửi lại email).
+// Trả về nil nếu không có đăng ký nào đang chờ với email này.
+func RenewPendingRegistration(email string, ttl time.Duration) (*models.PendingRegistration, string, error) {
+	var p models.PendingRegistration
+	if err := configs.DB.Where("email = ? AND confirmed_at IS NULL", email).First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	raw, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	p.TokenHash = hashToken(raw)
+	p.ExpiresAt = time.Now().Add(ttl)
+	if err := configs.DB.Model(&p).Updates(map[string]interface{}{
+		"token_hash": p.TokenHash,
+		"expires_at": p.ExpiresAt,
+	}).Error; err != nil {
+		return nil, "", err
+	}
+	return &p, raw, nil
+}
+
+// ConfirmPendingRegistration tạo user từ đăng ký đang chờ
+func ConfirmPendingRegistration(raw string) (*models.User, error) {
+	if raw == "" {
+		return nil, ErrRegistrationInvalid
+	}
+	var user models.User
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var p models.PendingRegistration
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashToken(raw)).First(&p).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRegistrationInvalid
+			}
+			return err
+		}
+		if p.ConfirmedAt != nil {
+			return ErrRegistrationConfirmed
+		}
+		if !p.ExpiresAt.After(time.Now()) {
+			return ErrRegistrationExpired
+		}
+		if err := checkRegistrationConflict(tx, p.Username, p.Email); err != nil {
+			return err
+		}
+		user = models.User{
+			Username:     p.Username,
+			PasswordHash: p.PasswordHash,
+			Email:        p.Email,
+			Phone:        p.Phone,
+			Address:      p.Address,
+			Role:         "customer",
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Model(&p).Update("confirmed_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CleanupPendingRegistrations xoá đăng ký hết hạn chưa xác nhận, và bản ghi đã xác nhận quá 7 ngày
+func CleanupPendingRegistrations() (int64, error) {
+	now := time.Now()
+	res := configs.DB.
+		Where("(confirmed_at IS NULL AND expires_at < ?) OR confirmed_at < ?", now, now.Add(-7*24*time.Hour)).
+		Delete(&models.PendingRegistration{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"fmt"
+
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+var slugTables = map[string]string{
+	models.SlugEntityProduct:  "products",
+	models.SlugEntityCategory: "categories",
+	models.SlugEntitySupplier: "suppliers",
+}
+
+// UniqueSlug sinh slug từ name, thêm hậu tố -2, -3... nếu đã bị entity khác dùng
+// (kể cả slug cũ trong lịch sử). excludeID = id của chính entity khi cập nhật.
+func UniqueSlug(tx *gorm.DB, entityType, name string, excludeID uint) (string, error) {
+	table, ok := slugTables[entityType]
+	if !ok {
+		return "", fmt.Errorf("unknown slug entity %q", entityType)
+	}
+	base := slug.Make(name)
+	if base == "" {
+		base = entityType
+	}
+	candidate := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Table(table).Where("slug = ? AND id <> ?", candidate, excludeID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			if err := tx.Model(&models.SlugHistory{}).
+				Where("entity_type = ? AND slug = ? AND entity_id <> ?", entityType, candidate, excludeID).
+				Count(&count).Error; err != nil {
+				return "", err
+			}
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// RecordSlugChange lưu slug cũ vào lịch sử khi entity đổi sang newSlug.
+// Nếu newSlug từng là slug cũ của chính entity thì bỏ khỏi lịch sử.
+func RecordSlugChange(tx *gorm.DB, entityType string, entityID uint, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+	if err := tx.Where("entity_type = ? AND entity_id = ? AND slug = ?", entityType, entityID, newSlug).
+		Delete(&models.SlugHistory{}).Error; err != nil {
+		return err
+	}
+	if oldSlug == "" {
+		return nil
+	}
+	return tx.Create(&models.SlugHistory{EntityType: entityType, EntityID: entityID, Slug: oldSlug}).Error
+}
+
+// ResolveOldSlug tìm slug hiện tại của entity từng dùng oldSlug.
+// Trả về gorm.ErrRecordNotFound nếu slug không có trong lịch sử.
+func ResolveOldSlug(entityType, oldSlug string) (string, error) {
+	table, ok := slugTables[entityType]
+	if !ok {
+		return "", fmt.Errorf("unknown slug entity %q", entityType)
+	}
+	var h models.SlugHistory
+	if err := configs.DB.Where("entity_type = ? AND slug = ?", entityType, oldSlug).First(&h).Error; err != nil {
+		return "", err
+	}
+	var current struct{ Slug string }
+	if err := configs.DB.Table(table).Select("slug").Where("id = ?", h.EntityID).Take(&current).Error; err != nil {
+		return "", err
+	}
+	if current.Slug == "" {
+		return "", gorm.ErrRecordNotFound
+	}
+	return current.Slug, nil
+}
+
+// DeleteSlugHistory xoá lịch sử slug khi entity bị xoá
+func DeleteSlugHistory(tx *gorm.DB, entityType string, entityID uint) error {
+	return tx.Where("entity_type = ? AND entity_id = ?", entityType, entityID).Delete(&models.SlugHistory{}).Error
+}
@@ -14,6 +14,12 @@ func GetUserByEmail(email string) (*models.User, error) {
 	err := configs.DB.Where("email = ?", email).First(&user).Error
 	return &user, err
 }
+func GetUserByID(id uint) (*models.User, error) {
+	var user models.User
+	err := configs.DB.First(&user, id).Error
+	return &user, err
+}
+
 func CreateLoginLog(log *models.LoginLog) error {
 	return configs.DB.Create(log).Error
 }
@@ -1,70 +1,183 @@
 package routes
 
 import (
+	"net/http"
+
 	adminCtrl "backend/internal/controllers/admin"
 	"backend/internal/middlewares"
+	"backend/internal/models"
+
 	"github.com/gorilla/mux"
-	
 )
 
+// adminRoute: một dòng trong bảng route admin, Permission rỗng = mọi admin/staff đều gọi được
+type adminRoute struct {
+	Method     string
+	Path       string
+	Permission string
+	Handler    http.HandlerFunc
+}
+
+// AdminRouteTable: mỗi route admin khai báo quyền cần có ngay cạnh handler
+func AdminRouteTable() []adminRoute {
+	return []adminRoute{
+		// Users
+		{"GET", "/users", models.PermUsersManage, adminCtrl.GetAllUsers},
+		{"PUT", "/users/{id:[0-9]+}", models.PermUsersManage, adminCtrl.EditUser},
+		{"DELETE", "/users/{id:[0-9]+}", models.PermUsersManage, adminCtrl.DeleteUser},
+		{"DELETE", "/users/{id:[0-9]+}/mfa", models.PermUsersManage, adminCtrl.ResetUserMFA},
+		{"GET", "/users/archived", models.PermUsersManage, adminCtrl.GetArchivedUsers},
+		{"POST", "/users/{id:[0-9]+}/restore", models.PermUsersManage, adminCtrl.RestoreUser},
+		// staff chỉ xem log của chính mình (xử lý trong controller)
+		{"GET", "/logs", "", adminCtrl.GetUserLogsHandler},
+		{"GET", "/logs/lockouts", models.PermUsersManage, adminCtrl.GetLockedAccounts},
+		{"POST", "/logs/lockouts/{id:[0-9]+}/unlock", models.PermUsersManage, adminCtrl.UnlockUser},
+
+		// Audit log: mọi thao tác ghi trên /api/admin (ghi tự động trong handleAdminRoute)
+		{"GET", "/audit-logs", models.PermAuditRead, adminCtrl.GetAuditLogs},
+		{"GET", "/audit-logs/{id:[0-9]+}", models.PermAuditRead, adminCtrl.GetAuditLogDetail},
+
+		// Permissions
+		{"GET", "/permissions", models.PermPermissionsManage, adminCtrl.GetPermissions},
+		{"PUT", "/permissions/{role}", models.PermPermissionsManage, adminCtrl.UpdateRolePermissions},
+		{"GET", "/security/mfa-policy", models.PermPermissionsManage, adminCtrl.GetMFAPolicy},
+		{"PUT", "/security/mfa-policy/{role}", models.PermPermissionsManage, adminCtrl.UpdateMFAPolicy},
+
+		// Suppliers (xem danh sách cần cho nhập hàng nên dùng quyền purchases)
+		{"GET", "/suppliers", models.PermPurchasesManage, adminCtrl.GetAllSuppliers},
+		{"POST", "/suppliers", models.PermSuppliersManage, adminCtrl.CreateSupplier},
+		{"GET", "/suppliers/{id:[0-9]+}", models.PermPurchasesManage, adminCtrl.GetSupplierDetail},
+		{"GET", "/suppliers/slug/{slug}", models.PermPurchasesManage, adminCtrl.GetSupplierBySlug},
+		{"PUT", "/suppliers/{id:[0-9]+}", models.PermSuppliersManage, adminCtrl.EditSupplier},
+		{"DELETE", "/suppliers/{id:[0-9]+}", models.PermSuppliersManage, adminCtrl.DeleteSupplier},
+		{"GET", "/suppliers/archived", models.PermSuppliersManage, adminCtrl.GetArchivedSuppliers},
+		{"POST", "/suppliers/{id:[0-9]+}/restore", models.PermSuppliersManage, adminCtrl.RestoreSupplier},
+
+		// Supplier-scoped purchases
+		{"GET", "/suppliers/{id:[0-9]+}/purchases", models.PermPurchasesManage, adminCtrl.GetPurchasesBySupplier},
+		{"POST", "/suppliers/{id:[0-9]+}/purchases", models.PermPurchasesManage, adminCtrl.CreatePurchaseForSupplier},
+		{"PUT", "/suppliers/{id:[0-9]+}/purchases/{purchaseId:[0-9]+}", models.PermPurchasesManage, adminCtrl.EditPurchaseForSupplier},
+		{"DELETE", "/suppliers/{id:[0-9]+}/purchases/{purchaseId:[0-9]+}", models.PermPurchasesManage, adminCtrl.DeletePurchaseForSupplier},
+
+		// Global purchases
+		{"GET", "/purchases", models.PermPurchasesManage, adminCtrl.GetAllPurchasesGlobal},
+		{"POST", "/purchases", models.PermPurchasesManage, adminCtrl.CreatePurchaseGlobal},
+		{"PUT", "/purchases/{id:[0-9]+}", models.PermPurchasesManage, adminCtrl.EditPurchaseGlobal},
+		{"DELETE", "/purchases/{id:[0-9]+}", models.PermPurchasesManage, adminCtrl.DeletePurchaseGlobal},
+
+		// Categories & Products
+		{"GET", "/categories", models.PermCatalogRead, adminCtrl.GetAllCategories},
+		{"POST", "/categories", models.PermCatalogWrite, adminCtrl.CreateCategory},
+		{"GET", "/categories/tree", models.PermCatalogRead, adminCtrl.GetCategoryTree},
+		{"PUT", "/categories/reorder", models.PermCatalogWrite, adminCtrl.ReorderCategories},
+		{"PUT", "/categories/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.EditCategory},
+		{"DELETE", "/categories/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.DeleteCategory},
+		{"GET", "/categories/archived", models.PermCatalogWrite, adminCtrl.GetArchivedCategories},
+		{"POST", "/categories/{id:[0-9]+}/restore", models.PermCatalogWrite, adminCtrl.RestoreCategory},
+		{"GET", "/categories/{id:[0-9]+}", models.PermCatalogRead, adminCtrl.GetCategoryDetail},
+		{"GET", "/categories/{id:[0-9]+}/attributes", models.PermCatalogRead, adminCtrl.GetCategoryAttributes},
+		{"PUT", "/categories/{id:[0-9]+}/attributes", models.PermCatalogWrite, adminCtrl.SetCategoryAttributes},
+
+		{"GET", "/products", models.PermCatalogRead, adminCtrl.GetAllProducts},
+		{"GET", "/products/{id:[0-9]+}", models.PermCatalogRead, adminCtrl.GetProductDetail},
+		{"POST", "/products", models.PermCatalogWrite, adminCtrl.CreateProduct},
+		{"PUT", "/products/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.EditProduct},
+		{"DELETE", "/products/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.DeleteProduct},
+		{"GET", "/products/archived", models.PermCatalogWrite, adminCtrl.GetArchivedProducts},
+		{"POST", "/products/{id:[0-9]+}/restore", models.PermCatalogWrite, adminCtrl.RestoreProduct},
+
+		// Product images & uploads
+		{"POST", "/uploads", models.PermCatalogWrite, adminCtrl.UploadImage},
+		{"GET", "/products/{id:[0-9]+}/images", models.PermCatalogRead, adminCtrl.GetProductImages},
+		{"POST", "/products/{id:[0-9]+}/images", models.PermCatalogWrite, adminCtrl.UploadProductImages},
+		{"PUT", "/products/{id:[0-9]+}/images/order", models.PermCatalogWrite, adminCtrl.ReorderProductImages},
+		{"DELETE", "/products/{id:[0-9]+}/images/{imageId:[0-9]+}", models.PermCatalogWrite, adminCtrl.DeleteProductImage},
+
+		// Attributes & size/color options
+		{"GET", "/attributes", models.PermCatalogRead, adminCtrl.GetAllAttributes},
+		{"POST", "/attributes", models.PermCatalogWrite, adminCtrl.CreateAttribute},
+		{"PUT", "/attributes/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.EditAttribute},
+		{"DELETE", "/attributes/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.DeleteAttribute},
+		{"POST", "/attributes/{id:[0-9]+}/values", models.PermCatalogWrite, adminCtrl.CreateAttributeValue},
+		{"PUT", "/attributes/{id:[0-9]+}/values/{valueId:[0-9]+}", models.PermCatalogWrite, adminCtrl.EditAttributeValue},
+		{"DELETE", "/attributes/{id:[0-9]+}/values/{valueId:[0-9]+}", models.PermCatalogWrite, adminCtrl.DeleteAttributeValue},
+		{"PUT", "/products/{id:[0-9]+}/attributes", models.PermCatalogWrite, adminCtrl.SetProductAttributes},
+
+		{"GET", "/sizes", models.PermCatalogRead, adminCtrl.GetSizeOptions},
+		{"POST", "/sizes", models.PermCatalogWrite, adminCtrl.CreateSizeOption},
+		{"PUT", "/sizes/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.EditSizeOption},
+		{"DELETE", "/sizes/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.DeleteSizeOption},
+		{"GET", "/colors", models.PermCatalogRead, adminCtrl.GetColorOptions},
+		{"POST", "/colors", models.PermCatalogWrite, adminCtrl.CreateColorOption},
+		{"PUT", "/colors/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.EditColorOption},
+		{"DELETE", "/colors/{id:[0-9]+}", models.PermCatalogWrite, adminCtrl.DeleteColorOption},
+
+		// Variants
+		{"GET", "/products/{id:[0-9]+}/variants", models.PermCatalogRead, adminCtrl.GetVariantsByProduct},
+		{"GET", "/variants", models.PermCatalogRead, adminCtrl.GetAllVariants},
+		{"POST", "/products/{id:[0-9]+}/variants", models.PermCatalogWrite, adminCtrl.CreateVariant},
+		{"PUT", "/products/{id:[0-9]+}/variants/{variantId:[0-9]+}", models.PermCatalogWrite, adminCtrl.EditVariant},
+		{"DELETE", "/products/{id:[0-9]+}/variants/{variantId:[0-9]+}", models.PermCatalogWrite, adminCtrl.DeleteVariant},
+		{"GET", "/products/{id:[0-9]+}/variants/archived", models.PermCatalogWrite, adminCtrl.GetArchivedVariants},
+		{"POST", "/products/{id:[0-9]+}/variants/{variantId:[0-9]+}/restore", models.PermCatalogWrite, adminCtrl.RestoreVariant},
+
+		// Inventory Logs
+		{"GET", "/inventory_logs", models.PermInventoryRead, adminCtrl.GetAllInventoryLogs},
+		{"GET", "/inventory_logs/{id:[0-9]+}", models.PermInventoryRead, adminCtrl.GetInventoryLogDetail},
+		{"POST", "/inventory_logs", models.PermInventoryAdjust, adminCtrl.CreateInventoryLog},
+		{"PUT", "/inventory_logs/{id:[0-9]+}", models.PermInventoryAdjust, adminCtrl.EditInventoryLog},
+		{"DELETE", "/inventory_logs/{id:[0-9]+}", models.PermInventoryAdjust, adminCtrl.DeleteInventoryLog},
+
+		// Orders
+		{"GET", "/orders", models.PermOrdersRead, adminCtrl.GetAllOrders},
+		{"GET", "/orders/{id:[0-9]+}", models.PermOrdersRead, adminCtrl.GetOrderDetail},
+		{"PATCH", "/orders/{id:[0-9]+}/status", models.PermOrdersWrite, adminCtrl.UpdateOrderStatus},
+
+		// Search
+		{"GET", "/search", models.PermCatalogRead, adminCtrl.SearchAll},
+		{"GET", "/search/analytics/top", models.PermSearchManage, adminCtrl.GetTopSearchQueries},
+		{"GET", "/search/analytics/zero-results", models.PermSearchManage, adminCtrl.GetZeroResultQueries},
+		{"GET", "/search/synonyms", models.PermSearchManage, adminCtrl.GetSearchSynonyms},
+		{"POST", "/search/synonyms", models.PermSearchManage, adminCtrl.CreateSearchSynonym},
+		{"PUT", "/search/synonyms/{id:[0-9]+}", models.PermSearchManage, adminCtrl.UpdateSearchSynonym},
+		{"DELETE", "/search/synonyms/{id:[0-9]+}", models.PermSearchManage, adminCtrl.DeleteSearchSynonym},
+
+		// Reviews
+		{"GET", "/reviews", models.PermReviewsModerate, adminCtrl.GetReviews},
+		{"PATCH", "/reviews/{id:[0-9]+}/status", models.PermReviewsModerate, adminCtrl.UpdateReviewStatus},
+		{"DELETE", "/reviews/{id:[0-9]+}", models.PermReviewsModerate, adminCtrl.DeleteReview},
+		{"POST", "/reviews/{id:[0-9]+}/replies", models.PermReviewsModerate, adminCtrl.CreateReviewReply},
+		{"DELETE", "/reviews/{id:[0-9]+}/replies/{replyId:[0-9]+}", models.PermReviewsModerate, adminCtrl.DeleteReviewReply},
+
+		// Export (csv / xlsx / ndjson)
+		{"GET", "/export/products", models.PermReportsExport, adminCtrl.ExportProducts},
+		{"GET", "/export/orders", models.PermReportsExport, adminCtrl.ExportOrders},
+		{"GET", "/export/purchases", models.PermReportsExport, adminCtrl.ExportPurchases},
+		{"GET", "/export/inventory_logs", models.PermReportsExport, adminCtrl.ExportInventoryLogs},
+		{"GET", "/export/customers", models.PermReportsExport, adminCtrl.ExportCustomers},
+	}
+}
+
+// handleAdminRoute đăng ký route, bọc thêm kiểm tra quyền nếu có;
+// route ghi (khác GET) được audit ở ngoài cùng để cả lần bị từ chối quyền cũng được ghi lại
+func handleAdminRoute(router *mux.Router, rt adminRoute) {
+	var h http.Handler = rt.Handler
+	if rt.Permission != "" {
+		h = middlewares.RequirePermission(rt.Permission)(h)
+	}
+	if rt.Method != http.MethodGet {
+		h = middlewares.Audit(rt.Method, rt.Path)(h)
+	}
+	router.Handle(rt.Path, h).Methods(rt.Method)
+}
+
 func SetupAdminRoutes(r *mux.Router) {
-	
 	adminRouter := r.PathPrefix("/api/admin").Subrouter()
-	adminRouter.Use(middlewares.JWTMiddleware) 
-	// Users
-	adminRouter.HandleFunc("/users", adminCtrl.GetAllUsers).Methods("GET")
-	adminRouter.HandleFunc("/users/{id:[0-9]+}", adminCtrl.EditUser).Methods("PUT")
-	adminRouter.HandleFunc("/users/{id:[0-9]+}", adminCtrl.DeleteUser).Methods("DELETE")
-	adminRouter.HandleFunc("/logs", adminCtrl.GetUserLogsHandler).Methods("GET")
-	
-	adminRouter.HandleFunc("/suppliers", adminCtrl.GetAllSuppliers).Methods("GET")
-	adminRouter.HandleFunc("/suppliers", adminCtrl.CreateSupplier).Methods("POST")
-	adminRouter.HandleFunc("/suppliers/{id:[0-9]+}", adminCtrl.GetSupplierDetail).Methods("GET")
-	adminRouter.HandleFunc("/suppliers/{id:[0-9]+}", adminCtrl.EditSupplier).Methods("PUT")
-	adminRouter.HandleFunc("/suppliers/{id:[0-9]+}", adminCtrl.DeleteSupplier).Methods("DELETE")
-
-	// Supplier-scoped purchases
-	adminRouter.HandleFunc("/suppliers/{id:[0-9]+}/purchases", adminCtrl.GetPurchasesBySupplier).Methods("GET")
-	adminRouter.HandleFunc("/suppliers/{id:[0-9]+}/purchases", adminCtrl.CreatePurchaseForSupplier).Methods("POST")
-	adminRouter.HandleFunc("/suppliers/{id:[0-9]+}/purchases/{purchaseId:[0-9]+}", adminCtrl.EditPurchaseForSupplier).Methods("PUT")
-	adminRouter.HandleFunc("/suppliers/{id:[0-9]+}/purchases/{purchaseId:[0-9]+}", adminCtrl.DeletePurchaseForSupplier).Methods("DELETE")
-
-
-	// Global purchases (optional)
-	adminRouter.HandleFunc("/purchases", adminCtrl.GetAllPurchasesGlobal).Methods("GET")
-	adminRouter.HandleFunc("/purchases", adminCtrl.CreatePurchaseGlobal).Methods("POST")
-	adminRouter.HandleFunc("/purchases/{id:[0-9]+}", adminCtrl.EditPurchaseGlobal).Methods("PUT")
-	adminRouter.HandleFunc("/purchases/{id:[0-9]+}", adminCtrl.DeletePurchaseGlobal).Methods("DELETE")
-    // Categories & Products
-	adminRouter.HandleFunc("/categories", adminCtrl.GetAllCategories).Methods("GET")
-	adminRouter.HandleFunc("/categories", adminCtrl.CreateCategory).Methods("POST")
-	adminRouter.HandleFunc("/categories/{id:[0-9]+}", adminCtrl.EditCategory).Methods("PUT")
-	adminRouter.HandleFunc("/categories/{id:[0-9]+}", adminCtrl.DeleteCategory).Methods("DELETE")
-	adminRouter.HandleFunc("/categories/{id:[0-9]+}", adminCtrl.GetCategoryDetail).Methods("GET")
-
-	adminRouter.HandleFunc("/products", adminCtrl.GetAllProducts).Methods("GET")
-	adminRouter.HandleFunc("/products/{id:[0-9]+}", adminCtrl.GetProductDetail).Methods("GET")
-	adminRouter.HandleFunc("/products", adminCtrl.CreateProduct).Methods("POST")
-	adminRouter.HandleFunc("/products/{id:[0-9]+}", adminCtrl.EditProduct).Methods("PUT")
-	adminRouter.HandleFunc("/products/{id:[0-9]+}", adminCtrl.DeleteProduct).Methods("DELETE")
-
-	// Variants
-	adminRouter.HandleFunc("/products/{id:[0-9]+}/variants", adminCtrl.GetVariantsByProduct).Methods("GET")
-	adminRouter.HandleFunc("/variants", adminCtrl.GetAllVariants).Methods("GET")
-	adminRouter.HandleFunc("/products/{id:[0-9]+}/variants", adminCtrl.CreateVariant).Methods("POST")
-	adminRouter.HandleFunc("/products/{id:[0-9]+}/variants/{variantId:[0-9]+}", adminCtrl.EditVariant).Methods("PUT")
-	adminRouter.HandleFunc("/products/{id:[0-9]+}/variants/{variantId:[0-9]+}", adminCtrl.DeleteVariant).Methods("DELETE")
-
-	// Inventory Logs
-	adminRouter.HandleFunc("/inventory_logs", adminCtrl.GetAllInventoryLogs).Methods("GET")
-	adminRouter.HandleFunc("/inventory_logs/{id:[0-9]+}", adminCtrl.GetInventoryLogDetail).Methods("GET")
-	adminRouter.HandleFunc("/inventory_logs", adminCtrl.CreateInventoryLog).Methods("POST")
-	adminRouter.HandleFunc("/inventory_logs/{id:[0-9]+}", adminCtrl.EditInventoryLog).Methods("PUT")
-	adminRouter.HandleFunc("/inventory_logs/{id:[0-9]+}", adminCtrl.DeleteInventoryLog).Methods("DELETE")
-    // Orders
-	adminRouter.HandleFunc("/orders", adminCtrl.GetAllOrders).Methods("GET")
-	adminRouter.HandleFunc("/orders/{id:[0-9]+}", adminCtrl.GetOrderDetail).Methods("GET")
-	adminRouter.HandleFunc("/orders/{id:[0-9]+}/status", adminCtrl.UpdateOrderStatus).Methods("PATCH")
-	// Search
-	adminRouter.HandleFunc("/search", adminCtrl.SearchAll).Methods("GET")
-}
\ No newline at end of file
+	adminRouter.Use(middlewares.JWTMiddleware)
+	// khách hàng không được vào bất kỳ route admin nào
+	adminRouter.Use(middlewares.RoleMiddleware("admin", "staff"))
+
+	for _, rt := range AdminRouteTable() {
+		handleAdminRoute(adminRouter, rt)
+	}
+}
@@ -0,0 +1,143 @@
+package routes
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"backend/configs"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gorilla/mux"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var pathVar = regexp.MustCompile(`\{([a-zA-Z]+)(:[^}]+)?\}`)
+
+// concretePath thay biến trong template bằng giá trị hợp lệ: {role} -> staff, còn lại -> 1
+func concretePath(template string) string {
+	return "/api/admin" + pathVar.ReplaceAllStringFunc(template, func(v string) string {
+		if pathVar.FindStringSubmatch(v)[1] == "role" {
+			return "staff"
+		}
+		return "1"
+	})
+}
+
+// dryRunDB: audit middleware đọc/ghi DB, test dùng DryRun để không cần MySQL
+func dryRunDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "test:test@tcp(127.0.0.1:1)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	orig := configs.DB
+	configs.DB = db
+	t.Cleanup(func() { configs.DB = orig })
+}
+
+func TestAdminRouteTableWritesDeclarePermission(t *testing.T) {
+	for _, rt := range AdminRouteTable() {
+		if rt.Method != http.MethodGet && rt.Permission == "" {
+			t.Errorf("%s %s: write route without permission", rt.Method, rt.Path)
+		}
+		if rt.Permission != "" && !contains(models.AllPermissions, rt.Permission) {
+			t.Errorf("%s %s: unknown permission %q", rt.Method, rt.Path, rt.Permission)
+		}
+	}
+}
+
+func TestAdminRoutesForbidOutsideGrants(t *testing.T) {
+	setupAuth(t)
+	dryRunDB(t)
+	service.SetPermissions(map[string][]string{"staff": repository.DefaultStaffPermissions})
+	t.Cleanup(func() { service.SetPermissions(nil) })
+
+	r := mux.NewRouter()
+	SetupAdminRoutes(r)
+	customer, staff := tokenFor(t, 1), tokenFor(t, 3)
+
+	checked := 0
+	for _, rt := range AdminRouteTable() {
+		path := concretePath(rt.Path)
+		expectStatus(t, serve(r, rt.Method, path, customer, "{}"), http.StatusForbidden, "customer "+rt.Method+" "+path)
+		if rt.Permission != "" && !contains(repository.DefaultStaffPermissions, rt.Permission) {
+			expectStatus(t, serve(r, rt.Method, path, staff, "{}"), http.StatusForbidden, "staff "+rt.Method+" "+path)
+			checked++
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no admin route outside the staff grants was checked")
+	}
+}
+
+// action/biến id mà audit suy ra từ template của route ghi
+func TestAdminRouteTableAuditActions(t *testing.T) {
+	want := map[string]struct {
+		action, idVar, parent, parentIDVar string
+	}{
+		"POST /users/{id:[0-9]+}/restore":                                {"user.restore", "id", "", ""},
+		"POST /logs/lockouts/{id:[0-9]+}/unlock":                         {"user.unlock", "id", "", ""},
+		"PUT /permissions/{role}":                                        {"role_permissions.update", "role", "", ""},
+		"POST /suppliers":                                                {"supplier.create", "", "", ""},
+		"PUT /categories/reorder":                                        {"category.reorder", "", "", ""},
+		"PUT /categories/{id:[0-9]+}/attributes":                         {"category.attributes.update", "id", "", ""},
+		"POST /attributes/{id:[0-9]+}/values":                            {"attribute_value.create", "", "attribute", "id"},
+		"PUT /attributes/{id:[0-9]+}/values/{valueId:[0-9]+}":            {"attribute_value.update", "valueId", "", ""},
+		"POST /products/{id:[0-9]+}/images":                              {"product_image.create", "", "product", "id"},
+		"DELETE /products/{id:[0-9]+}/images/{imageId:[0-9]+}":           {"product_image.delete", "imageId", "", ""},
+		"POST /products/{id:[0-9]+}/variants/{variantId:[0-9]+}/restore": {"variant.restore", "variantId", "", ""},
+		"PATCH /orders/{id:[0-9]+}/status":                               {"order.status.update", "id", "", ""},
+		"POST /reviews/{id:[0-9]+}/replies":                              {"review_reply.create", "", "review", "id"},
+	}
+
+	seen := 0
+	for _, rt := range AdminRouteTable() {
+		if rt.Method == http.MethodGet {
+			continue
+		}
+		key := rt.Method + " " + rt.Path
+		a := service.NewAuditRoute(rt.Method, rt.Path)
+		if a.Action == "" || strings.ContainsAny(a.Action, "{}") || strings.HasPrefix(a.Action, ".") {
+			t.Errorf("%s: bad action %q", key, a.Action)
+		}
+		if a.IDVar != "" && !strings.Contains(rt.Path, "{"+a.IDVar) {
+			t.Errorf("%s: id var %q not in template", key, a.IDVar)
+		}
+		if a.Parent != nil && !strings.Contains(rt.Path, "{"+a.ParentIDVar) {
+			t.Errorf("%s: parent id var %q not in template", key, a.ParentIDVar)
+		}
+
+		w, ok := want[key]
+		if !ok {
+			continue
+		}
+		seen++
+		parent := ""
+		if a.Parent != nil {
+			parent = a.Parent.Type
+		}
+		if a.Action != w.action || a.IDVar != w.idVar || parent != w.parent || a.ParentIDVar != w.parentIDVar {
+			t.Errorf("%s: got action=%q id=%q parent=%q/%q, want %q id=%q parent=%q/%q",
+				key, a.Action, a.IDVar, parent, a.ParentIDVar, w.action, w.idVar, w.parent, w.parentIDVar)
+		}
+	}
+	if seen != len(want) {
+		t.Errorf("checked %d of %d expected routes, route table changed?", seen, len(want))
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/middlewares"
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// testUsers: user giả cho JWT middleware (id -> role), token_version luôn 0
+var testUsers = map[uint]string{
+	1: "customer",
+	2: "customer",
+	3: "staff",
+	4: "admin",
+}
+
+// setupAuth ký token bằng secret test và tra trạng thái token từ testUsers thay vì DB
+func setupAuth(t *testing.T) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_SESSION_KEYS", "")
+	orig := middlewares.LookupTokenState
+	middlewares.LookupTokenState = func(userID uint) (string, uint, error) {
+		role, ok := testUsers[userID]
+		if !ok {
+			return "", 0, errors.New("user not found")
+		}
+		return role, 0, nil
+	}
+	t.Cleanup(func() { middlewares.LookupTokenState = orig })
+}
+
+func tokenFor(t *testing.T, userID uint) string {
+	t.Helper()
+	token, err := service.GenerateToken(&models.User{ID: userID, Role: testUsers[userID], Username: "test"})
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+// serve gửi request tới router, token rỗng = không đăng nhập
+func serve(router *mux.Router, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, want int, what string) {
+	t.Helper()
+	if rec.Code != want {
+		t.Errorf("%s: status = %d, want %d (body %q)", what, rec.Code, want, strings.TrimSpace(rec.Body.String()))
+	}
+}
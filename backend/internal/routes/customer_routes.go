@@ -7,27 +7,76 @@ import (
 )
 
 func SetupCustomerRoutes(r *mux.Router) {
+	// Public: catalog + giỏ hàng khách vãng lai, token (nếu có) vẫn được đọc
+	publicRouter := r.PathPrefix("/api/customer").Subrouter()
+	publicRouter.Use(middlewares.OptionalJWTMiddleware)
+
+	publicRouter.HandleFunc("/categories", customerCtrl.GetCategoriesByGroup).Methods("GET")
+	publicRouter.HandleFunc("/categories/tree", customerCtrl.GetCategoryTree).Methods("GET")
+	publicRouter.HandleFunc("/products", customerCtrl.GetProductsByCategory).Methods("GET")
+
+	publicRouter.HandleFunc("/products-all", customerCtrl.GetAllProducts).Methods("GET")
+	publicRouter.HandleFunc("/products/filter", customerCtrl.FilterProducts).Methods("GET")
+	publicRouter.HandleFunc("/products-latest", customerCtrl.GetLatestProducts).Methods("GET")
+	publicRouter.HandleFunc("/products-random", customerCtrl.GetRandomProducts).Methods("GET")
+	publicRouter.HandleFunc("/product/{slug}", customerCtrl.GetProductDetail).Methods("GET")
+	publicRouter.HandleFunc("/product/{slug}/bought-together", customerCtrl.GetBoughtTogether).Methods("GET")
+	publicRouter.HandleFunc("/product/{slug}/similar", customerCtrl.GetSimilarProducts).Methods("GET")
+	publicRouter.HandleFunc("/product/{slug}/reviews", customerCtrl.GetProductReviews).Methods("GET")
+	publicRouter.HandleFunc("/products-best-sellers", customerCtrl.GetBestSellersHandler).Methods("GET")
+	publicRouter.HandleFunc("/products-best-sellers/by-category", customerCtrl.GetBestSellersByCategoryHandler).Methods("GET")
+	publicRouter.HandleFunc("/products-trending", customerCtrl.GetTrendingProductsHandler).Methods("GET")
+	publicRouter.HandleFunc("/products-discounted", customerCtrl.GetDiscountedProductsHandler).Methods("GET")
+
+	publicRouter.HandleFunc("/search/suggest", customerCtrl.SearchSuggestions).Methods("GET")
+	publicRouter.HandleFunc("/search", customerCtrl.SearchProducts).Methods("GET")
+
+	publicRouter.HandleFunc("/guest/session", customerCtrl.CreateGuestSession).Methods("POST")
+	publicRouter.HandleFunc("/guest/cart", customerCtrl.GetGuestCart).Methods("GET")
+	publicRouter.HandleFunc("/guest/cart/add", customerCtrl.AddToGuestCart).Methods("POST")
+	publicRouter.HandleFunc("/guest/cart/update", customerCtrl.UpdateGuestCartItem).Methods("PUT")
+	publicRouter.HandleFunc("/guest/cart/remove/{variantId:[0-9]+}", customerCtrl.RemoveGuestCartItem).Methods("DELETE")
+	publicRouter.HandleFunc("/guest/cart/clear", customerCtrl.ClearGuestCart).Methods("DELETE")
+	publicRouter.HandleFunc("/guest/checkout", customerCtrl.GuestCheckout).Methods("POST")
+	publicRouter.HandleFunc("/email-change/confirm", customerCtrl.ConfirmEmailChange).Methods("GET")
+
 	custRouter := r.PathPrefix("/api/customer").Subrouter()
 	custRouter.Use(middlewares.JWTMiddleware) 
-	
-	custRouter.HandleFunc("/categories", customerCtrl.GetCategoriesByGroup).Methods("GET")
-	custRouter.HandleFunc("/products", customerCtrl.GetProductsByCategory).Methods("GET")
-	
-	custRouter.HandleFunc("/products-all", customerCtrl.GetAllProducts).Methods("GET")
-	custRouter.HandleFunc("/products-latest", customerCtrl.GetLatestProducts).Methods("GET")
-	custRouter.HandleFunc("/products-random", customerCtrl.GetRandomProducts).Methods("GET")
-	custRouter.HandleFunc("/product/{slug}", customerCtrl.GetProductDetail).Methods("GET")
-	custRouter.HandleFunc("/products-best-sellers", customerCtrl.GetBestSellersHandler).Methods("GET")
-	custRouter.HandleFunc("/products-discounted", customerCtrl.GetDiscountedProductsHandler).Methods("GET")
-
-	custRouter.HandleFunc("/search/suggest", customerCtrl.SearchSuggestions).Methods("GET")
-	custRouter.HandleFunc("/search", customerCtrl.SearchProducts).Methods("GET")
-	custRouter.HandleFunc("/cart/{userId}", customerCtrl.GetCart).Methods("GET")
+
+	custRouter.HandleFunc("/product/{slug}/reviews", customerCtrl.CreateProductReview).Methods("POST")
+	custRouter.HandleFunc("/product/{slug}/reviews/eligibility", customerCtrl.GetReviewEligibility).Methods("GET")
+	custRouter.HandleFunc("/reviews/{id}/helpful", customerCtrl.ToggleReviewHelpful).Methods("POST")
+	custRouter.HandleFunc("/recommendations", customerCtrl.GetRecommendationsForMe).Methods("GET")
+
+	custRouter.HandleFunc("/cart/merge", customerCtrl.MergeGuestCart).Methods("POST")
+	// user luôn lấy từ JWT; các route có {userId} giữ lại cho client cũ, khác user -> 403
+	// hồ sơ cá nhân + quyền dữ liệu (sửa, đổi email, xoá tài khoản, xuất dữ liệu)
+	custRouter.HandleFunc("/me", customerCtrl.GetMyProfile).Methods("GET")
+	custRouter.HandleFunc("/me", customerCtrl.UpdateMyProfile).Methods("PUT")
+	custRouter.HandleFunc("/me", customerCtrl.DeleteMyAccount).Methods("DELETE")
+	custRouter.HandleFunc("/me/email", customerCtrl.RequestEmailChange).Methods("POST")
+	custRouter.HandleFunc("/me/export", customerCtrl.ExportMyData).Methods("GET")
+
+	custRouter.HandleFunc("/me/cart", customerCtrl.GetMyCart).Methods("GET")
+	custRouter.HandleFunc("/me/cart", customerCtrl.AddToCart).Methods("POST")
+	custRouter.HandleFunc("/me/cart", customerCtrl.UpdateCartItem).Methods("PUT")
+	custRouter.HandleFunc("/me/cart", customerCtrl.ClearMyCart).Methods("DELETE")
+	custRouter.HandleFunc("/me/cart/{variantId:[0-9]+}", customerCtrl.RemoveMyCartItem).Methods("DELETE")
+	custRouter.HandleFunc("/me/orders", customerCtrl.GetCustomerOrdersHandler).Methods("GET")
+
+	custRouter.HandleFunc("/cart/{userId:[0-9]+}", customerCtrl.GetCart).Methods("GET")
 
 	custRouter.HandleFunc("/cart/add", customerCtrl.AddToCart).Methods("POST")
 	custRouter.HandleFunc("/cart/update", customerCtrl.UpdateCartItem).Methods("PUT")
-	custRouter.HandleFunc("/cart/remove/{userId}/{variantId}", customerCtrl.RemoveCartItem).Methods("DELETE")
-	custRouter.HandleFunc("/cart/clear/{userId}", customerCtrl.ClearCart).Methods("DELETE")
+	custRouter.HandleFunc("/cart/remove/{userId:[0-9]+}/{variantId:[0-9]+}", customerCtrl.RemoveCartItem).Methods("DELETE")
+	custRouter.HandleFunc("/cart/clear/{userId:[0-9]+}", customerCtrl.ClearCart).Methods("DELETE")
+
+	custRouter.HandleFunc("/wishlist", customerCtrl.GetWishlist).Methods("GET")
+	custRouter.HandleFunc("/wishlist/{productId:[0-9]+}", customerCtrl.AddToWishlist).Methods("POST")
+	custRouter.HandleFunc("/wishlist/{productId:[0-9]+}", customerCtrl.RemoveFromWishlist).Methods("DELETE")
+	custRouter.HandleFunc("/stock-subscriptions", customerCtrl.GetStockSubscriptions).Methods("GET")
+	custRouter.HandleFunc("/variants/{variantId:[0-9]+}/notify-me", customerCtrl.SubscribeBackInStock).Methods("POST")
+	custRouter.HandleFunc("/variants/{variantId:[0-9]+}/notify-me", customerCtrl.UnsubscribeBackInStock).Methods("DELETE")
 
 	custRouter.HandleFunc("/orders", customerCtrl.PlaceOrderHandler).Methods("POST")
 
@@ -37,4 +86,5 @@ func SetupCustomerRoutes(r *mux.Router) {
   
 	r.HandleFunc("/api/vnpay-return", customerCtrl.VnpayReturnHandler).Methods("GET")
 	custRouter.HandleFunc("/chat", customerCtrl.ChatHandler).Methods("POST")
+	custRouter.HandleFunc("/uploads/chat", customerCtrl.UploadChatImage).Methods("POST")
 }
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// Route giỏ hàng/đặt hàng cũ nhận userId/customer_id từ client: khác user trong JWT phải bị 403
+func TestCustomerCartRoutesRejectOtherUsers(t *testing.T) {
+	setupAuth(t)
+	r := mux.NewRouter()
+	SetupCustomerRoutes(r)
+	token := tokenFor(t, 1)
+
+	cases := []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, "/api/customer/cart/2", ""},
+		{http.MethodDelete, "/api/customer/cart/remove/2/5", ""},
+		{http.MethodDelete, "/api/customer/cart/clear/2", ""},
+		{http.MethodPost, "/api/customer/orders", `{"customer_id": 2, "payment_method": "cod", "items": [{"variant_id": 5, "quantity": 1}]}`},
+	}
+	for _, c := range cases {
+		expectStatus(t, serve(r, c.method, c.path, token, c.body), http.StatusForbidden, c.method+" "+c.path)
+	}
+}
+
+func TestCustomerCartRoutesRequireLogin(t *testing.T) {
+	setupAuth(t)
+	r := mux.NewRouter()
+	SetupCustomerRoutes(r)
+
+	expectStatus(t, serve(r, http.MethodGet, "/api/customer/cart/1", "", ""), http.StatusUnauthorized, "GET cart without token")
+	expectStatus(t, serve(r, http.MethodDelete, "/api/customer/cart/clear/1", "", ""), http.StatusUnauthorized, "DELETE cart without token")
+}
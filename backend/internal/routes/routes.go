@@ -2,7 +2,13 @@
 package routes
 
 import (
+    "net/http"
+    "time"
+
     "backend/internal/controllers"
+    "backend/internal/middlewares"
+    "backend/internal/models"
+    "backend/internal/ratelimit"
     "github.com/gorilla/mux"
 )
 
@@ -11,17 +17,43 @@ func SetupRoutes(r *mux.Router, chatHandler *controllers.ChatHandler, msgControl
 
     // Auth routes
     auth := api.PathPrefix("/auth").Subrouter()
+    // giới hạn mọi endpoint auth theo IP (AUTH_RATE_LIMIT_PER_MINUTE, mặc định 30/phút)
+    auth.Use(middlewares.RateLimitByIP(ratelimit.FromEnv("auth_ip", "AUTH_RATE_LIMIT_PER_MINUTE", 30, time.Minute)))
     auth.HandleFunc("/register", controllers.RegisterHandler).Methods("POST")
     auth.HandleFunc("/confirm", controllers.ConfirmRegisterHandler).Methods("GET")
+    auth.HandleFunc("/resend-confirmation", controllers.ResendConfirmationHandler).Methods("POST")
     auth.HandleFunc("/login", controllers.LoginHandler).Methods("POST")
+    auth.HandleFunc("/refresh", controllers.RefreshHandler).Methods("POST")
+    auth.HandleFunc("/logout", controllers.LogoutHandler).Methods("POST")
+    auth.HandleFunc("/forgot-password", controllers.ForgotPasswordHandler).Methods("POST")
+    auth.HandleFunc("/reset-password", controllers.ResetPasswordHandler).Methods("POST")
+    auth.Handle("/change-password", middlewares.JWTMiddleware(http.HandlerFunc(controllers.ChangePasswordHandler))).Methods("POST")
+
+    // MFA (TOTP): setup/activate nhận JWT hoặc mfa_token khi bị bắt đăng ký lúc login
+    auth.HandleFunc("/mfa/verify", controllers.VerifyMFAHandler).Methods("POST")
+    auth.Handle("/mfa/setup", middlewares.OptionalJWTMiddleware(http.HandlerFunc(controllers.SetupMFAHandler))).Methods("POST")
+    auth.Handle("/mfa/activate", middlewares.OptionalJWTMiddleware(http.HandlerFunc(controllers.ActivateMFAHandler))).Methods("POST")
+    auth.Handle("/mfa", middlewares.JWTMiddleware(http.HandlerFunc(controllers.GetMFAStatusHandler))).Methods("GET")
+    auth.Handle("/mfa", middlewares.JWTMiddleware(http.HandlerFunc(controllers.DisableMFAHandler))).Methods("DELETE")
+    auth.Handle("/mfa/recovery-codes", middlewares.JWTMiddleware(http.HandlerFunc(controllers.RegenerateRecoveryCodesHandler))).Methods("POST")
+
+    // Đăng nhập qua OIDC/OAuth2 (provider cấu hình bằng OIDC_PROVIDERS)
+    auth.HandleFunc("/oidc/providers", controllers.GetOIDCProvidersHandler).Methods("GET")
+    auth.HandleFunc("/oidc/{provider}/login", controllers.OIDCLoginHandler).Methods("GET")
+    auth.HandleFunc("/oidc/{provider}/callback", controllers.OIDCCallbackHandler).Methods("GET")
 
-    // WebSocket
-    api.HandleFunc("/ws", chatHandler.HandleWS)
+    // WebSocket: danh tính lấy từ JWT (?token=), không tin role/customer_id trên query
+    api.Handle("/ws", middlewares.WebSocketJWTMiddleware(http.HandlerFunc(chatHandler.HandleWS)))
 
-    admin := api.PathPrefix("/admin").Subrouter()
-    admin.HandleFunc("/messages/customers", msgController.GetCustomerList).Methods("GET")
-	admin.HandleFunc("/messages/unread", msgController.GetUnreadSummary).Methods("GET")
-	admin.HandleFunc("/messages/{customerId}", msgController.GetMessagesByCustomer).Methods("GET")
-	admin.HandleFunc("/messages/{customerId}/read", msgController.MarkAsRead).Methods("PATCH")
-	admin.HandleFunc("/messages/recent", msgController.GetRecentMessages).Methods("GET")
+    admin := api.PathPrefix("/admin/messages").Subrouter()
+    admin.Use(middlewares.JWTMiddleware)
+    admin.Use(middlewares.RoleMiddleware("admin", "staff"))
+    // audit trước kiểm tra quyền để lần bị từ chối cũng được ghi, giống route trong AdminRouteTable
+    admin.Use(middlewares.AuditWrites("/api/admin"))
+    admin.Use(middlewares.RequirePermission(models.PermMessagesManage))
+    admin.HandleFunc("/customers", msgController.GetCustomerList).Methods("GET")
+	admin.HandleFunc("/unread", msgController.GetUnreadSummary).Methods("GET")
+	admin.HandleFunc("/{customerId:[0-9]+}", msgController.GetMessagesByCustomer).Methods("GET")
+	admin.HandleFunc("/{customerId:[0-9]+}/read", msgController.MarkAsRead).Methods("PATCH")
+	admin.HandleFunc("/recent", msgController.GetRecentMessages).Methods("GET")
 }
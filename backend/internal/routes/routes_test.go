@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/configs"
+	"backend/internal/controllers"
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// WebSocket chat: role/customer_id trên query phải khớp JWT, bị từ chối trước khi upgrade
+func TestChatWebSocketChecksIdentity(t *testing.T) {
+	setupAuth(t)
+	r := mux.NewRouter()
+	SetupRoutes(r, controllers.NewChatHandler(nil), controllers.NewMessageController(nil))
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/api/ws?role=customer&customer_id=1", http.StatusUnauthorized},
+		{"/api/ws?role=customer&customer_id=2&token=" + tokenFor(t, 1), http.StatusForbidden},
+		{"/api/ws?role=admin&token=" + tokenFor(t, 1), http.StatusForbidden},
+		{"/api/ws?role=customer&token=" + tokenFor(t, 3), http.StatusForbidden},
+	}
+	for _, c := range cases {
+		expectStatus(t, serve(r, http.MethodGet, c.path, "", ""), c.want, "GET "+c.path)
+	}
+}
+
+// PATCH /api/admin/messages/{customerId}/read được audit, kể cả khi bị từ chối quyền; GET thì không
+func TestAdminMessagesWritesAreAudited(t *testing.T) {
+	setupAuth(t)
+	dryRunDB(t)
+	service.SetPermissions(map[string][]string{"staff": {}})
+	t.Cleanup(func() { service.SetPermissions(nil) })
+
+	var logs []*models.AuditLog
+	err := configs.DB.Callback().Create().After("gorm:create").Register("test:capture_audit", func(tx *gorm.DB) {
+		if entry, ok := tx.Statement.Dest.(*models.AuditLog); ok {
+			logs = append(logs, entry)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := mux.NewRouter()
+	SetupRoutes(r, controllers.NewChatHandler(nil), controllers.NewMessageController(nil))
+	staff := tokenFor(t, 3)
+
+	expectStatus(t, serve(r, http.MethodGet, "/api/admin/messages/unread", staff, ""), http.StatusForbidden, "GET unread")
+	if len(logs) != 0 {
+		t.Fatalf("GET was audited: %+v", logs[0])
+	}
+	expectStatus(t, serve(r, http.MethodPatch, "/api/admin/messages/7/read", staff, ""), http.StatusForbidden, "PATCH read")
+	if len(logs) != 1 {
+		t.Fatalf("got %d audit logs, want 1", len(logs))
+	}
+	if e := logs[0]; e.Action != "conversation.read.update" || e.EntityID != "7" || e.Status != http.StatusForbidden || e.ActorID != 3 {
+		t.Errorf("unexpected audit log %+v", e)
+	}
+}
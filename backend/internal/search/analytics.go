@@ -0,0 +1,37 @@
+package search
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxLoggedQuery = 255
+
+// LogQuery ghi log truy vấn ở goroutine riêng để không làm chậm request
+func LogQuery(source, query string, results int) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+	entry := models.SearchLog{
+		Query:           truncate(query, maxLoggedQuery),
+		NormalizedQuery: truncate(Normalize(query), maxLoggedQuery),
+		Source:          source,
+		ResultCount:     results,
+	}
+	go func() {
+		if err := configs.DB.Create(&entry).Error; err != nil {
+			log.Println("Search log failed:", err)
+		}
+	}()
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const snippetRunes = 160
+
+// Highlight: tên và đoạn mô tả đã escape HTML, từ khớp được bọc trong <mark>
+type Highlight struct {
+	Name    string `json:"name"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+type segment struct {
+	text  string
+	match bool
+}
+
+// splitWords tách text gốc thành các đoạn chữ/không chữ, đánh dấu từ có fold nằm trong terms
+func splitWords(text string, terms map[string]bool) []segment {
+	var segs []segment
+	var cur []rune
+	inWord := false
+	flush := func() {
+		if len(cur) == 0 {
+			return
+		}
+		s := string(cur)
+		segs = append(segs, segment{text: s, match: inWord && terms[Fold(s)]})
+		cur = cur[:0]
+	}
+	for _, r := range text {
+		w := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+		if w != inWord {
+			flush()
+			inWord = w
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return segs
+}
+
+func render(segs []segment) string {
+	var b strings.Builder
+	for _, s := range segs {
+		if s.match {
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(s.text))
+			b.WriteString("</mark>")
+		} else {
+			b.WriteString(html.EscapeString(s.text))
+		}
+	}
+	return b.String()
+}
+
+// Highlighter tạo highlight cho tên và đoạn trích mô tả quanh từ khớp đầu tiên
+func Highlighter(name, description string, matched []string) Highlight {
+	terms := map[string]bool{}
+	for _, t := range matched {
+		terms[t] = true
+	}
+	h := Highlight{Name: render(splitWords(name, terms))}
+
+	segs := splitWords(description, terms)
+	first := -1
+	for i, s := range segs {
+		if s.match {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return h
+	}
+
+	// lùi ~1/3 độ dài snippet trước từ khớp, rồi lấy tới khi đủ snippetRunes
+	start, budget := first, snippetRunes/3
+	for start > 0 && budget > 0 {
+		start--
+		budget -= len([]rune(segs[start].text))
+	}
+	end, total := start, 0
+	for end < len(segs) && total < snippetRunes {
+		total += len([]rune(segs[end].text))
+		end++
+	}
+
+	snippet := strings.TrimSpace(render(segs[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(segs) {
+		snippet += "…"
+	}
+	h.Snippet = snippet
+	return h
+}
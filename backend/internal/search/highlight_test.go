@@ -0,0 +1,41 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlighterMarksFoldedMatches(t *testing.T) {
+	h := Highlighter("Đầm dạ hội ĐEN", "Chất liệu lụa, màu đen tuyền, dáng dài", []string{"dam", "den"})
+	if want := "<mark>Đầm</mark> dạ hội <mark>ĐEN</mark>"; h.Name != want {
+		t.Errorf("Name = %q, want %q", h.Name, want)
+	}
+	if !strings.Contains(h.Snippet, "màu <mark>đen</mark> tuyền") {
+		t.Errorf("Snippet = %q", h.Snippet)
+	}
+}
+
+func TestHighlighterKeepsCombiningMarksInsideMatch(t *testing.T) {
+	// "Áo" viết dạng tổ hợp A + U+0301: dấu phải nằm trong <mark>, không bị tách ra ngoài
+	h := Highlighter("A\u0301o thun", "", []string{"ao"})
+	if want := "<mark>A\u0301o</mark> thun"; h.Name != want {
+		t.Errorf("Name = %q, want %q", h.Name, want)
+	}
+}
+
+func TestHighlighterEscapesAndTrimsSnippet(t *testing.T) {
+	h := Highlighter("<b>Áo</b>", strings.Repeat("vải mềm ", 40)+"cổ tròn "+strings.Repeat("dễ giặt ", 40), []string{"tron"})
+	if h.Name != "&lt;b&gt;Áo&lt;/b&gt;" {
+		t.Errorf("Name not escaped: %q", h.Name)
+	}
+	if !strings.HasPrefix(h.Snippet, "…") || !strings.HasSuffix(h.Snippet, "…") || !strings.Contains(h.Snippet, "<mark>tròn</mark>") {
+		t.Errorf("Snippet = %q", h.Snippet)
+	}
+	if n := len([]rune(h.Snippet)); n > snippetRunes+40 {
+		t.Errorf("snippet too long: %d runes", n)
+	}
+
+	if h := Highlighter("Áo", "không khớp gì", []string{"quan"}); h.Snippet != "" {
+		t.Errorf("Snippet without match = %q, want empty", h.Snippet)
+	}
+}
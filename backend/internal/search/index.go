@@ -0,0 +1,292 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Trọng số theo trường: tên > SKU > category > mô tả
+const (
+	weightName        = 10.0
+	weightNameJoined  = 6.0 // "aothun" khớp "Áo thun"
+	weightSKU         = 8.0
+	weightCategory    = 4.0
+	weightDescription = 1.0
+
+	factorExact  = 1.0
+	factorPrefix = 0.7
+	factorTypo1  = 0.5
+	factorTypo2  = 0.35
+
+	bonusPhrase     = 15.0
+	bonusNamePrefix = 5.0
+)
+
+// Document là dữ liệu của 1 sản phẩm được đưa vào index
+type Document struct {
+	ProductID   uint
+	Name        string
+	Description string
+	Category    string
+	SKUs        []string
+}
+
+// Match là 1 kết quả đã chấm điểm, Terms là các term trong index đã khớp (dùng để highlight)
+type Match struct {
+	ProductID uint
+	Score     float64
+	Terms     []string
+}
+
+type indexedDoc struct {
+	foldedName string
+	terms      map[string]float64
+}
+
+// Index: inverted index trong bộ nhớ, an toàn khi dùng đồng thời
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[uint]*indexedDoc
+	postings map[string]map[uint]float64
+	vocab    []string // term đã sắp xếp cho prefix search, nil khi cần dựng lại
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     map[uint]*indexedDoc{},
+		postings: map[string]map[uint]float64{},
+	}
+}
+
+// Len trả về số sản phẩm trong index
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Replace thay toàn bộ nội dung index
+func (ix *Index) Replace(docs []Document) {
+	fresh := NewIndex()
+	for _, d := range docs {
+		fresh.add(d)
+	}
+	ix.mu.Lock()
+	ix.docs, ix.postings, ix.vocab = fresh.docs, fresh.postings, nil
+	ix.mu.Unlock()
+}
+
+// Upsert thêm hoặc cập nhật 1 sản phẩm
+func (ix *Index) Upsert(d Document) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(d.ProductID)
+	ix.add(d)
+}
+
+// Remove xoá sản phẩm khỏi index
+func (ix *Index) Remove(productID uint) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(productID)
+}
+
+func (ix *Index) add(d Document) {
+	doc := &indexedDoc{foldedName: strings.Join(Tokenize(d.Name), " "), terms: map[string]float64{}}
+	put := func(term string, w float64) {
+		if term != "" && w > doc.terms[term] {
+			doc.terms[term] = w
+		}
+	}
+
+	name := Tokenize(d.Name)
+	for i, t := range name {
+		put(t, weightName)
+		if i > 0 {
+			put(name[i-1]+t, weightNameJoined)
+		}
+	}
+	for _, sku := range d.SKUs {
+		parts := Tokenize(sku)
+		for _, t := range parts {
+			put(t, weightSKU/2)
+		}
+		put(strings.Join(parts, ""), weightSKU)
+	}
+	for _, t := range Tokenize(d.Category) {
+		put(t, weightCategory)
+	}
+	for _, t := range Tokenize(d.Description) {
+		put(t, weightDescription)
+	}
+
+	ix.docs[d.ProductID] = doc
+	for term, w := range doc.terms {
+		p := ix.postings[term]
+		if p == nil {
+			p = map[uint]float64{}
+			ix.postings[term] = p
+			ix.vocab = nil
+		}
+		p[d.ProductID] = w
+	}
+}
+
+func (ix *Index) remove(productID uint) {
+	doc, ok := ix.docs[productID]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		if p := ix.postings[term]; p != nil {
+			delete(p, productID)
+			if len(p) == 0 {
+				delete(ix.postings, term)
+				ix.vocab = nil
+			}
+		}
+	}
+	delete(ix.docs, productID)
+}
+
+// sortedVocab dựng lại danh sách term khi index thay đổi. Gọi khi đã giữ lock ghi.
+func (ix *Index) sortedVocab() []string {
+	if ix.vocab == nil {
+		ix.vocab = make([]string, 0, len(ix.postings))
+		for t := range ix.postings {
+			ix.vocab = append(ix.vocab, t)
+		}
+		sort.Strings(ix.vocab)
+	}
+	return ix.vocab
+}
+
+// expand tìm các term trong index khớp với token truy vấn kèm hệ số
+func (ix *Index) expand(vocab []string, token string, prefix bool) map[string]float64 {
+	out := map[string]float64{}
+	if _, ok := ix.postings[token]; ok {
+		out[token] = factorExact
+	}
+	if prefix {
+		for i := sort.SearchStrings(vocab, token); i < len(vocab) && strings.HasPrefix(vocab[i], token); i++ {
+			if vocab[i] != token {
+				out[vocab[i]] = factorPrefix
+			}
+		}
+	}
+	if len(out) == 0 {
+		if maxD := maxTypos(token); maxD > 0 {
+			for _, t := range vocab {
+				if !isWord(t) {
+					continue
+				}
+				if d := editDistance(token, t, maxD); d == 1 {
+					out[t] = factorTypo1
+				} else if d == 2 && maxD >= 2 {
+					out[t] = factorTypo2
+				}
+			}
+		}
+	}
+	return out
+}
+
+func isWord(t string) bool {
+	for _, r := range t {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Search chấm điểm sản phẩm theo truy vấn. prefixAll = true cho autocomplete
+// (mọi từ đều có thể là tiền tố), ngược lại chỉ từ cuối được coi là tiền tố.
+func (ix *Index) Search(query string, prefixAll bool) []Match {
+	tokens := dedupe(Tokenize(query))
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	ix.mu.Lock()
+	vocab := ix.sortedVocab()
+	ix.mu.Unlock()
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	type acc struct {
+		score   float64
+		matched int
+		terms   []string
+	}
+	results := map[uint]*acc{}
+	for i, tok := range tokens {
+		prefix := prefixAll || i == len(tokens)-1
+		best := map[uint]float64{}
+		bestTerm := map[uint]string{}
+		for term, factor := range ix.expand(vocab, tok, prefix) {
+			for id, w := range ix.postings[term] {
+				if s := w * factor; s > best[id] {
+					best[id] = s
+					bestTerm[id] = term
+				}
+			}
+		}
+		for id, s := range best {
+			a := results[id]
+			if a == nil {
+				a = &acc{}
+				results[id] = a
+			}
+			a.score += s
+			a.matched++
+			a.terms = append(a.terms, bestTerm[id])
+		}
+	}
+
+	phrase := strings.Join(tokens, " ")
+	out := make([]Match, 0, len(results))
+	for id, a := range results {
+		coverage := float64(a.matched) / float64(len(tokens))
+		if coverage < 0.5 {
+			continue
+		}
+		score := a.score * coverage
+		if doc := ix.docs[id]; doc != nil && len(tokens) > 1 {
+			if strings.Contains(doc.foldedName, phrase) {
+				score += bonusPhrase
+			}
+		}
+		if doc := ix.docs[id]; doc != nil && strings.HasPrefix(doc.foldedName, phrase) {
+			score += bonusNamePrefix
+		}
+		out = append(out, Match{ProductID: id, Score: score, Terms: a.terms})
+	}
+	sortMatches(out)
+	return out
+}
+
+// sortMatches: điểm cao trước, cùng điểm thì sản phẩm mới hơn trước
+func sortMatches(out []Match) {
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].ProductID > out[j].ProductID
+	})
+}
+
+func dedupe(tokens []string) []string {
+	seen := map[string]bool{}
+	out := tokens[:0]
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package search
+
+import "testing"
+
+func testIndex() *Index {
+	ix := NewIndex()
+	ix.Replace([]Document{
+		{ProductID: 1, Name: "Áo thun cổ tròn", Category: "Áo", Description: "Cotton 100%", SKUs: []string{"AT-001"}},
+		{ProductID: 2, Name: "Đầm dạ hội", Category: "Đầm", Description: "Dáng dài, màu đen"},
+		{ProductID: 3, Name: "Áo khoác jean", Category: "Áo khoác", SKUs: []string{"AK-010"}},
+		{ProductID: 4, Name: "Quần jean nam", Category: "Quần", Description: "Form slim"},
+	})
+	return ix
+}
+
+func ids(ms []Match) []uint {
+	out := make([]uint, len(ms))
+	for i, m := range ms {
+		out[i] = m.ProductID
+	}
+	return out
+}
+
+func find(ms []Match, id uint) *Match {
+	for i := range ms {
+		if ms[i].ProductID == id {
+			return &ms[i]
+		}
+	}
+	return nil
+}
+
+func TestSearchFoldsDiacritics(t *testing.T) {
+	ix := testIndex()
+	for _, q := range []string{"ao thun", "Áo Thun", "ÁO THUN"} {
+		if res := ix.Search(q, false); len(res) == 0 || res[0].ProductID != 1 {
+			t.Errorf("Search(%q) = %v, want product 1 first", q, ids(res))
+		}
+	}
+	for _, q := range []string{"dam", "đầm", "Đầm"} {
+		if res := ix.Search(q, false); len(res) == 0 || res[0].ProductID != 2 {
+			t.Errorf("Search(%q) = %v, want product 2 first", q, ids(res))
+		}
+	}
+}
+
+func TestSearchRanksNameAboveDescription(t *testing.T) {
+	ix := testIndex()
+	ix.Upsert(Document{ProductID: 5, Name: "Áo sơ mi", Description: "phối với quần jean"})
+	res := ix.Search("jean", false)
+	if len(res) < 3 {
+		t.Fatalf("Search(jean) = %v", ids(res))
+	}
+	if last := res[len(res)-1].ProductID; last != 5 {
+		t.Errorf("description-only match should rank last, got order %v", ids(res))
+	}
+}
+
+func TestSearchTypoRanksBelowExact(t *testing.T) {
+	ix := testIndex()
+	exact := find(ix.Search("ao khoac", false), 3)
+	typo := find(ix.Search("ao khoat", false), 3)
+	if exact == nil || typo == nil {
+		t.Fatalf("exact %v, typo %v: both queries should find product 3", exact, typo)
+	}
+	if typo.Score >= exact.Score {
+		t.Errorf("typo score %.2f should be below exact %.2f", typo.Score, exact.Score)
+	}
+	// từ ngắn (≤ 3 ký tự) không sửa lỗi gõ
+	if res := ix.Search("dao", false); find(res, 2) != nil {
+		t.Errorf("short token matched with a typo: %v", ids(res))
+	}
+}
+
+func TestSearchPrefixOnlyOnLastToken(t *testing.T) {
+	ix := testIndex()
+	if m := find(ix.Search("ao kho", false), 3); m == nil || !contains(m.Terms, "khoac") {
+		t.Errorf("last token should match as prefix, got %+v", m)
+	}
+	if m := find(ix.Search("kho jean", false), 3); m != nil && contains(m.Terms, "khoac") {
+		t.Errorf("non-last token matched as prefix: %+v", m)
+	}
+	// autocomplete: mọi từ đều là tiền tố
+	if m := find(ix.Search("kho jea", true), 3); m == nil || !contains(m.Terms, "khoac") || !contains(m.Terms, "jean") {
+		t.Errorf("prefixAll should expand every token, got %+v", m)
+	}
+}
+
+func TestSearchSKUAndRemove(t *testing.T) {
+	ix := testIndex()
+	if res := ix.Search("AT-001", false); len(res) == 0 || res[0].ProductID != 1 {
+		t.Errorf("SKU search = %v, want product 1", ids(res))
+	}
+	ix.Remove(1)
+	if res := ix.Search("thun", false); find(res, 1) != nil {
+		t.Errorf("removed product still found: %v", ids(res))
+	}
+	if ix.Len() != 3 {
+		t.Errorf("Len = %d, want 3", ix.Len())
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// Default là index dùng chung cho toàn app, dựng lúc khởi động
+var Default = NewIndex()
+
+func toDocument(p models.Product) Document {
+	d := Document{
+		ProductID:   p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Category:    p.Category.Name,
+	}
+	for _, v := range p.Variants {
+		if v.SKU != "" {
+			d.SKUs = append(d.SKUs, v.SKU)
+		}
+	}
+	return d
+}
+
+// Rebuild nạp toàn bộ sản phẩm từ DB vào Default
+func Rebuild() error {
+	var products []models.Product
+	if err := configs.DB.Preload("Category").Preload("Variants").Find(&products).Error; err != nil {
+		return err
+	}
+	docs := make([]Document, 0, len(products))
+	for _, p := range products {
+		docs = append(docs, toDocument(p))
+	}
+	Default.Replace(docs)
+	log.Printf("Search index built: %d products", len(docs))
+	return nil
+}
+
+// ReindexProduct cập nhật 1 sản phẩm sau khi ghi DB (xoá khỏi index nếu không còn)
+func ReindexProduct(productID uint) {
+	var p models.Product
+	err := configs.DB.Preload("Category").Preload("Variants").First(&p, productID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		Default.Remove(productID)
+		return
+	}
+	if err != nil {
+		log.Println("Search reindex product failed:", err)
+		return
+	}
+	Default.Upsert(toDocument(p))
+}
+
+// ReindexCategory cập nhật các sản phẩm thuộc category (khi đổi tên category)
+func ReindexCategory(categoryID uint) {
+	var ids []uint
+	if err := configs.DB.Model(&models.Product{}).Where("category_id = ?", categoryID).Pluck("id", &ids).Error; err != nil {
+		log.Println("Search reindex category failed:", err)
+		return
+	}
+	for _, id := range ids {
+		ReindexProduct(id)
+	}
+}
+
+// RemoveProduct xoá sản phẩm khỏi index
+func RemoveProduct(productID uint) {
+	Default.Remove(productID)
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"backend/configs"
+	"backend/internal/models"
+	"log"
+	"strings"
+	"sync"
+)
+
+const (
+	factorSynonym   = 0.9
+	maxAlternatives = 10
+)
+
+var synonyms = struct {
+	sync.RWMutex
+	groups [][]string // mỗi cụm đã fold + tokenize, nối bằng dấu cách
+}{}
+
+// Normalize đưa truy vấn về dạng dùng để so khớp/thống kê: "Quần  Bò!" -> "quan bo"
+func Normalize(query string) string {
+	return strings.Join(Tokenize(query), " ")
+}
+
+// SetSynonyms thay toàn bộ từ điển đồng nghĩa
+func SetSynonyms(groups [][]string) {
+	normalized := make([][]string, 0, len(groups))
+	for _, g := range groups {
+		var terms []string
+		for _, t := range g {
+			if n := Normalize(t); n != "" {
+				terms = append(terms, n)
+			}
+		}
+		if len(terms) > 1 {
+			normalized = append(normalized, terms)
+		}
+	}
+	synonyms.Lock()
+	synonyms.groups = normalized
+	synonyms.Unlock()
+}
+
+// LoadSynonyms nạp từ điển đồng nghĩa từ DB (gọi lúc khởi động và sau khi admin sửa)
+func LoadSynonyms() error {
+	var rows []models.SearchSynonym
+	if err := configs.DB.Find(&rows).Error; err != nil {
+		return err
+	}
+	groups := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		groups = append(groups, r.Terms)
+	}
+	SetSynonyms(groups)
+	log.Printf("Search synonyms loaded: %d groups", len(groups))
+	return nil
+}
+
+// expandQuery sinh các truy vấn thay thế bằng cách đổi cụm từ có trong từ điển
+func expandQuery(query string) []string {
+	padded := " " + Normalize(query) + " "
+	synonyms.RLock()
+	defer synonyms.RUnlock()
+
+	var out []string
+	seen := map[string]bool{padded: true}
+	for _, g := range synonyms.groups {
+		for _, from := range g {
+			if !strings.Contains(padded, " "+from+" ") {
+				continue
+			}
+			for _, to := range g {
+				alt := strings.Replace(padded, " "+from+" ", " "+to+" ", 1)
+				if !seen[alt] {
+					seen[alt] = true
+					out = append(out, strings.TrimSpace(alt))
+				}
+				if len(out) >= maxAlternatives {
+					return out
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Query tìm trên Default, mở rộng theo từ đồng nghĩa và gộp kết quả theo điểm cao nhất
+func Query(query string, prefixAll bool) []Match {
+	matches := Default.Search(query, prefixAll)
+	alts := expandQuery(query)
+	if len(alts) == 0 {
+		return matches
+	}
+
+	byID := make(map[uint]int, len(matches))
+	for i, m := range matches {
+		byID[m.ProductID] = i
+	}
+	for _, alt := range alts {
+		for _, m := range Default.Search(alt, prefixAll) {
+			m.Score *= factorSynonym
+			if i, ok := byID[m.ProductID]; ok {
+				if m.Score > matches[i].Score {
+					matches[i].Score = m.Score
+				}
+				matches[i].Terms = append(matches[i].Terms, m.Terms...)
+				continue
+			}
+			byID[m.ProductID] = len(matches)
+			matches = append(matches, m)
+		}
+	}
+	sortMatches(matches)
+	return matches
+}
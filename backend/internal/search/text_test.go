@@ -0,0 +1,74 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	cases := map[string]string{
+		"Áo thun":          "ao thun",
+		"ĐẦM Dạ Hội":       "dam da hoi",
+		"đầm":              "dam",
+		"Quần Jean Xanh":   "quan jean xanh",
+		"A\u0301o":         "ao", // dấu tổ hợp (NFD)
+		"Giày size 42-EU!": "giay size 42-eu!",
+	}
+	for in, want := range cases {
+		if got := Fold(in); got != want {
+			t.Errorf("Fold(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if Fold("đầm") != Fold("dam") {
+		t.Error(`"đầm" and "dam" must fold to the same text`)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	cases := map[string][]string{
+		"Áo thun, cổ tròn!":  {"ao", "thun", "co", "tron"},
+		"SKU: AT-001/đen":    {"sku", "at", "001", "den"},
+		"  ":                 nil,
+		"Quần  Bò (size 30)": {"quan", "bo", "size", "30"},
+	}
+	for in, want := range cases {
+		got := Tokenize(in)
+		if len(got) == 0 && len(want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"thun", "thun", 2, 0},
+		{"thun", "thum", 2, 1},  // thay 1 ký tự
+		{"khoac", "khoc", 2, 1}, // thiếu 1 ký tự
+		{"jean", "jeans", 2, 1}, // thừa 1 ký tự
+		{"quan", "qaun", 2, 1},  // đảo 2 ký tự liền kề
+		{"somi", "smoi", 1, 1},  // đảo
+		{"vay", "dam", 1, 2},    // vượt max → max+1
+		{"ao", "aokhoac", 2, 3}, // chênh độ dài > max
+		{"khoac", "khoat", 1, 1},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b, c.max); got != c.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", c.a, c.b, c.max, got, c.want)
+		}
+	}
+}
+
+func TestMaxTypos(t *testing.T) {
+	for term, want := range map[string]int{"ao": 0, "den": 0, "thun": 1, "khoac": 1, "somivest": 2} {
+		if got := maxTypos(term); got != want {
+			t.Errorf("maxTypos(%q) = %d, want %d", term, got, want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"encoding/json"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// auditEntity: đoạn path của /api/admin ứng với loại bản ghi nào, load snapshot ra sao
+type auditEntity struct {
+	Type string
+	Load func(key string) (interface{}, error) // nil = không chụp snapshot
+}
+
+// snapshotOf đọc bản ghi theo column (mặc định id); 1 dòng trả về object, nhiều dòng trả về list
+func snapshotOf[T any](column string) func(string) (interface{}, error) {
+	return func(key string) (interface{}, error) {
+		var rows []T
+		if err := repository.SnapshotRows(&rows, column, key); err != nil {
+			return nil, err
+		}
+		switch len(rows) {
+		case 0:
+			return nil, nil
+		case 1:
+			return rows[0], nil
+		}
+		return rows, nil
+	}
+}
+
+var auditEntities = map[string]auditEntity{
+	"users":          {"user", snapshotOf[models.User]("id")},
+	"lockouts":       {"user", snapshotOf[models.User]("id")},
+	"permissions":    {"role_permissions", rolePermissionSnapshot},
+	"mfa-policy":     {"mfa_policy", snapshotOf[models.RoleMFAPolicy]("role")},
+	"suppliers":      {"supplier", snapshotOf[models.Supplier]("id")},
+	"purchases":      {"purchase", snapshotOf[models.Purchase]("id")},
+	"categories":     {"category", snapshotOf[models.Category]("id")},
+	"products":       {"product", snapshotOf[models.Product]("id")},
+	"images":         {"product_image", snapshotOf[models.ProductImage]("id")},
+	"uploads":        {"upload", nil},
+	"attributes":     {"attribute", snapshotOf[models.Attribute]("id")},
+	"values":         {"attribute_value", snapshotOf[models.AttributeValue]("id")},
+	"sizes":          {"size_option", snapshotOf[models.SizeOption]("id")},
+	"colors":         {"color_option", snapshotOf[models.ColorOption]("id")},
+	"variants":       {"variant", snapshotOf[models.ProductVariant]("id")},
+	"inventory_logs": {"inventory_log", snapshotOf[models.InventoryLog]("id")},
+	"orders":         {"order", snapshotOf[models.Order]("id")},
+	"synonyms":       {"search_synonym", snapshotOf[models.SearchSynonym]("id")},
+	"reviews":        {"review", snapshotOf[models.Review]("id")},
+	"replies":        {"review_reply", snapshotOf[models.ReviewReply]("id")},
+	"messages":       {"conversation", nil}, // id = customerId
+}
+
+func rolePermissionSnapshot(role string) (interface{}, error) {
+	perms, err := repository.GetRolePermissions()
+	if err != nil {
+		return nil, err
+	}
+	if perms[role] == nil {
+		return []string{}, nil
+	}
+	return perms[role], nil
+}
+
+var auditVerbs = map[string]string{"POST": "create", "PUT": "update", "PATCH": "update", "DELETE": "delete"}
+
+// AuditRoute: thông tin audit tính sẵn từ path template của 1 route admin
+type AuditRoute struct {
+	Method string
+	Path   string
+	Action string
+	Entity auditEntity
+	IDVar  string // biến mux chứa id bản ghi, rỗng = lấy id từ response (tạo mới)
+	// tạo bản ghi con (POST /products/{id}/images): không đọc được id mới thì ghi theo bản ghi cha
+	Parent      *auditEntity
+	ParentIDVar string
+}
+
+func pathVarName(seg string) (string, bool) {
+	if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+		return "", false
+	}
+	name, _, _ := strings.Cut(strings.Trim(seg, "{}"), ":")
+	return name, true
+}
+
+func entityFor(seg string) auditEntity {
+	if e, ok := auditEntities[seg]; ok {
+		return e
+	}
+	return auditEntity{Type: strings.TrimSuffix(seg, "s")}
+}
+
+// NewAuditRoute suy ra action/entity từ template, vd:
+// PUT /products/{id} → product.update, POST /users/{id}/restore → user.restore,
+// POST /attributes/{id}/values → attribute_value.create, PUT /categories/reorder → category.reorder
+func NewAuditRoute(method, path string) *AuditRoute {
+	rt := &AuditRoute{Method: method, Path: path}
+	verb := auditVerbs[method]
+
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	lastVar := -1
+	for i, seg := range segs {
+		if _, ok := pathVarName(seg); ok {
+			lastVar = i
+		}
+	}
+	trailing := segs[lastVar+1:]
+
+	if lastVar < 0 {
+		// không có id: tạo mới (POST /products) hoặc thao tác trên cả tập (PUT /categories/reorder)
+		last := segs[len(segs)-1]
+		if _, ok := auditEntities[last]; ok || len(segs) == 1 {
+			rt.Entity = entityFor(last)
+			rt.Action = rt.Entity.Type + "." + verb
+			return rt
+		}
+		rt.Entity = entityFor(segs[len(segs)-2])
+		rt.Action = rt.Entity.Type + "." + last
+		return rt
+	}
+
+	idVar, _ := pathVarName(segs[lastVar])
+	var owner auditEntity
+	if lastVar > 0 {
+		owner = entityFor(segs[lastVar-1])
+	}
+
+	if len(trailing) == 1 && method == "POST" {
+		if child, ok := auditEntities[trailing[0]]; ok {
+			rt.Entity = child
+			rt.Parent = &owner
+			rt.ParentIDVar = idVar
+			rt.Action = child.Type + ".create"
+			return rt
+		}
+		// động từ riêng: restore, unlock...
+		rt.Entity = owner
+		rt.IDVar = idVar
+		rt.Action = owner.Type + "." + trailing[0]
+		return rt
+	}
+
+	rt.Entity = owner
+	rt.IDVar = idVar
+	rt.Action = strings.Join(append([]string{owner.Type}, trailing...), ".") + "." + verb
+	return rt
+}
+
+// Snapshot chụp bản ghi hiện tại (kể cả đã lưu trữ), lỗi chỉ log lại để không chặn thao tác chính
+func (e auditEntity) Snapshot(key string) interface{} {
+	if e.Load == nil || key == "" {
+		return nil
+	}
+	v, err := e.Load(key)
+	if err != nil {
+		log.Printf("Audit snapshot %s #%s failed: %v", e.Type, key, err)
+		return nil
+	}
+	return v
+}
+
+// ResponseID lấy id bản ghi mới tạo từ JSON trả về: {"id": ..} hoặc {"<key>": {"id": ..}}
+func ResponseID(body []byte) string {
+	var top map[string]json.RawMessage
+	if json.Unmarshal(body, &top) != nil {
+		return ""
+	}
+	if id := rawID(top["id"]); id != "" {
+		return id
+	}
+	found := ""
+	for key, raw := range top {
+		var nested map[string]json.RawMessage
+		if key == "id" || json.Unmarshal(raw, &nested) != nil {
+			continue
+		}
+		if id := rawID(nested["id"]); id != "" {
+			if found != "" {
+				return "" // nhiều object có id, không đoán
+			}
+			found = id
+		}
+	}
+	return found
+}
+
+func rawID(raw json.RawMessage) string {
+	var n json.Number
+	if len(raw) == 0 || json.Unmarshal(raw, &n) != nil {
+		return ""
+	}
+	return n.String()
+}
+
+// auditIgnoredFields: đổi theo mỗi lần lưu, không có ý nghĩa khi so sánh
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+// auditDiff trả về {field: {"from": .., "to": ..}} cho các field thay đổi; list/giá trị đơn thì so cả khối
+func auditDiff(before, after []byte) map[string]interface{} {
+	var b, a interface{}
+	json.Unmarshal(before, &b)
+	json.Unmarshal(after, &a)
+
+	bm, okB := b.(map[string]interface{})
+	am, okA := a.(map[string]interface{})
+	if !okB || !okA {
+		if reflect.DeepEqual(b, a) {
+			return nil
+		}
+		return map[string]interface{}{"value": map[string]interface{}{"from": b, "to": a}}
+	}
+
+	diff := map[string]interface{}{}
+	for k, av := range am {
+		if bv := bm[k]; !auditIgnoredFields[k] && !reflect.DeepEqual(bv, av) {
+			diff[k] = map[string]interface{}{"from": bv, "to": av}
+		}
+	}
+	for k, bv := range bm {
+		if _, ok := am[k]; !ok && !auditIgnoredFields[k] {
+			diff[k] = map[string]interface{}{"from": bv, "to": nil}
+		}
+	}
+	return diff
+}
+
+func marshalSnapshot(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// RecordAudit lưu 1 dòng audit với snapshot trước/sau và diff (chỉ khi có cả 2 snapshot)
+func RecordAudit(entry *models.AuditLog, before, after interface{}) {
+	entry.Before = marshalSnapshot(before)
+	entry.After = marshalSnapshot(after)
+	if entry.Before != nil && entry.After != nil {
+		if diff := auditDiff(entry.Before, entry.After); len(diff) > 0 {
+			entry.Diff = marshalSnapshot(diff)
+		}
+	}
+	if err := repository.CreateAuditLog(entry); err != nil {
+		log.Printf("Write audit log %s failed: %v", entry.Action, err)
+	}
+}
+
+// AuditRetention: giữ audit log bao lâu (AUDIT_RETENTION_DAYS, mặc định 365 ngày, 0 = giữ vĩnh viễn)
+func AuditRetention() time.Duration {
+	days := 365
+	if d, err := strconv.Atoi(os.Getenv("AUDIT_RETENTION_DAYS")); err == nil && d >= 0 {
+		days = d
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// StartAuditCleanupJob xoá audit log quá hạn lưu giữ (chạy mỗi ngày)
+func StartAuditCleanupJob() {
+	cleanup := func() {
+		retention := AuditRetention()
+		if retention == 0 {
+			return
+		}
+		n, err := repository.CleanupAuditLogs(time.Now().Add(-retention))
+		if err != nil {
+			log.Println("Audit log cleanup failed:", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("Audit log cleanup: removed %d entries", n)
+		}
+	}
+
+	go func() {
+		cleanup()
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			cleanup()
+		}
+	}()
+}
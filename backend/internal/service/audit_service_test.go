@@ -0,0 +1,83 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResponseID(t *testing.T) {
+	cases := []struct {
+		body string
+		want string
+	}{
+		{`{"id": 12, "name": "Áo"}`, "12"},
+		{`{"product": {"id": 5, "name": "Áo"}}`, "5"},
+		{`{"message": "ok", "review_reply": {"id": 9}}`, "9"},
+		{`{"a": {"id": 1}, "b": {"id": 2}}`, ""}, // nhiều object có id, không đoán
+		{`{"id": "abc"}`, ""},
+		{`{"message": "ok"}`, ""},
+		{`[{"id": 1}]`, ""},
+		{`not json`, ""},
+		{``, ""},
+	}
+	for _, c := range cases {
+		if got := ResponseID([]byte(c.body)); got != c.want {
+			t.Errorf("ResponseID(%s) = %q, want %q", c.body, got, c.want)
+		}
+	}
+}
+
+func TestAuditDiff(t *testing.T) {
+	change := func(from, to interface{}) map[string]interface{} {
+		return map[string]interface{}{"from": from, "to": to}
+	}
+	cases := []struct {
+		name          string
+		before, after string
+		want          map[string]interface{}
+	}{
+		{
+			name:   "changed, added and removed fields",
+			before: `{"id": 1, "name": "Áo", "price": 100, "note": "x"}`,
+			after:  `{"id": 1, "name": "Áo thun", "price": 100, "stock": 3}`,
+			want: map[string]interface{}{
+				"name":  change("Áo", "Áo thun"),
+				"stock": change(nil, float64(3)),
+				"note":  change("x", nil),
+			},
+		},
+		{
+			name:   "updated_at ignored",
+			before: `{"id": 1, "updated_at": "2024-01-01T00:00:00Z"}`,
+			after:  `{"id": 1, "updated_at": "2024-02-01T00:00:00Z"}`,
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "lists compared as a whole",
+			before: `["orders.read"]`,
+			after:  `["orders.read", "orders.write"]`,
+			want: map[string]interface{}{
+				"value": change([]interface{}{"orders.read"}, []interface{}{"orders.read", "orders.write"}),
+			},
+		},
+		{
+			name:   "same list",
+			before: `["a"]`,
+			after:  `["a"]`,
+			want:   nil,
+		},
+	}
+	for _, c := range cases {
+		got := auditDiff([]byte(c.before), []byte(c.after))
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: diff = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestNewAuditRouteMessages(t *testing.T) {
+	rt := NewAuditRoute("PATCH", "/messages/{customerId:[0-9]+}/read")
+	if rt.Action != "conversation.read.update" || rt.IDVar != "customerId" || rt.Entity.Load != nil {
+		t.Errorf("unexpected route %+v", rt)
+	}
+}
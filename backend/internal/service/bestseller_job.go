@@ -0,0 +1,22 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	repo "backend/internal/repository/customer"
+)
+
+// StartBestSellerJob làm mới cache best-seller/trending theo chu kỳ TTL của cache
+func StartBestSellerJob() {
+	go func() {
+		ticker := time.NewTicker(repo.BestSellerCacheTTL())
+		defer ticker.Stop()
+		for {
+			if err := repo.RefreshBestSellerCache(); err != nil {
+				log.Println("Refresh best-seller cache failed:", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
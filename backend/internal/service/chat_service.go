@@ -15,6 +15,7 @@ import (
 	openai "github.com/sashabaranov/go-openai"
 	"backend/internal/models"
 	repo "backend/internal/repository/customer"
+	"backend/internal/search"
 )
 
 // --- DTOs ---
@@ -161,6 +162,7 @@ func (s *ChatService) HandleChat(req ChatRequest) (*ChatResponse, error) {
 			return &ChatResponse{Reply: reply}, nil
 		case "search":
 			products, _ := s.repo.FindProducts(intent.Category, intent.Keywords, intent.PriceMin, intent.PriceMax)
+			logChatSearch(intent.Category, intent.Keywords, len(products))
 			reply := natural
 			if reply == "" {
 				reply = generateNaturalReply(intent, products)
@@ -284,6 +286,7 @@ func (s *ChatService) handleLocally(req ChatRequest) (*ChatResponse, error) {
 	keywords := extractKeywords(req.Message)
 
 	products, _ := s.repo.FindProducts(category, keywords, min, max)
+	logChatSearch(category, keywords, len(products))
 	if len(products) > 0 {
 		reply := generateNaturalReply(IntentResult{Intent: "search", Category: category, Keywords: keywords, PriceMin: min, PriceMax: max}, products)
 		return &ChatResponse{Reply: reply, Products: products}, nil
@@ -292,6 +295,12 @@ func (s *ChatService) handleLocally(req ChatRequest) (*ChatResponse, error) {
 	return &ChatResponse{Reply: "Mình chưa hiểu rõ câu hỏi. Bạn có thể hỏi về giờ mở cửa hoặc tìm sản phẩm theo tên, loại, giá."}, nil
 }
 
+// logChatSearch ghi intent tìm kiếm của chatbot vào search log
+func logChatSearch(category string, keywords []string, results int) {
+	query := strings.TrimSpace(category + " " + strings.Join(keywords, " "))
+	search.LogQuery(models.SearchSourceChatbot, query, results)
+}
+
 // --- Price detection ---
 func detectPriceRange(msg string) (float64, float64) {
 	re := regexp.MustCompile(`([0-9]+(?:[\.,][0-9]+)?)\s?(k|nghìn|tr|triệu|m)?`)
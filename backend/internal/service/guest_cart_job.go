@@ -0,0 +1,30 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	repo "backend/internal/repository/customer"
+)
+
+// StartGuestCartCleanupJob trả stock của giỏ khách vãng lai hết hạn (chạy mỗi 30 phút)
+func StartGuestCartCleanupJob() {
+	cleanup := func() {
+		released, err := repo.CleanupExpiredGuestSessions()
+		if err != nil {
+			log.Println("Guest cart cleanup failed:", err)
+		}
+		if len(released) > 0 {
+			NotifyBackInStock(released...)
+		}
+	}
+
+	go func() {
+		cleanup()
+		ticker := time.NewTicker(30 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			cleanup()
+		}
+	}()
+}
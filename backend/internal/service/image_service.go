@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	_ "image/gif"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	thumbnailWidth = 300
+	webpMaxWidth   = 1200
+	// ảnh giải nén chiếm width*height*4 byte RAM: file vài MB có thể khai báo kích thước rất lớn
+	maxImageWidth  = 8000
+	maxImageHeight = 8000
+)
+
+var (
+	ErrFileTooLarge     = errors.New("file too large")
+	ErrUnsupportedImage = errors.New("unsupported image type")
+	ErrImageTooLarge    = errors.New("image dimensions too large")
+)
+
+// MIME được phép upload -> phần mở rộng file
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// UploadedImage: URL của ảnh gốc, thumbnail và bản WebP
+type UploadedImage struct {
+	Key          string `json:"key"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	WebPURL      string `json:"webp_url"`
+	ContentType  string `json:"content_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Size         int    `json:"size"`
+}
+
+// MaxUploadBytes đọc UPLOAD_MAX_MB (mặc định 5MB)
+func MaxUploadBytes() int64 {
+	mb, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_MB"))
+	if err != nil || mb <= 0 {
+		mb = 5
+	}
+	return int64(mb) << 20
+}
+
+// SaveImage kiểm tra MIME/size, lưu ảnh gốc + thumbnail + WebP vào storage.
+// folder ví dụ "products", "variants", "messages".
+func SaveImage(ctx context.Context, store Storage, folder string, data []byte) (*UploadedImage, error) {
+	if int64(len(data)) > MaxUploadBytes() {
+		return nil, ErrFileTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+	// đọc kích thước từ header trước khi giải nén cả ảnh
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width > maxImageWidth || cfg.Height > maxImageHeight {
+		return nil, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	base := path.Join(folder, time.Now().Format("2006/01"), randomName())
+	res := &UploadedImage{
+		Key:         base + ext,
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Size:        len(data),
+	}
+
+	if res.URL, err = store.Put(ctx, res.Key, data, contentType); err != nil {
+		return nil, err
+	}
+	// lỗi ở bước thumbnail/WebP: xoá ảnh gốc (và bản đã sinh) để không bỏ sót file mồ côi
+	done := false
+	defer func() {
+		if !done {
+			DeleteImage(context.WithoutCancel(ctx), store, res.Key)
+		}
+	}()
+
+	// Thumbnail: PNG giữ PNG (có alpha), còn lại dùng JPEG
+	thumb := resizeToWidth(img, thumbnailWidth)
+	var buf bytes.Buffer
+	thumbKey, thumbType := base+"_thumb.jpg", "image/jpeg"
+	if contentType == "image/png" {
+		thumbKey, thumbType = base+"_thumb.png", "image/png"
+		err = png.Encode(&buf, thumb)
+	} else {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode thumbnail: %w", err)
+	}
+	if res.ThumbnailURL, err = store.Put(ctx, thumbKey, buf.Bytes(), thumbType); err != nil {
+		return nil, err
+	}
+
+	if contentType == "image/webp" {
+		res.WebPURL = res.URL
+		done = true
+		return res, nil
+	}
+	buf.Reset()
+	if err := nativewebp.Encode(&buf, resizeToWidth(img, webpMaxWidth), nil); err != nil {
+		return nil, fmt.Errorf("encode webp: %w", err)
+	}
+	if res.WebPURL, err = store.Put(ctx, base+".webp", buf.Bytes(), "image/webp"); err != nil {
+		return nil, err
+	}
+	done = true
+	return res, nil
+}
+
+// DeleteImage xoá ảnh gốc cùng các bản thumbnail/WebP sinh ra từ key
+func DeleteImage(ctx context.Context, store Storage, key string) error {
+	ext := path.Ext(key)
+	base := key[:len(key)-len(ext)]
+	var firstErr error
+	for _, k := range []string{key, base + "_thumb.jpg", base + "_thumb.png", base + ".webp"} {
+		if err := store.Delete(ctx, k); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// resizeToWidth thu nhỏ giữ tỉ lệ, ảnh nhỏ hơn width thì giữ nguyên
+func resizeToWidth(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	if b.Dx() <= width {
+		return src
+	}
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
+
+func randomName() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// ReadUploadedFile đọc file multipart, giới hạn theo MaxUploadBytes
+func ReadUploadedFile(fh *multipart.FileHeader) ([]byte, error) {
+	if fh.Size > MaxUploadBytes() {
+		return nil, ErrFileTooLarge
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, MaxUploadBytes()+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > MaxUploadBytes() {
+		return nil, ErrFileTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 giả lập bucket S3 (path-style): ghi nhận PUT/DELETE, failSuffix = key kết thúc bằng đuôi này thì trả 500
+type fakeS3 struct {
+	mu         sync.Mutex
+	objects    map[string][]byte
+	deleted    []string
+	failSuffix string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *S3Storage) {
+	t.Helper()
+	f := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	store := NewS3Storage(S3Config{
+		Endpoint:  srv.URL,
+		Bucket:    "shop",
+		AccessKey: "AKIDTEST",
+		SecretKey: "secret",
+	})
+	return f, store
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDTEST/") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		http.Error(w, "missing signature", http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/shop/")
+	if !ok {
+		http.Error(w, "no such bucket", http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		if f.failSuffix != "" && strings.HasSuffix(key, f.failSuffix) {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case http.MethodDelete:
+		delete(f.objects, key)
+		f.deleted = append(f.deleted, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSaveImageStoresVariantsOnS3(t *testing.T) {
+	f, store := newFakeS3(t)
+
+	res, err := SaveImage(context.Background(), store, "products", testPNG(t, 640, 480))
+	if err != nil {
+		t.Fatalf("SaveImage: %v", err)
+	}
+	if res.Width != 640 || res.Height != 480 || res.ContentType != "image/png" {
+		t.Errorf("unexpected result %+v", res)
+	}
+	base := strings.TrimSuffix(res.Key, ".png")
+	for _, key := range []string{res.Key, base + "_thumb.png", base + ".webp"} {
+		if _, ok := f.objects[key]; !ok {
+			t.Errorf("object %s not stored, have %v", key, f.keys())
+		}
+	}
+	if !strings.HasPrefix(res.URL, store.cfg.PublicURL+"/products/") {
+		t.Errorf("URL %s not under public URL", res.URL)
+	}
+
+	if err := DeleteImage(context.Background(), store, res.Key); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	if keys := f.keys(); len(keys) != 0 {
+		t.Errorf("objects left after delete: %v", keys)
+	}
+}
+
+func TestSaveImageRejectsHugeDimensions(t *testing.T) {
+	f, store := newFakeS3(t)
+
+	_, err := SaveImage(context.Background(), store, "products", testPNG(t, maxImageWidth+1, 1))
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("err = %v, want ErrImageTooLarge", err)
+	}
+	if keys := f.keys(); len(keys) != 0 {
+		t.Errorf("objects stored for rejected image: %v", keys)
+	}
+}
+
+func TestSaveImageRemovesOriginalWhenVariantFails(t *testing.T) {
+	f, store := newFakeS3(t)
+	f.failSuffix = ".webp"
+
+	if _, err := SaveImage(context.Background(), store, "products", testPNG(t, 64, 64)); err == nil {
+		t.Fatal("SaveImage succeeded although the WebP upload failed")
+	}
+	if keys := f.keys(); len(keys) != 0 {
+		t.Errorf("orphaned objects left behind: %v", keys)
+	}
+	if len(f.deleted) == 0 {
+		t.Error("no cleanup DELETE sent")
+	}
+}
+
+func TestUploadFileSystemHidesDirectories(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStorage(dir, "/uploads")
+	if _, err := store.Put(context.Background(), "products/2026/10/a.png", testPNG(t, 4, 4), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	srv := http.StripPrefix("/uploads/", http.FileServer(UploadFileSystem(dir)))
+
+	for path, want := range map[string]int{
+		"/uploads/products/2026/10/a.png": http.StatusOK,
+		"/uploads/products/2026/10/":      http.StatusNotFound,
+		"/uploads/products/":              http.StatusNotFound,
+		"/uploads/":                       http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
@@ -1,38 +1,53 @@
 package service
 
 import (
+	"backend/internal/models"
 	"os"
+	"strconv"
 	"time"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
+// Claims: access token của phiên đăng nhập
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
-	jwt.RegisteredClaims
+	UserID       uint   `json:"user_id"`
+	Role         string `json:"role"`
+	Username     string `json:"username,omitempty"`
+	TokenVersion uint   `json:"ver"`
+	TokenMeta
+}
+
+// AccessTokenTTL: access token sống ngắn (ACCESS_TOKEN_TTL_MINUTES, mặc định 15 phút), gia hạn qua /api/auth/refresh
+func AccessTokenTTL() time.Duration {
+	if m, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL_MINUTES")); err == nil && m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return 15 * time.Minute
 }
 
-func GenerateToken(userID uint, role string) (string, error) {
-	claims := Claims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// RefreshTokenTTL: REFRESH_TOKEN_TTL_DAYS, mặc định 30 ngày
+func RefreshTokenTTL() time.Duration {
+	if d, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL_DAYS")); err == nil && d > 0 {
+		return time.Duration(d) * 24 * time.Hour
 	}
+	return 30 * 24 * time.Hour
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+func GenerateToken(user *models.User) (string, error) {
+	claims := &Claims{
+		UserID:       user.ID,
+		Role:         user.Role,
+		Username:     user.Username,
+		TokenVersion: user.TokenVersion,
+	}
+	claims.Subject = strconv.FormatUint(uint64(user.ID), 10)
+	return issueToken(PurposeAccess, AccessTokenTTL(), claims)
 }
 
+// ParseToken chỉ nhận access token (token xác nhận email hay loại khác bị từ chối)
 func ParseToken(tokenStr string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-	if err != nil {
+	claims := &Claims{}
+	if err := parseToken(PurposeAccess, tokenStr, claims); err != nil {
 		return nil, err
 	}
-	return token.Claims.(*Claims), nil
+	return claims, nil
 }
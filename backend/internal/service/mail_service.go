@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/smtp"
 	"os"
+	"time"
 )
 
 func SendConfirmationEmail(toEmail, confirmLink string) error {
@@ -58,3 +59,101 @@ func SendConfirmationEmail(toEmail, confirmLink string) error {
 
 	return nil
 }
+
+func SendPasswordResetEmail(toEmail, resetLink string, ttl time.Duration) error {
+	from := os.Getenv("EMAIL_USER")
+	pass := os.Getenv("EMAIL_PASS")
+	host := os.Getenv("EMAIL_HOST")
+	port := os.Getenv("EMAIL_PORT")
+
+	auth := smtp.PlainAuth("", from, pass, host)
+
+	subject := "Subject: 🔑 Đặt lại mật khẩu\n"
+
+	body := fmt.Sprintf(`
+	<html>
+		<body style="font-family: Arial, sans-serif; line-height: 1.5;">
+			<h2>Yêu cầu đặt lại mật khẩu</h2>
+			<p>Chúng tôi nhận được yêu cầu đặt lại mật khẩu cho tài khoản của bạn. Link dưới đây chỉ dùng được một lần và hết hạn sau %d phút:</p>
+			<p style="text-align:center;">
+				<a href="%s" style="
+					background-color: #007bff;
+					color: white;
+					padding: 12px 24px;
+					text-decoration: none;
+					border-radius: 6px;
+					font-weight: bold;
+				">Đặt lại mật khẩu</a>
+			</p>
+			<p>Nếu bạn không yêu cầu, vui lòng bỏ qua email này, mật khẩu sẽ không thay đổi.</p>
+			<hr>
+			<p style="font-size:12px; color: gray;">© 2025 Cửa hàng của chúng tôi. Bảo lưu mọi quyền.</p>
+		</body>
+	</html>
+	`, int(ttl.Minutes()), resetLink)
+
+	msg := []byte(
+		"From: " + from + "\n" +
+			"To: " + toEmail + "\n" +
+			subject +
+			"MIME-Version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n" +
+			body,
+	)
+
+	addr := fmt.Sprintf("%s:%s", host, port)
+	if err := smtp.SendMail(addr, auth, from, []string{toEmail}, msg); err != nil {
+		log.Println("Email error:", err)
+		return err
+	}
+
+	return nil
+}
+
+func SendEmailChangeEmail(toEmail, confirmLink string, ttl time.Duration) error {
+	from := os.Getenv("EMAIL_USER")
+	pass := os.Getenv("EMAIL_PASS")
+	host := os.Getenv("EMAIL_HOST")
+	port := os.Getenv("EMAIL_PORT")
+
+	auth := smtp.PlainAuth("", from, pass, host)
+
+	subject := "Subject: ✉️ Xác nhận đổi email\n"
+
+	body := fmt.Sprintf(`
+	<html>
+		<body style="font-family: Arial, sans-serif; line-height: 1.5;">
+			<h2>Xác nhận địa chỉ email mới</h2>
+			<p>Tài khoản của bạn vừa yêu cầu đổi email sang địa chỉ này. Nhấn nút dưới đây để xác nhận (link hết hạn sau %d giờ):</p>
+			<p style="text-align:center;">
+				<a href="%s" style="
+					background-color: #28a745;
+					color: white;
+					padding: 12px 24px;
+					text-decoration: none;
+					border-radius: 6px;
+					font-weight: bold;
+				">Xác nhận email mới</a>
+			</p>
+			<p>Nếu bạn không yêu cầu, vui lòng bỏ qua email này, email đăng nhập sẽ không thay đổi.</p>
+			<hr>
+			<p style="font-size:12px; color: gray;">© 2025 Cửa hàng của chúng tôi. Bảo lưu mọi quyền.</p>
+		</body>
+	</html>
+	`, int(ttl.Hours()), confirmLink)
+
+	msg := []byte(
+		"From: " + from + "\n" +
+			"To: " + toEmail + "\n" +
+			subject +
+			"MIME-Version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n" +
+			body,
+	)
+
+	addr := fmt.Sprintf("%s:%s", host, port)
+	if err := smtp.SendMail(addr, auth, from, []string{toEmail}, msg); err != nil {
+		log.Println("Email error:", err)
+		return err
+	}
+
+	return nil
+}
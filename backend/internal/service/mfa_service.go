@@ -0,0 +1,224 @@
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMFAInvalidCode      = errors.New("invalid MFA code")
+	ErrMFANotEnabled       = errors.New("MFA is not enabled")
+	ErrMFAAlreadyEnabled   = errors.New("MFA is already enabled")
+	ErrMFANotStarted       = errors.New("MFA setup has not been started")
+	ErrMFARequiredByPolicy = errors.New("MFA is required for this role")
+	ErrMFAChallenge        = errors.New("invalid or expired MFA token")
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+// MFAClaims: token tạm sau khi nhập đúng mật khẩu; Enroll = bắt buộc đăng ký MFA trước khi vào
+type MFAClaims struct {
+	UserID       uint `json:"user_id"`
+	TokenVersion uint `json:"ver"`
+	Enroll       bool `json:"enroll,omitempty"`
+	TokenMeta
+}
+
+func GenerateMFAToken(user *models.User, enroll bool) (string, error) {
+	claims := &MFAClaims{UserID: user.ID, TokenVersion: user.TokenVersion, Enroll: enroll}
+	claims.Subject = strconv.FormatUint(uint64(user.ID), 10)
+	return issueToken(PurposeMFA, mfaChallengeTTL, claims)
+}
+
+// ParseMFAToken trả về user của token, token cũ (đổi mật khẩu, đổi role...) bị từ chối
+func ParseMFAToken(tokenStr string) (*models.User, *MFAClaims, error) {
+	claims := &MFAClaims{}
+	if err := parseToken(PurposeMFA, tokenStr, claims); err != nil {
+		return nil, nil, ErrMFAChallenge
+	}
+	user, err := repository.GetUserByID(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion {
+		return nil, nil, ErrMFAChallenge
+	}
+	return user, claims, nil
+}
+
+func mfaIssuer() string {
+	if v := os.Getenv("MFA_ISSUER"); v != "" {
+		return v
+	}
+	return "Clothing App"
+}
+
+// MFAEnabled: user đã bật MFA (đã xác nhận mã đầu tiên)
+func MFAEnabled(userID uint) (bool, error) {
+	m, err := repository.GetUserMFA(userID)
+	if err != nil {
+		return false, err
+	}
+	return m != nil && m.EnabledAt != nil, nil
+}
+
+// BeginMFAEnrollment sinh secret mới, trả về secret và otpauth URI để hiển thị QR
+func BeginMFAEnrollment(user *models.User) (string, string, error) {
+	if enabled, err := MFAEnabled(user.ID); err != nil {
+		return "", "", err
+	} else if enabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	encrypted, err := utils.EncryptSecret(secret)
+	if err != nil {
+		return "", "", err
+	}
+	if err := repository.SavePendingMFA(user.ID, encrypted); err != nil {
+		return "", "", err
+	}
+	return secret, utils.TOTPProvisioningURI(mfaIssuer(), user.Email, secret), nil
+}
+
+// ActivateMFA xác nhận mã đầu tiên từ app, bật MFA và trả về mã dự phòng (chỉ hiển thị một lần)
+func ActivateMFA(user *models.User, code string) ([]string, error) {
+	m, err := repository.GetUserMFA(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrMFANotStarted
+	}
+	if m.EnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	secret, err := utils.DecryptSecret(m.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := utils.VerifyTOTP(secret, code, time.Now(), 1)
+	if !ok {
+		return nil, ErrMFAInvalidCode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.EnableMFA(user.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+var totpCodeRe = regexp.MustCompile(`^\d{6}$`)
+
+// VerifyMFACode nhận mã TOTP 6 số hoặc mã dự phòng
+func VerifyMFACode(userID uint, code string) error {
+	m, err := repository.GetUserMFA(userID)
+	if err != nil {
+		return err
+	}
+	if m == nil || m.EnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if totpCodeRe.MatchString(code) {
+		secret, err := utils.DecryptSecret(m.Secret)
+		if err != nil {
+			return err
+		}
+		step, ok := utils.VerifyTOTP(secret, code, time.Now(), 1)
+		if !ok {
+			return ErrMFAInvalidCode
+		}
+		claimed, err := repository.ClaimTOTPStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrMFAInvalidCode
+		}
+		return nil
+	}
+	used, err := repository.UseRecoveryCode(userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrMFAInvalidCode
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes cấp bộ mã dự phòng mới (mã cũ hết hiệu lực), cần mã MFA hợp lệ
+func RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	if err := VerifyMFACode(userID, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA tắt MFA (không cho tắt nếu role bắt buộc MFA)
+func DisableMFA(user *models.User, code string) error {
+	required, err := repository.IsMFARequired(user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredByPolicy
+	}
+	if err := VerifyMFACode(user.ID, code); err != nil {
+		return err
+	}
+	return repository.DisableMFA(user.ID)
+}
+
+// mã dự phòng dạng "xxxxx-xxxxx" (base32 chữ thường)
+func newRecoveryCodes() ([]string, []string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var sb strings.Builder
+		for j, b := range buf {
+			if j == 5 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(alphabet[int(b)%len(alphabet)])
+		}
+		code := sb.String()
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
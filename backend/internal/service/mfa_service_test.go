@@ -0,0 +1,37 @@
+package service
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestHashRecoveryCodeNormalises(t *testing.T) {
+	want := hashRecoveryCode("abcdefghij")
+	for _, input := range []string{"ABCDE-fghij", "abcde-fghij", "abcde fghij", "ABCDEFGHIJ"} {
+		if got := hashRecoveryCode(input); got != want {
+			t.Errorf("hashRecoveryCode(%q) differs from abcdefghij", input)
+		}
+	}
+	if hashRecoveryCode("abcdefghik") == want {
+		t.Error("different codes hash the same")
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("got %d codes, %d hashes", len(codes), len(hashes))
+	}
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	for i, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q has wrong format", code)
+		}
+		if hashes[i] != hashRecoveryCode(code) {
+			t.Errorf("hash of %q does not match", code)
+		}
+	}
+}
@@ -0,0 +1,538 @@
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/utils"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	PurposeOIDCState = "oidc_state" // state gửi sang provider, mang nonce + PKCE verifier
+	oidcStateTTL     = 10 * time.Minute
+)
+
+var (
+	ErrOIDCProvider = errors.New("unknown login provider")
+	ErrOIDCState    = errors.New("invalid or expired login state")
+	ErrOIDCToken    = errors.New("invalid token from provider")
+)
+
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// OIDCProvider cấu hình qua env, ví dụ OIDC_PROVIDERS=google,facebook rồi với mỗi tên:
+//
+//	OIDC_GOOGLE_ISSUER, OIDC_GOOGLE_CLIENT_ID, OIDC_GOOGLE_CLIENT_SECRET, OIDC_GOOGLE_DISPLAY_NAME
+//	OIDC_GOOGLE_REDIRECT_URL (mặc định BACKEND_URL/api/auth/oidc/google/callback), OIDC_GOOGLE_SCOPES
+//	OIDC_GOOGLE_AUTH_URL / TOKEN_URL / USERINFO_URL / JWKS_URL: ghi đè discovery, bắt buộc với provider
+//	không có /.well-known/openid-configuration (kiểu Facebook: chỉ OAuth2 + userinfo)
+//	OIDC_GOOGLE_TRUST_EMAIL=true: coi email từ userinfo là đã xác minh khi provider không trả email_verified
+//	(mặc định false: chỉ bật khi chắc provider đã xác minh email, nếu không email giả có thể chiếm tài khoản)
+type OIDCProvider struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+	TrustEmail   bool
+}
+
+// OIDCIdentity: thông tin người dùng lấy được từ provider sau khi xác thực
+type OIDCIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+}
+
+func oidcEnv(name, key string) string {
+	return strings.TrimSpace(os.Getenv("OIDC_" + strings.ToUpper(name) + "_" + key))
+}
+
+// OIDCProviders: các provider đã cấu hình đủ client id
+func OIDCProviders() []OIDCProvider {
+	var list []OIDCProvider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if p, err := GetOIDCProvider(name); err == nil {
+			list = append(list, *p)
+		}
+	}
+	return list
+}
+
+func GetOIDCProvider(name string) (*OIDCProvider, error) {
+	name = strings.ToLower(name)
+	enabled := false
+	for _, n := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		if strings.EqualFold(strings.TrimSpace(n), name) {
+			enabled = true
+		}
+	}
+	if !enabled || oidcEnv(name, "CLIENT_ID") == "" {
+		return nil, ErrOIDCProvider
+	}
+	p := &OIDCProvider{
+		Name:         name,
+		DisplayName:  oidcEnv(name, "DISPLAY_NAME"),
+		Issuer:       strings.TrimRight(oidcEnv(name, "ISSUER"), "/"),
+		ClientID:     oidcEnv(name, "CLIENT_ID"),
+		ClientSecret: oidcEnv(name, "CLIENT_SECRET"),
+		RedirectURL:  oidcEnv(name, "REDIRECT_URL"),
+		AuthURL:      oidcEnv(name, "AUTH_URL"),
+		TokenURL:     oidcEnv(name, "TOKEN_URL"),
+		UserInfoURL:  oidcEnv(name, "USERINFO_URL"),
+		JWKSURL:      oidcEnv(name, "JWKS_URL"),
+		TrustEmail:   oidcEnv(name, "TRUST_EMAIL") == "true",
+	}
+	if p.DisplayName == "" {
+		p.DisplayName = strings.ToUpper(name[:1]) + name[1:]
+	}
+	if p.RedirectURL == "" {
+		p.RedirectURL = os.Getenv("BACKEND_URL") + "/api/auth/oidc/" + name + "/callback"
+	}
+	if s := oidcEnv(name, "SCOPES"); s != "" {
+		p.Scopes = strings.Fields(strings.ReplaceAll(s, ",", " "))
+	} else {
+		p.Scopes = []string{"openid", "email", "profile"}
+	}
+	if p.Issuer != "" && (p.AuthURL == "" || p.TokenURL == "") {
+		doc, err := discoverOIDC(p.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		if p.AuthURL == "" {
+			p.AuthURL = doc.AuthorizationEndpoint
+		}
+		if p.TokenURL == "" {
+			p.TokenURL = doc.TokenEndpoint
+		}
+		if p.UserInfoURL == "" {
+			p.UserInfoURL = doc.UserinfoEndpoint
+		}
+		if p.JWKSURL == "" {
+			p.JWKSURL = doc.JWKSURI
+		}
+	}
+	if p.AuthURL == "" || p.TokenURL == "" {
+		return nil, ErrOIDCProvider
+	}
+	return p, nil
+}
+
+// ================= DISCOVERY + JWKS (cache trong bộ nhớ) =================
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jwksEntry struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+var (
+	oidcCacheMu     sync.Mutex
+	oidcDiscoveries = map[string]*oidcDiscovery{}
+	oidcJWKS        = map[string]*jwksEntry{}
+)
+
+func getJSON(rawURL string, out interface{}) error {
+	res, err := oidcHTTPClient.Get(rawURL)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", rawURL, res.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(out)
+}
+
+func discoverOIDC(issuer string) (*oidcDiscovery, error) {
+	oidcCacheMu.Lock()
+	doc, ok := oidcDiscoveries[issuer]
+	oidcCacheMu.Unlock()
+	if ok {
+		return doc, nil
+	}
+	doc = &oidcDiscovery{}
+	if err := getJSON(issuer+"/.well-known/openid-configuration", doc); err != nil {
+		return nil, err
+	}
+	if strings.TrimRight(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery issuer mismatch: %s", doc.Issuer)
+	}
+	oidcCacheMu.Lock()
+	oidcDiscoveries[issuer] = doc
+	oidcCacheMu.Unlock()
+	return doc, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	dec := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := dec(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := dec(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := dec(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// jwksKey lấy public key theo kid; kid lạ thì tải lại JWKS (provider xoay key), tối đa 1 lần/phút
+func jwksKey(jwksURL, kid string) (crypto.PublicKey, error) {
+	oidcCacheMu.Lock()
+	entry := oidcJWKS[jwksURL]
+	oidcCacheMu.Unlock()
+	if entry != nil {
+		if key, ok := entry.keys[kid]; ok {
+			return key, nil
+		}
+		if time.Since(entry.fetchedAt) < time.Minute {
+			return nil, ErrTokenKey
+		}
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(jwksURL, &set); err != nil {
+		return nil, err
+	}
+	entry = &jwksEntry{keys: map[string]crypto.PublicKey{}, fetchedAt: time.Now()}
+	for _, k := range set.Keys {
+		if key, err := k.publicKey(); err == nil {
+			entry.keys[k.Kid] = key
+		}
+	}
+	oidcCacheMu.Lock()
+	oidcJWKS[jwksURL] = entry
+	oidcCacheMu.Unlock()
+
+	if key, ok := entry.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrTokenKey
+}
+
+// ================= LOGIN FLOW =================
+
+// OIDCStateClaims: state ký bằng key riêng, cookie trình duyệt giữ bản sao để chống login CSRF
+type OIDCStateClaims struct {
+	Provider   string `json:"prv"`
+	Nonce      string `json:"nonce"`
+	Verifier   string `json:"cv"`
+	GuestToken string `json:"gt,omitempty"`
+	TokenMeta
+}
+
+// OIDCAuthURL trả về URL chuyển hướng sang provider và state (cũng là giá trị cookie)
+func OIDCAuthURL(p *OIDCProvider, guestToken string) (string, string, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+	state, err := issueToken(PurposeOIDCState, oidcStateTTL, &OIDCStateClaims{
+		Provider:   p.Name,
+		Nonce:      nonce,
+		Verifier:   verifier,
+		GuestToken: guestToken,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.AuthURL + sep + q.Encode(), state, nil
+}
+
+// ParseOIDCState kiểm tra state trả về từ provider khớp provider đang callback
+func ParseOIDCState(p *OIDCProvider, state string) (*OIDCStateClaims, error) {
+	claims := &OIDCStateClaims{}
+	if err := parseToken(PurposeOIDCState, state, claims); err != nil || claims.Provider != p.Name {
+		return nil, ErrOIDCState
+	}
+	return claims, nil
+}
+
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	Error       string `json:"error"`
+}
+
+func exchangeOIDCCode(p *OIDCProvider, code, verifier string) (*oidcTokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	res, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var tok oidcTokenResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&tok); err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK || tok.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: status %d %s", res.StatusCode, tok.Error)
+	}
+	return &tok, nil
+}
+
+// oidcBool: email_verified có provider trả bool, có provider trả chuỗi "true"
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	*b = oidcBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+type oidcProfile struct {
+	Subject           string    `json:"sub"`
+	ID                string    `json:"id"` // kiểu Facebook Graph API
+	Email             string    `json:"email"`
+	EmailVerified     *oidcBool `json:"email_verified"`
+	Name              string    `json:"name"`
+	PreferredUsername string    `json:"preferred_username"`
+}
+
+// oidcIDClaims: không nhúng oidcProfile vì trùng tag "sub" với RegisteredClaims (encoding/json sẽ bỏ cả hai)
+type oidcIDClaims struct {
+	Nonce             string    `json:"nonce"`
+	Email             string    `json:"email"`
+	EmailVerified     *oidcBool `json:"email_verified"`
+	Name              string    `json:"name"`
+	PreferredUsername string    `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+func (c *oidcIDClaims) profile() *oidcProfile {
+	return &oidcProfile{
+		Subject:           c.Subject,
+		Email:             c.Email,
+		EmailVerified:     c.EmailVerified,
+		Name:              c.Name,
+		PreferredUsername: c.PreferredUsername,
+	}
+}
+
+func verifyIDToken(p *OIDCProvider, raw, nonce string) (*oidcIDClaims, error) {
+	if p.JWKSURL == "" {
+		return nil, ErrOIDCToken
+	}
+	claims := &oidcIDClaims{}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	}
+	if p.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(p.Issuer))
+	}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return jwksKey(p.JWKSURL, kid)
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Nonce != nonce {
+		return nil, ErrOIDCToken
+	}
+	return claims, nil
+}
+
+func fetchUserInfo(p *OIDCProvider, accessToken string) (*oidcProfile, error) {
+	req, err := http.NewRequest(http.MethodGet, p.UserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	res, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo failed: status %d", res.StatusCode)
+	}
+	var profile oidcProfile
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&profile); err != nil {
+		return nil, err
+	}
+	if profile.Subject == "" {
+		profile.Subject = profile.ID
+	}
+	return &profile, nil
+}
+
+// ExchangeOIDCCode đổi code lấy danh tính: ưu tiên id_token (verify chữ ký qua JWKS + nonce),
+// provider chỉ có OAuth2 thì dùng userinfo
+func ExchangeOIDCCode(p *OIDCProvider, code string, state *OIDCStateClaims) (*OIDCIdentity, error) {
+	tok, err := exchangeOIDCCode(p, code, state.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile *oidcProfile
+	if tok.IDToken != "" {
+		claims, err := verifyIDToken(p, tok.IDToken, state.Nonce)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrOIDCToken, err)
+		}
+		profile = claims.profile()
+		// id_token tối giản (không có email) thì bổ sung từ userinfo, sub phải trùng
+		if profile.Email == "" && p.UserInfoURL != "" && tok.AccessToken != "" {
+			if info, err := fetchUserInfo(p, tok.AccessToken); err == nil && info.Subject == profile.Subject {
+				profile = info
+			}
+		}
+	} else if p.UserInfoURL != "" && tok.AccessToken != "" {
+		if profile, err = fetchUserInfo(p, tok.AccessToken); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, ErrOIDCToken
+	}
+	if profile.Subject == "" {
+		return nil, ErrOIDCToken
+	}
+
+	verified := p.TrustEmail
+	if profile.EmailVerified != nil {
+		verified = bool(*profile.EmailVerified)
+	}
+	username := profile.PreferredUsername
+	if username == "" {
+		username, _, _ = strings.Cut(profile.Email, "@")
+	}
+	return &OIDCIdentity{
+		Provider:      p.Name,
+		Subject:       profile.Subject,
+		Email:         profile.Email,
+		EmailVerified: verified,
+		Name:          profile.Name,
+		Username:      username,
+	}, nil
+}
+
+// ResolveOIDCUser liên kết identity với user có sẵn (cùng email đã xác minh) hoặc tạo user mới
+func ResolveOIDCUser(id *OIDCIdentity) (*models.User, bool, error) {
+	// user tạo qua OIDC chưa có mật khẩu dùng được: hash của chuỗi ngẫu nhiên
+	random, err := randomHex(32)
+	if err != nil {
+		return nil, false, err
+	}
+	hash, err := utils.HashPassword(random)
+	if err != nil {
+		return nil, false, err
+	}
+	return repository.LinkOrCreateOIDCUser(&models.UserIdentity{
+		Provider: id.Provider,
+		Subject:  id.Subject,
+		Email:    id.Email,
+	}, id.EmailVerified, id.Username, hash)
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeOIDC: provider giả phục vụ discovery, JWKS, token và userinfo
+type fakeOIDC struct {
+	srv      *httptest.Server
+	key      *rsa.PrivateKey
+	claims   jwt.MapClaims // claims của id_token trả về ở /token
+	userinfo map[string]interface{}
+	verifier string // code_verifier nhận được ở lần đổi code gần nhất
+}
+
+func newFakeOIDC(t *testing.T) *fakeOIDC {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeOIDC{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.srv.URL,
+			"authorization_endpoint": f.srv.URL + "/authorize",
+			"token_endpoint":         f.srv.URL + "/token",
+			"userinfo_endpoint":      f.srv.URL + "/userinfo",
+			"jwks_uri":               f.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		enc := base64.RawURLEncoding.EncodeToString
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"n":   enc(key.N.Bytes()),
+			"e":   enc(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("code") != "good-code" || r.PostForm.Get("client_id") != "client-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		f.verifier = r.PostForm.Get("code_verifier")
+		resp := map[string]string{"access_token": "access-1", "token_type": "Bearer"}
+		if f.claims != nil {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, f.claims)
+			token.Header["kid"] = "test-key"
+			signed, err := token.SignedString(key)
+			if err != nil {
+				t.Errorf("sign id_token: %v", err)
+			}
+			resp["id_token"] = signed
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-1" || f.userinfo == nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(f.userinfo)
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// idClaims: id_token hợp lệ cho client-1 với nonce cho trước
+func (f *fakeOIDC) idClaims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            f.srv.URL,
+		"sub":            "sub-123",
+		"aud":            "client-1",
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          "Lan@Example.com",
+		"email_verified": true,
+		"name":           "Lan",
+	}
+}
+
+func (f *fakeOIDC) provider(t *testing.T) *OIDCProvider {
+	t.Helper()
+	t.Setenv("OIDC_PROVIDERS", "fake")
+	t.Setenv("OIDC_FAKE_ISSUER", f.srv.URL)
+	t.Setenv("OIDC_FAKE_CLIENT_ID", "client-1")
+	t.Setenv("OIDC_FAKE_CLIENT_SECRET", "secret-1")
+	p, err := GetOIDCProvider("fake")
+	if err != nil {
+		t.Fatalf("GetOIDCProvider: %v", err)
+	}
+	return p
+}
+
+func TestExchangeOIDCCodeWithValidIDToken(t *testing.T) {
+	f := newFakeOIDC(t)
+	p := f.provider(t)
+	if p.TokenURL != f.srv.URL+"/token" || p.JWKSURL != f.srv.URL+"/jwks" {
+		t.Fatalf("discovery not applied: %+v", p)
+	}
+	f.claims = f.idClaims("nonce-1")
+
+	id, err := ExchangeOIDCCode(p, "good-code", &OIDCStateClaims{Nonce: "nonce-1", Verifier: "verifier-1"})
+	if err != nil {
+		t.Fatalf("ExchangeOIDCCode: %v", err)
+	}
+	if id.Provider != "fake" || id.Subject != "sub-123" || id.Email != "Lan@Example.com" || !id.EmailVerified || id.Username != "Lan" {
+		t.Errorf("unexpected identity %+v", id)
+	}
+	if f.verifier != "verifier-1" {
+		t.Errorf("code_verifier = %q, want PKCE verifier from state", f.verifier)
+	}
+	if err := repository.CheckOIDCLink(id.Email, id.EmailVerified, nil); err != nil {
+		t.Errorf("verified new email should create an account: %v", err)
+	}
+}
+
+func TestExchangeOIDCCodeRejectsBadIDToken(t *testing.T) {
+	f := newFakeOIDC(t)
+	p := f.provider(t)
+
+	cases := map[string]func(c jwt.MapClaims){
+		"wrong nonce":    func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "client-2" },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+	}
+	for name, mutate := range cases {
+		f.claims = f.idClaims("nonce-1")
+		mutate(f.claims)
+		_, err := ExchangeOIDCCode(p, "good-code", &OIDCStateClaims{Nonce: "nonce-1", Verifier: "v"})
+		if !errors.Is(err, ErrOIDCToken) {
+			t.Errorf("%s: err = %v, want ErrOIDCToken", name, err)
+		}
+	}
+
+	f.claims = f.idClaims("nonce-1")
+	if _, err := ExchangeOIDCCode(p, "bad-code", &OIDCStateClaims{Nonce: "nonce-1", Verifier: "v"}); err == nil {
+		t.Error("rejected code exchanged successfully")
+	}
+}
+
+func TestOIDCUnverifiedEmailIsNotLinked(t *testing.T) {
+	f := newFakeOIDC(t)
+	p := f.provider(t)
+	f.claims = f.idClaims("n")
+	f.claims["email_verified"] = "false" // có provider trả chuỗi
+
+	id, err := ExchangeOIDCCode(p, "good-code", &OIDCStateClaims{Nonce: "n", Verifier: "v"})
+	if err != nil {
+		t.Fatalf("ExchangeOIDCCode: %v", err)
+	}
+	if id.EmailVerified {
+		t.Fatal("email_verified=false reported as verified")
+	}
+	customer := &models.User{ID: 7, Role: "customer", Email: "lan@example.com"}
+	if err := repository.CheckOIDCLink(id.Email, id.EmailVerified, customer); !errors.Is(err, repository.ErrIdentityEmailUnverified) {
+		t.Errorf("err = %v, want ErrIdentityEmailUnverified", err)
+	}
+}
+
+func TestOIDCUserInfoOnlyProviderTrustEmail(t *testing.T) {
+	f := newFakeOIDC(t)
+	p := f.provider(t)
+	f.userinfo = map[string]interface{}{"id": "fb-1", "email": "lan@example.com", "name": "Lan"}
+
+	id, err := ExchangeOIDCCode(p, "good-code", &OIDCStateClaims{Nonce: "n", Verifier: "v"})
+	if err != nil {
+		t.Fatalf("ExchangeOIDCCode: %v", err)
+	}
+	if id.Subject != "fb-1" || id.EmailVerified {
+		t.Errorf("userinfo without email_verified must not be trusted by default: %+v", id)
+	}
+
+	p.TrustEmail = true
+	if id, err = ExchangeOIDCCode(p, "good-code", &OIDCStateClaims{Nonce: "n", Verifier: "v"}); err != nil || !id.EmailVerified {
+		t.Errorf("TRUST_EMAIL=true: identity %+v, err %v", id, err)
+	}
+}
+
+func TestOIDCStaffEmailRequiresExplicitLink(t *testing.T) {
+	f := newFakeOIDC(t)
+	p := f.provider(t)
+	f.claims = f.idClaims("n")
+
+	id, err := ExchangeOIDCCode(p, "good-code", &OIDCStateClaims{Nonce: "n", Verifier: "v"})
+	if err != nil {
+		t.Fatalf("ExchangeOIDCCode: %v", err)
+	}
+	for _, role := range []string{"staff", "admin"} {
+		user := &models.User{ID: 3, Role: role, Email: "lan@example.com"}
+		if err := repository.CheckOIDCLink(id.Email, id.EmailVerified, user); !errors.Is(err, repository.ErrIdentityLinkRequired) {
+			t.Errorf("%s: err = %v, want ErrIdentityLinkRequired", role, err)
+		}
+	}
+	customer := &models.User{ID: 1, Role: "customer", Email: "lan@example.com"}
+	if err := repository.CheckOIDCLink(id.Email, id.EmailVerified, customer); err != nil {
+		t.Errorf("customer with same verified email should link: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"backend/internal/repository"
+	"log"
+	"sync"
+)
+
+// cache role -> quyền, nạp từ DB lúc khởi động và sau mỗi lần cấu hình lại
+var rolePermissions = struct {
+	sync.RWMutex
+	byRole map[string]map[string]bool
+}{byRole: map[string]map[string]bool{}}
+
+func LoadPermissions() error {
+	perms, err := repository.GetRolePermissions()
+	if err != nil {
+		return err
+	}
+	SetPermissions(perms)
+	return nil
+}
+
+// SetPermissions thay toàn bộ cache role -> quyền
+func SetPermissions(perms map[string][]string) {
+	byRole := make(map[string]map[string]bool, len(perms))
+	for role, list := range perms {
+		set := make(map[string]bool, len(list))
+		for _, p := range list {
+			set[p] = true
+		}
+		byRole[role] = set
+	}
+	rolePermissions.Lock()
+	rolePermissions.byRole = byRole
+	rolePermissions.Unlock()
+	log.Printf("Role permissions loaded: %d roles", len(byRole))
+}
+
+// HasPermission: admin luôn có toàn quyền, role khác tra theo bảng role_permissions
+func HasPermission(role, permission string) bool {
+	if role == "admin" {
+		return true
+	}
+	rolePermissions.RLock()
+	defer rolePermissions.RUnlock()
+	return rolePermissions.byRole[role][permission]
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	repo "backend/internal/repository/customer"
+)
+
+// StartRecommendationJob tính lại gợi ý sản phẩm lúc khởi động và định kỳ
+// (RECOMMENDATION_REFRESH_MINUTES, mặc định 60 phút)
+func StartRecommendationJob() {
+	interval := 60 * time.Minute
+	if m, err := strconv.Atoi(os.Getenv("RECOMMENDATION_REFRESH_MINUTES")); err == nil && m > 0 {
+		interval = time.Duration(m) * time.Minute
+	}
+
+	refresh := func() {
+		start := time.Now()
+		if err := repo.RefreshRecommendations(); err != nil {
+			log.Println("Refresh recommendations failed:", err)
+			return
+		}
+		log.Printf("Recommendations refreshed in %s", time.Since(start).Round(time.Millisecond))
+	}
+
+	go func() {
+		refresh()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			refresh()
+		}
+	}()
+}
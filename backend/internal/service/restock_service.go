@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	repo "backend/internal/repository/customer"
+)
+
+// CustomerPusher gửi message realtime tới khách đang kết nối WebSocket
+// (ChatHandler implement, gắn lúc khởi động qua SetCustomerPusher)
+type CustomerPusher interface {
+	PushToCustomer(customerID uint, payload interface{}) bool
+}
+
+var customerPusher CustomerPusher
+
+func SetCustomerPusher(p CustomerPusher) {
+	customerPusher = p
+}
+
+// BackInStockMessage: payload WebSocket {"type": "back_in_stock", ...}
+type BackInStockMessage struct {
+	Type string `json:"type"`
+	repo.RestockNotice
+}
+
+// NotifyBackInStock gửi email + push cho các đăng ký của variant vừa có hàng lại.
+// Chạy nền để không chặn request; không truyền variantIDs = quét toàn bộ.
+func NotifyBackInStock(variantIDs ...uint) {
+	go func() {
+		if err := dispatchRestockNotices(variantIDs); err != nil {
+			log.Println("Back-in-stock notify failed:", err)
+		}
+	}()
+}
+
+func dispatchRestockNotices(variantIDs []uint) error {
+	notices, err := repo.ClaimRestockNotices(variantIDs)
+	for _, n := range notices {
+		if customerPusher != nil {
+			customerPusher.PushToCustomer(n.UserID, BackInStockMessage{Type: "back_in_stock", RestockNotice: n})
+		}
+		if n.Email != "" {
+			if err := SendBackInStockEmail(n); err != nil {
+				log.Printf("Back-in-stock email to user %d failed: %v", n.UserID, err)
+			}
+		}
+	}
+	return err
+}
+
+// StartBackInStockJob quét định kỳ (BACK_IN_STOCK_SWEEP_MINUTES, mặc định 5 phút)
+// để không bỏ sót thay đổi stock đi đường khác ngoài các API đã gọi NotifyBackInStock
+func StartBackInStockJob() {
+	interval := 5 * time.Minute
+	if m, err := strconv.Atoi(os.Getenv("BACK_IN_STOCK_SWEEP_MINUTES")); err == nil && m > 0 {
+		interval = time.Duration(m) * time.Minute
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := dispatchRestockNotices(nil); err != nil {
+				log.Println("Back-in-stock sweep failed:", err)
+			}
+		}
+	}()
+}
+
+func SendBackInStockEmail(n repo.RestockNotice) error {
+	from := os.Getenv("EMAIL_USER")
+	pass := os.Getenv("EMAIL_PASS")
+	host := os.Getenv("EMAIL_HOST")
+	port := os.Getenv("EMAIL_PORT")
+
+	auth := smtp.PlainAuth("", from, pass, host)
+
+	productLink := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/") + "/product/" + url.PathEscape(n.Slug)
+	var options []string
+	for _, v := range []string{n.Size, n.Color} {
+		if v != "" {
+			options = append(options, html.EscapeString(v))
+		}
+	}
+
+	subject := "Subject: 🔔 Sản phẩm bạn chờ đã có hàng\n"
+	body := fmt.Sprintf(`
+	<html>
+		<body style="font-family: Arial, sans-serif; line-height: 1.5;">
+			<h3>%s đã có hàng trở lại!</h3>
+			<p>Phân loại: %s</p>
+			<p>Số lượng có hạn, đặt ngay kẻo hết nhé.</p>
+			<p><a href="%s">Xem sản phẩm</a></p>
+		</body>
+	</html>
+	`, html.EscapeString(n.ProductName), strings.Join(options, " / "), html.EscapeString(productLink))
+
+	msg := []byte(
+		"From: " + from + "\n" +
+			"To: " + n.Email + "\n" +
+			subject +
+			"MIME-Version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n" +
+			body,
+	)
+
+	addr := fmt.Sprintf("%s:%s", host, port)
+	if err := smtp.SendMail(addr, auth, from, []string{n.Email}, msg); err != nil {
+		log.Println("Email error:", err)
+		return err
+	}
+	return nil
+}
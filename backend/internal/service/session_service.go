@@ -0,0 +1,69 @@
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"log"
+	"time"
+)
+
+// Session: cặp token trả về cho client sau khi login/refresh
+type Session struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // giây, của access token
+}
+
+func newSession(user *models.User, refreshToken string) (*Session, error) {
+	access, err := GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		Token:        access,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// StartSession mở phiên đăng nhập mới (refresh token family mới)
+func StartSession(user *models.User, ip, userAgent string) (*Session, error) {
+	refresh, err := repository.IssueRefreshToken(user.ID, RefreshTokenTTL(), ip, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return newSession(user, refresh)
+}
+
+// RefreshSession xoay vòng refresh token, access token mới lấy role/token_version hiện tại trong DB
+func RefreshSession(refreshToken, ip, userAgent string) (*Session, error) {
+	user, next, err := repository.RotateRefreshToken(refreshToken, RefreshTokenTTL(), ip, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return newSession(user, next)
+}
+
+// StartAuthTokenCleanupJob dọn refresh token, token đặt lại mật khẩu và đăng ký chờ xác nhận đã hết hạn (chạy mỗi 6 giờ)
+func StartAuthTokenCleanupJob() {
+	go func() {
+		ticker := time.NewTicker(6 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if n, err := repository.CleanupRefreshTokens(); err != nil {
+				log.Println("Refresh token cleanup failed:", err)
+			} else if n > 0 {
+				log.Printf("Refresh token cleanup: %d removed", n)
+			}
+			if _, err := repository.CleanupPasswordResetTokens(); err != nil {
+				log.Println("Password reset token cleanup failed:", err)
+			}
+			if _, err := repository.CleanupPendingRegistrations(); err != nil {
+				log.Println("Pending registration cleanup failed:", err)
+			}
+			if _, err := repository.CleanupEmailChangeRequests(); err != nil {
+				log.Println("Email change request cleanup failed:", err)
+			}
+		}
+	}()
+}
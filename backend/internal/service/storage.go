@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Storage lưu file upload (ảnh sản phẩm, ảnh chat...) và trả về URL public
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+var (
+	storageOnce    sync.Once
+	defaultStorage Storage
+)
+
+// DefaultStorage chọn backend theo STORAGE_DRIVER (local | s3), mặc định local
+func DefaultStorage() Storage {
+	storageOnce.Do(func() {
+		switch strings.ToLower(os.Getenv("STORAGE_DRIVER")) {
+		case "s3":
+			defaultStorage = NewS3Storage(S3Config{
+				Endpoint:  os.Getenv("S3_ENDPOINT"),
+				Region:    os.Getenv("S3_REGION"),
+				Bucket:    os.Getenv("S3_BUCKET"),
+				AccessKey: os.Getenv("S3_ACCESS_KEY"),
+				SecretKey: os.Getenv("S3_SECRET_KEY"),
+				PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			})
+		default:
+			dir := os.Getenv("UPLOAD_DIR")
+			if dir == "" {
+				dir = "uploads"
+			}
+			defaultStorage = NewLocalStorage(dir, strings.TrimRight(os.Getenv("BACKEND_URL"), "/")+"/uploads")
+		}
+		log.Printf("Storage driver: %T", defaultStorage)
+	})
+	return defaultStorage
+}
+
+// ---------- Local disk ----------
+
+type LocalStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+func NewLocalStorage(dir, baseURL string) *LocalStorage {
+	return &LocalStorage{Dir: dir, BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if strings.Contains(clean, "..") {
+		return "", errors.New("invalid storage key")
+	}
+	return filepath.Join(s.Dir, clean), nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.BaseURL + "/" + strings.TrimLeft(key, "/")
+}
+
+// UploadFileSystem phục vụ thư mục upload nhưng không cho mở thư mục (chặn liệt kê file của FileServer)
+func UploadFileSystem(dir string) http.FileSystem {
+	return filesOnlyFS{http.Dir(dir)}
+}
+
+type filesOnlyFS struct {
+	fs http.FileSystem
+}
+
+func (f filesOnlyFS) Open(name string) (http.File, error) {
+	file, err := f.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return nil, os.ErrNotExist
+	}
+	return file, nil
+}
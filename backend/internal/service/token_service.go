@@ -0,0 +1,164 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Mục đích của token, mỗi loại ký bằng bộ key riêng và không dùng thay nhau được
+const (
+	PurposeAccess = "access"
+	PurposeMFA    = "mfa" // token tạm giữa bước mật khẩu và bước nhập mã MFA
+)
+
+var (
+	ErrTokenPurpose = errors.New("token used for wrong purpose")
+	ErrTokenKey     = errors.New("unknown token key")
+)
+
+// TokenMeta: phần claim chung của mọi token (iss, aud, exp, iat, jti + pur)
+type TokenMeta struct {
+	Purpose string `json:"pur"`
+	jwt.RegisteredClaims
+}
+
+func (m *TokenMeta) meta() *TokenMeta { return m }
+
+type tokenClaims interface {
+	jwt.Claims
+	meta() *TokenMeta
+}
+
+func tokenIssuer() string {
+	if v := os.Getenv("JWT_ISSUER"); v != "" {
+		return v
+	}
+	return "clothing-app"
+}
+
+// audience theo mục đích: "<JWT_AUDIENCE>:<purpose>"
+func tokenAudience(purpose string) string {
+	aud := os.Getenv("JWT_AUDIENCE")
+	if aud == "" {
+		aud = "clothing-app"
+	}
+	return aud + ":" + purpose
+}
+
+// keyRing: key đầu tiên dùng để ký, các key còn lại chỉ để verify (xoay vòng key)
+type keyRing struct {
+	active string
+	keys   map[string][]byte
+}
+
+// parseKeyRing đọc dạng "kid1:secret1,kid2:secret2"
+func parseKeyRing(spec string) keyRing {
+	ring := keyRing{keys: map[string][]byte{}}
+	for _, part := range strings.Split(spec, ",") {
+		kid, secret, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || kid == "" || secret == "" {
+			continue
+		}
+		if ring.active == "" {
+			ring.active = kid
+		}
+		ring.keys[kid] = []byte(secret)
+	}
+	return ring
+}
+
+// keyRingFor: access token dùng JWT_SESSION_KEYS, purpose khác dùng JWT_<PURPOSE>_KEYS.
+// Chưa cấu hình thì lùi về JWT_SECRET; purpose khác access được dẫn xuất key riêng từ JWT_SECRET
+func keyRingFor(purpose string) keyRing {
+	envKeys := "JWT_" + strings.ToUpper(purpose) + "_KEYS"
+	if purpose == PurposeAccess {
+		envKeys = "JWT_SESSION_KEYS"
+	}
+	if ring := parseKeyRing(os.Getenv(envKeys)); ring.active != "" {
+		return ring
+	}
+	// JWT_SECRET rỗng: không có key nào, không ký/verify bằng key dẫn xuất từ chuỗi rỗng (ai cũng tính được)
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return keyRing{}
+	}
+	if purpose == PurposeAccess {
+		return keyRing{active: "default", keys: map[string][]byte{"default": []byte(secret)}}
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return keyRing{active: "derived", keys: map[string][]byte{"derived": mac.Sum(nil)}}
+}
+
+// randomHex trả về n byte ngẫu nhiên dạng hex, lỗi nếu nguồn ngẫu nhiên của hệ thống hỏng
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func newTokenID() (string, error) {
+	return randomHex(16)
+}
+
+// issueToken điền iss/aud/pur/exp/iat/jti rồi ký bằng key đang active của purpose
+func issueToken(purpose string, ttl time.Duration, claims tokenClaims) (string, error) {
+	ring := keyRingFor(purpose)
+	key, ok := ring.keys[ring.active]
+	if !ok || len(key) == 0 {
+		return "", fmt.Errorf("no signing key configured for %s tokens", purpose)
+	}
+	jti, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("generate token id: %w", err)
+	}
+	now := time.Now()
+	m := claims.meta()
+	m.Purpose = purpose
+	m.Issuer = tokenIssuer()
+	m.Audience = jwt.ClaimStrings{tokenAudience(purpose)}
+	m.IssuedAt = jwt.NewNumericDate(now)
+	m.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	m.ID = jti
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = ring.active
+	return token.SignedString(key)
+}
+
+// parseToken verify chữ ký theo kid, iss, aud, exp và purpose
+func parseToken(purpose, tokenStr string, claims tokenClaims) error {
+	ring := keyRingFor(purpose)
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := ring.keys[kid]
+		if !ok || len(key) == 0 {
+			return nil, ErrTokenKey
+		}
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer()),
+		jwt.WithAudience(tokenAudience(purpose)),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return err
+	}
+	if claims.meta().Purpose != purpose {
+		return ErrTokenPurpose
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestDerivedKeysRequireJWTSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_SESSION_KEYS", "")
+	t.Setenv("JWT_MFA_KEYS", "")
+
+	for _, purpose := range []string{PurposeAccess, PurposeMFA} {
+		if tok, err := issueToken(purpose, time.Minute, &TokenMeta{}); err == nil {
+			t.Errorf("%s token issued without JWT_SECRET: %s", purpose, tok)
+		}
+	}
+
+	// token ký bằng key dẫn xuất từ secret rỗng không được chấp nhận
+	t.Setenv("JWT_SECRET", "x")
+	tok, err := issueToken(PurposeMFA, time.Minute, &TokenMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JWT_SECRET", "")
+	if err := parseToken(PurposeMFA, tok, &TokenMeta{}); err == nil {
+		t.Error("token accepted without JWT_SECRET")
+	}
+}
+
+// tokenEnv: cấu hình key/iss/aud cho một bước của test
+type tokenEnv map[string]string
+
+func (e tokenEnv) apply(t *testing.T) {
+	t.Helper()
+	base := tokenEnv{
+		"JWT_SECRET":       "test-secret",
+		"JWT_SESSION_KEYS": "",
+		"JWT_MFA_KEYS":     "",
+		"JWT_ISSUER":       "",
+		"JWT_AUDIENCE":     "",
+	}
+	for k, v := range e {
+		base[k] = v
+	}
+	for k, v := range base {
+		t.Setenv(k, v)
+	}
+}
+
+func TestParseTokenRejects(t *testing.T) {
+	user := &models.User{ID: 9, Role: "customer", TokenVersion: 2}
+
+	// token access đúng key/aud nhưng claim pur khác
+	wrongPurpose := func(t *testing.T) string {
+		claims := &Claims{UserID: 9, Role: "customer"}
+		claims.Purpose = PurposeMFA
+		claims.Issuer = tokenIssuer()
+		claims.Audience = jwt.ClaimStrings{tokenAudience(PurposeAccess)}
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+		tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tok.Header["kid"] = "default"
+		s, err := tok.SignedString([]byte("test-secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	cases := []struct {
+		name    string
+		issue   tokenEnv // env lúc ký
+		parse   tokenEnv // env lúc verify
+		token   func(t *testing.T) string
+		wantErr error // nil = chỉ cần có lỗi
+	}{
+		{
+			name: "mfa token used as access token",
+			token: func(t *testing.T) string {
+				s, err := GenerateMFAToken(user, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return s
+			},
+		},
+		{
+			name:    "access audience but mfa purpose",
+			token:   wrongPurpose,
+			wantErr: ErrTokenPurpose,
+		},
+		{
+			name:  "wrong audience",
+			issue: tokenEnv{"JWT_AUDIENCE": "other-app"},
+		},
+		{
+			name:  "wrong issuer",
+			issue: tokenEnv{"JWT_ISSUER": "other-issuer"},
+		},
+		{
+			name:    "unknown kid",
+			issue:   tokenEnv{"JWT_SESSION_KEYS": "stolen:some-key"},
+			parse:   tokenEnv{"JWT_SESSION_KEYS": "new:key-2,old:key-1"},
+			wantErr: ErrTokenKey,
+		},
+		{
+			name:    "retired kid",
+			issue:   tokenEnv{"JWT_SESSION_KEYS": "old:key-1"},
+			parse:   tokenEnv{"JWT_SESSION_KEYS": "new:key-2"},
+			wantErr: ErrTokenKey,
+		},
+		{
+			name:  "same kid, different secret",
+			issue: tokenEnv{"JWT_SESSION_KEYS": "k1:key-1"},
+			parse: tokenEnv{"JWT_SESSION_KEYS": "k1:key-2"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.issue.apply(t)
+			var tok string
+			if c.token != nil {
+				tok = c.token(t)
+			} else {
+				var err error
+				if tok, err = GenerateToken(user); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c.parse.apply(t)
+			_, err := ParseToken(tok)
+			if err == nil {
+				t.Fatal("token accepted")
+			}
+			if c.wantErr != nil && !errors.Is(err, c.wantErr) {
+				t.Errorf("err = %v, want %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTokenKeyRotation(t *testing.T) {
+	user := &models.User{ID: 9, Role: "staff", TokenVersion: 1}
+
+	tokenEnv{"JWT_SESSION_KEYS": "old:key-1"}.apply(t)
+	oldTok, err := GenerateToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// key mới đứng đầu để ký, key cũ vẫn verify được token đang lưu hành
+	tokenEnv{"JWT_SESSION_KEYS": "new:key-2,old:key-1"}.apply(t)
+	claims, err := ParseToken(oldTok)
+	if err != nil {
+		t.Fatalf("old token rejected after rotation: %v", err)
+	}
+	if claims.UserID != 9 || claims.Role != "staff" || claims.TokenVersion != 1 || claims.Purpose != PurposeAccess {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	newTok, err := GenerateToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newTok, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := parsed.Header["kid"]; kid != "new" {
+		t.Errorf("new token signed with kid %v, want new", kid)
+	}
+	if _, err := ParseToken(newTok); err != nil {
+		t.Errorf("new token rejected: %v", err)
+	}
+}
+
+func TestMFATokenRoundTrip(t *testing.T) {
+	tokenEnv{}.apply(t)
+	user := &models.User{ID: 5, Role: "admin", TokenVersion: 3}
+	tok, err := GenerateMFAToken(user, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &MFAClaims{}
+	if err := parseToken(PurposeMFA, tok, claims); err != nil {
+		t.Fatalf("mfa token rejected for its own purpose: %v", err)
+	}
+	if claims.UserID != 5 || claims.TokenVersion != 3 || !claims.Enroll {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
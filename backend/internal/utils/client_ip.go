@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// trustedProxies: TRUSTED_PROXIES="10.0.0.0/8,127.0.0.1" — chỉ tin X-Forwarded-For khi request đi qua các proxy này
+func trustedProxies() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, part := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			if ip := net.ParseIP(part); ip != nil && ip.To4() != nil {
+				part += "/32"
+			} else {
+				part += "/128"
+			}
+		}
+		if _, n, err := net.ParseCIDR(part); err == nil {
+			nets = append(nets, n)
+		}
+	}
+	return nets
+}
+
+func isTrusted(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeIP(ip net.IP) string {
+	if ip.IsLoopback() {
+		return "127.0.0.1"
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String()
+	}
+	return ip.String()
+}
+
+// ClientIP lấy IP thật của client. X-Forwarded-For chỉ được đọc khi RemoteAddr là proxy tin cậy,
+// và lấy IP gần nhất (từ phải sang) không phải proxy, để client không tự giả IP được.
+func ClientIP(r *http.Request) string {
+	host := strings.TrimSpace(r.RemoteAddr)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	remote := net.ParseIP(strings.Trim(host, "[]"))
+	if remote == nil {
+		return "127.0.0.1"
+	}
+
+	nets := trustedProxies()
+	if isTrusted(remote, nets) {
+		hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			if !isTrusted(ip, nets) {
+				return normalizeIP(ip)
+			}
+			remote = ip
+		}
+	}
+	return normalizeIP(remote)
+}
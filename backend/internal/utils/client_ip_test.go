@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,127.0.0.1")
+	cases := []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"no proxy", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"spoofed header from untrusted client", "203.0.113.7:5000", "1.2.3.4", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:80", "198.51.100.9", "198.51.100.9"},
+		{"client prepends fake hop", "10.0.0.2:80", "1.2.3.4, 198.51.100.9", "198.51.100.9"},
+		{"chain of trusted proxies", "127.0.0.1:80", "198.51.100.9, 10.0.0.5", "198.51.100.9"},
+		{"garbage hop stops at last trusted", "10.0.0.2:80", "198.51.100.9, not-an-ip", "10.0.0.2"},
+		{"ipv6 client", "[2001:db8::1]:443", "1.2.3.4", "2001:db8::1"},
+		{"loopback normalised", "[::1]:80", "", "127.0.0.1"},
+		{"bad remote addr", "garbage", "1.2.3.4", "127.0.0.1"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = c.remote
+		if c.xff != "" {
+			r.Header.Set("X-Forwarded-For", c.xff)
+		}
+		if got := ClientIP(r); got != c.want {
+			t.Errorf("%s: ClientIP = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestClientIPWithoutTrustedProxies(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "")
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.2:80"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	if got := ClientIP(r); got != "10.0.0.2" {
+		t.Errorf("ClientIP = %q, want RemoteAddr when no proxy is trusted", got)
+	}
+}
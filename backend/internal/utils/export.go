@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Các định dạng export hỗ trợ
+const (
+	ExportCSV    = "csv"
+	ExportXLSX   = "xlsx"
+	ExportNDJSON = "ndjson"
+)
+
+// RowWriter ghi từng dòng ra output, không giữ toàn bộ dữ liệu trong bộ nhớ.
+type RowWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// ContentType trả về MIME type tương ứng với định dạng export
+func ContentType(format string) string {
+	switch format {
+	case ExportXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// NewRowWriter tạo writer theo format, headers là tên cột (và key của NDJSON)
+func NewRowWriter(w io.Writer, format string, headers []string) (RowWriter, error) {
+	switch format {
+	case ExportCSV, "":
+		return newCSVWriter(w, headers)
+	case ExportXLSX:
+		return newXLSXWriter(w, headers)
+	case ExportNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w), headers: headers}, nil
+	}
+	return nil, errors.New("unsupported export format: " + format)
+}
+
+func formatCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case *string:
+		if val == nil {
+			return ""
+		}
+		return *val
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return formatCell(*val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// ---------- CSV ----------
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, headers []string) (*csvWriter, error) {
+	// BOM để Excel đọc đúng tiếng Việt
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, err
+	}
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(headers); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// csvSafeCell: chuỗi do người dùng nhập bắt đầu bằng = + - @ tab/CR bị Excel hiểu là công thức,
+// thêm ' phía trước để mở file không chạy công thức (CSV injection). Số âm vẫn giữ nguyên.
+func csvSafeCell(v interface{}) string {
+	cell := formatCell(v)
+	switch v.(type) {
+	case string, *string:
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			return "'" + cell
+		}
+	}
+	return cell
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = csvSafeCell(v)
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ---------- NDJSON ----------
+
+type ndjsonWriter struct {
+	enc     *json.Encoder
+	headers []string
+}
+
+func (n *ndjsonWriter) WriteRow(values []interface{}) error {
+	obj := make(map[string]interface{}, len(n.headers))
+	for i, h := range n.headers {
+		if i < len(values) {
+			obj[h] = values[i]
+		}
+	}
+	return n.enc.Encode(obj)
+}
+
+func (n *ndjsonWriter) Close() error { return nil }
+
+// ---------- XLSX ----------
+
+// xlsxWriter ghi một workbook 1 sheet. Sheet được stream thẳng vào zip entry
+// cuối cùng nên chỉ cần giữ 1 dòng trong bộ nhớ.
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+func newXLSXWriter(w io.Writer, headers []string) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	xw := &xlsxWriter{zw: zw, sheet: sheet}
+	hv := make([]interface{}, len(headers))
+	for i, h := range headers {
+		hv[i] = h
+	}
+	if err := xw.WriteRow(hv); err != nil {
+		return nil, err
+	}
+	return xw, nil
+}
+
+func (x *xlsxWriter) WriteRow(values []interface{}) error {
+	x.row++
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<row r="%d">`, x.row)
+	for i, v := range values {
+		ref := xlsxColumn(i) + strconv.Itoa(x.row)
+		switch n := v.(type) {
+		case int, int64, uint, uint64, float64:
+			fmt.Fprintf(&sb, `<c r="%s"><v>%s</v></c>`, ref, formatCell(n))
+		default:
+			sb.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			sb.WriteString(xmlEscape(formatCell(v)))
+			sb.WriteString(`</t></is></c>`)
+		}
+	}
+	sb.WriteString(`</row>`)
+	_, err := io.WriteString(x.sheet, sb.String())
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+// xlsxColumn: 0 -> A, 25 -> Z, 26 -> AA
+func xlsxColumn(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '<':
+			sb.WriteString("&lt;")
+		case '>':
+			sb.WriteString("&gt;")
+		case '&':
+			sb.WriteString("&amp;")
+		case '"':
+			sb.WriteString("&quot;")
+		default:
+			// bỏ ký tự điều khiển không hợp lệ trong XML
+			if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+				continue
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
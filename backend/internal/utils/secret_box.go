@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+)
+
+// ErrNoSecretKey: chưa cấu hình MFA_ENCRYPTION_KEY lẫn JWT_SECRET
+var ErrNoSecretKey = errors.New("no encryption key configured (MFA_ENCRYPTION_KEY or JWT_SECRET)")
+
+// secretKey: MFA_ENCRYPTION_KEY, chưa cấu hình thì dẫn xuất từ JWT_SECRET.
+// Cả hai đều rỗng thì báo lỗi: key dẫn xuất từ chuỗi rỗng ai cũng tính được, mã hoá như không.
+func secretKey() ([]byte, error) {
+	if k := os.Getenv("MFA_ENCRYPTION_KEY"); k != "" {
+		sum := sha256.Sum256([]byte(k))
+		return sum[:], nil
+	}
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, ErrNoSecretKey
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("mfa-secret"))
+	return mac.Sum(nil), nil
+}
+
+func newGCM() (cipher.AEAD, error) {
+	key, err := secretKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret mã hoá AES-GCM để lưu secret TOTP trong DB
+func EncryptSecret(plain string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+func DecryptSecret(encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
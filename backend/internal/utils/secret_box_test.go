@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "")
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	enc, err := EncryptSecret("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := DecryptSecret(enc); err != nil || plain != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("DecryptSecret = %q, %v", plain, err)
+	}
+
+	// đổi key thì không giải mã được
+	t.Setenv("MFA_ENCRYPTION_KEY", "other-key")
+	if _, err := DecryptSecret(enc); err == nil {
+		t.Error("decrypted with a different key")
+	}
+}
+
+func TestSecretBoxRequiresKey(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "")
+	t.Setenv("JWT_SECRET", "x")
+	enc, err := EncryptSecret("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("JWT_SECRET", "")
+	if _, err := EncryptSecret("secret"); !errors.Is(err, ErrNoSecretKey) {
+		t.Errorf("EncryptSecret err = %v, want ErrNoSecretKey", err)
+	}
+	if _, err := DecryptSecret(enc); !errors.Is(err, ErrNoSecretKey) {
+		t.Errorf("DecryptSecret err = %v, want ErrNoSecretKey", err)
+	}
+}
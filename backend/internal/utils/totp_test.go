@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// secret của RFC 6238 Appendix B (SHA-1): chuỗi ASCII "12345678901234567890"
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	// mã 8 chữ số trong RFC, cắt còn 6 chữ số cuối
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, c := range cases {
+		got, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(c.unix, 0)))
+		if err != nil {
+			t.Fatalf("T=%d: %v", c.unix, err)
+		}
+		if got != c.code {
+			t.Errorf("T=%d: code = %s, want %s", c.unix, got, c.code)
+		}
+	}
+}
+
+func TestVerifyTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+	codeAt := func(s int64) string {
+		code, err := TOTPCode(rfcSecret, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	cases := []struct {
+		name string
+		code string
+		ok   bool
+	}{
+		{"current step", codeAt(step), true},
+		{"previous step", codeAt(step - 1), true},
+		{"next step", codeAt(step + 1), true},
+		{"two steps back", codeAt(step - 2), false},
+		{"two steps ahead", codeAt(step + 2), false},
+		{"spaces allowed", codeAt(step)[:3] + " " + codeAt(step)[3:], true},
+		{"wrong length", codeAt(step)[:5], false},
+	}
+	for _, c := range cases {
+		matched, ok := VerifyTOTP(rfcSecret, c.code, now, 1)
+		if ok != c.ok {
+			t.Errorf("%s: ok = %v, want %v", c.name, ok, c.ok)
+		}
+		if ok && c.name == "previous step" && matched != step-1 {
+			t.Errorf("%s: matched step %d, want %d", c.name, matched, step-1)
+		}
+	}
+
+	if _, ok := VerifyTOTP(rfcSecret, codeAt(step-1), now, 0); ok {
+		t.Error("skew 0 accepted previous step")
+	}
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"unicode/utf8"
+)
+
+var phoneRe = regexp.MustCompile(`^(?:\+?84|0)\d{8,10}$`)
+
+// ValidateUsername: 3-50 ký tự
+func ValidateUsername(username string) error {
+	if n := utf8.RuneCountInString(username); n < 3 || n > 50 {
+		return errors.New("username must be 3-50 characters")
+	}
+	return nil
+}
+
+// ValidateEmail: chỉ nhận địa chỉ trần (không kèm tên hiển thị), đã chuẩn hoá chữ thường
+func ValidateEmail(email string) error {
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return errors.New("invalid email")
+	}
+	return nil
+}
+
+// ValidatePhone: số điện thoại Việt Nam (0xxx hoặc +84xxx), rỗng là hợp lệ
+func ValidatePhone(phone string) error {
+	if phone != "" && !phoneRe.MatchString(phone) {
+		return errors.New("invalid phone number")
+	}
+	return nil
+}
+
+// ValidateAddress: vừa cột varchar(255)
+func ValidateAddress(address string) error {
+	if len(address) > 255 {
+		return errors.New("address is too long")
+	}
+	return nil
+}
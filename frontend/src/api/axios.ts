@@ -14,4 +14,49 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+// access token sống ngắn: gặp 401 thì đổi refresh token lấy token mới rồi gửi lại request (chỉ 1 lần)
+let refreshing: Promise<string | null> | null = null;
+
+const refreshAccessToken = async (): Promise<string | null> => {
+  const refreshToken = localStorage.getItem("refresh_token");
+  if (!refreshToken) return null;
+  try {
+    const res = await axios.post(`${api.defaults.baseURL}/api/auth/refresh`, {
+      refresh_token: refreshToken,
+    });
+    localStorage.setItem("token", res.data.token);
+    localStorage.setItem("refresh_token", res.data.refresh_token);
+    return res.data.token as string;
+  } catch {
+    localStorage.removeItem("token");
+    localStorage.removeItem("refresh_token");
+    return null;
+  }
+};
+
+api.interceptors.response.use(
+  (res) => res,
+  async (error) => {
+    const original = error.config;
+    if (error.response?.status !== 401 || !original || original._retry) {
+      return Promise.reject(error);
+    }
+    original._retry = true;
+    refreshing = refreshing || refreshAccessToken().finally(() => (refreshing = null));
+    const token = await refreshing;
+    if (!token) return Promise.reject(error);
+    original.headers.Authorization = `Bearer ${token}`;
+    return api(original);
+  }
+);
+
+// thu hồi refresh token phía server khi đăng xuất
+export const revokeSession = () => {
+  const refreshToken = localStorage.getItem("refresh_token");
+  localStorage.removeItem("refresh_token");
+  if (refreshToken) {
+    api.post("/api/auth/logout", { refresh_token: refreshToken }).catch(() => {});
+  }
+};
+
 export default api;
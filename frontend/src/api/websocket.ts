@@ -1,6 +1,8 @@
 export const connectWS = (role: string, customerId?: number): WebSocket => {
   const createWS = (): WebSocket => {
-    const url = `/api/ws?role=${role}${customerId ? `&customer_id=${customerId}` : ""}`;
+    // WebSocket không gửi được header Authorization, JWT đi qua query
+    const token = encodeURIComponent(localStorage.getItem("token") || "");
+    const url = `/api/ws?role=${role}&token=${token}${customerId ? `&customer_id=${customerId}` : ""}`;
     const ws = new WebSocket(`ws://localhost:8080${url}`);
 
     ws.onopen = () => console.log("WS open", role, customerId);
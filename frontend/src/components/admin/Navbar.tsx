@@ -11,14 +11,22 @@ import {
 import { Link, useNavigate } from "react-router-dom";
 import { FaBell, FaEnvelope, FaBars, FaTimes, FaCog, FaClipboardList, FaSun, FaSignOutAlt } from "react-icons/fa";
 import logo from "../../assets/logo.png";
-import api from "../../api/axios";
+import api, { revokeSession } from "../../api/axios";
 import "./Navbar.css";
 
 interface SearchResult {
   id: number;
   name: string;
-  type: "product" | "supplier" | "category" | "order" | "purchase";
+  type: "product" | "variant" | "supplier" | "category" | "order" | "purchase" | "user";
   slug?: string;
+  subtitle?: string;
+  data?: Record<string, any>;
+}
+
+interface SearchGroup {
+  type: string;
+  total: number;
+  items: SearchResult[];
 }
 
 const typeLabels: Record<string, string> = {
@@ -26,6 +34,8 @@ const typeLabels: Record<string, string> = {
   supplier: "Nhà cung cấp",
   category: "Loại",
   order: "Đơn hàng",
+  variant: "SKU",
+  user: "Người dùng",
   purchase: "Phiếu nhập",
 };
 
@@ -71,6 +81,7 @@ const Navbar: React.FC<NavbarProps> = ({ onSelectCustomer }) => {
   }, []);
 
   const handleLogout = () => {
+    revokeSession();
     localStorage.removeItem("token");
     localStorage.removeItem("userId");
     localStorage.removeItem("role");
@@ -132,7 +143,8 @@ const Navbar: React.FC<NavbarProps> = ({ onSelectCustomer }) => {
       setLoading(true);
       try {
         const res = await api.get(`/api/admin/search?q=${encodeURIComponent(value)}`);
-        setResults(res.data || []);
+        const groups: SearchGroup[] = res.data?.groups || [];
+        setResults(groups.flatMap((g) => g.items));
       } catch (err) {
         console.error("Search error:", err);
         setResults([]);
@@ -153,6 +165,12 @@ const Navbar: React.FC<NavbarProps> = ({ onSelectCustomer }) => {
       case "product":
         navigate(`/admin/products/${item.id}${slugPart}`);
         break;
+      case "variant":
+        navigate(`/admin/products/${item.data?.product_id}${slugPart}`);
+        break;
+      case "user":
+        navigate(`/admin/users`);
+        break;
       case "order":
         navigate(`/admin/orders/${item.id}`);
         break;
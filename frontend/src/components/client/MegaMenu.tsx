@@ -2,7 +2,7 @@ import React, { useEffect, useState } from "react";
 import { Navbar, Container, Nav, NavDropdown, Badge, Button, Spinner, Form, FormControl } from "react-bootstrap";
 import { useNavigate, Link } from "react-router-dom";
 import { useAppSelector } from "../../hooks/reduxHooks";
-import api from "../../api/axios";
+import api, { revokeSession } from "../../api/axios";
 import logoImg from "../../assets/logo2.png";
 import "bootstrap-icons/font/bootstrap-icons.css";
 import { FaShoppingCart, FaHistory, FaTachometerAlt, FaClipboardList, FaSignOutAlt, FaUserPlus, FaSignInAlt } from "react-icons/fa";
@@ -54,6 +54,7 @@ const MegaMenu: React.FC = () => {
   }, []);
 
   const handleLogout = () => {
+    revokeSession();
     localStorage.removeItem("token");
     localStorage.removeItem("userId");
     localStorage.removeItem("role");
@@ -9,7 +9,8 @@ import { FaLock } from "react-icons/fa";
 interface Category {
   id: number;
   name: string;
-  group_name: string;
+  parent_id?: number | null;
+  sort_order?: number;
   created_at?: string;
   slug:string;
 }
@@ -64,7 +65,7 @@ const CategoryPage: React.FC = () => {
   
   const handleAdd = async () => {
     try {
-      await api.post("/api/admin/categories", { name: activeCategory.name, group_name: activeCategory.group_name });
+      await api.post("/api/admin/categories", { name: activeCategory.name, parent_id: activeCategory.parent_id ?? null });
       toast.success("Category created");
       setShowAdd(false);
       setActiveCategory({});
@@ -78,7 +79,7 @@ const CategoryPage: React.FC = () => {
   const handleEdit = async () => {
     if (!activeCategory.id) return;
     try {
-      await api.put(`/api/admin/categories/${activeCategory.id}`, { name: activeCategory.name, group_name: activeCategory.group_name });
+      await api.put(`/api/admin/categories/${activeCategory.id}`, { name: activeCategory.name, parent_id: activeCategory.parent_id ?? null });
       toast.success("Category updated");
       setShowEdit(false);
       setActiveCategory({});
@@ -140,7 +141,7 @@ const CategoryPage: React.FC = () => {
                 <tr>
                   <th>ID</th>
                   <th>Name</th>
-                  <th>Group</th>
+                  <th>Parent</th>
                   <th>Created At</th>
                   <th>Slug</th>
                   <th>Actions</th>
@@ -154,7 +155,7 @@ const CategoryPage: React.FC = () => {
                     <tr key={c.id}>
                       <td>{c.id}</td>
                       <td><Link to={`/admin/categories/${c.id}-${c.slug}`}>{c.name}</Link></td>
-                      <td>{c.group_name}</td>
+                      <td>{categories.find(p => p.id === c.parent_id)?.name ?? "-"}</td>
                       <td>{c.created_at ? new Date(c.created_at).toLocaleString() : "-"}</td>
                       <td>{c.slug}</td>
                       <td className="d-flex gap-2">
@@ -225,16 +226,15 @@ const CategoryPage: React.FC = () => {
               />
             </Form.Group>
             <Form.Group className="mt-2">
-              <Form.Label>Group</Form.Label>
+              <Form.Label>Parent</Form.Label>
               <Form.Select
-                value={activeCategory.group_name ?? "Áo Nam"}
-                onChange={e => setActiveCategory({ ...activeCategory, group_name: e.target.value })}
+                value={activeCategory.parent_id ?? ""}
+                onChange={e => setActiveCategory({ ...activeCategory, parent_id: e.target.value ? Number(e.target.value) : null })}
               >
-                <option value="Áo Nam">Áo Nam</option>
-                <option value="Quần Nam">Quần Nam</option>
-                <option value="Đồ Thể Thao">Đồ Thể Thao</option>
-                <option value="Đồ Bộ">Đồ Bộ</option>
-                <option value="Phụ kiện">Phụ Kiện</option>
+                <option value="">(Danh mục gốc)</option>
+                {categories.filter(p => p.id !== activeCategory.id).map(p => (
+                  <option key={p.id} value={p.id}>{p.name}</option>
+                ))}
               </Form.Select>
             </Form.Group>
 
@@ -266,16 +266,15 @@ const CategoryPage: React.FC = () => {
               />
             </Form.Group>
             <Form.Group className="mt-2">
-              <Form.Label>Group</Form.Label>
+              <Form.Label>Parent</Form.Label>
               <Form.Select
-                value={activeCategory.group_name ?? "Đồ nam"}
-                onChange={e => setActiveCategory({ ...activeCategory, group_name: e.target.value })}
+                value={activeCategory.parent_id ?? ""}
+                onChange={e => setActiveCategory({ ...activeCategory, parent_id: e.target.value ? Number(e.target.value) : null })}
               >
-                <option value="Đồ nam">Đồ nam</option>
-                <option value="Đồ nữ">Đồ nữ</option>
-                <option value="Đồ thể thao">Đồ thể thao</option>
-                <option value="Trẻ em">Trẻ em</option>
-                <option value="Phụ kiện">Phụ kiện</option>
+                <option value="">(Danh mục gốc)</option>
+                {categories.filter(p => p.id !== activeCategory.id).map(p => (
+                  <option key={p.id} value={p.id}>{p.name}</option>
+                ))}
               </Form.Select>
             </Form.Group>
           </Form>
@@ -4,9 +4,20 @@ import { ToastContainer, toast } from "react-toastify";
 import "react-toastify/dist/ReactToastify.css";
 import "bootstrap/dist/css/bootstrap.min.css";
 
+// reason do backend gửi về khi xác nhận email thất bại
+const REASONS: Record<string, string> = {
+    invalid: "Link xác nhận không hợp lệ.",
+    expired: "Link xác nhận đã hết hạn, vui lòng đăng ký lại hoặc gửi lại email xác nhận.",
+    already_confirmed: "Tài khoản đã được kích hoạt trước đó, bạn có thể đăng nhập.",
+    taken: "Email hoặc tên đăng nhập đã được sử dụng.",
+    error: "Có lỗi xảy ra, vui lòng thử lại sau.",
+};
+
 function FailPage() {
     const [params] = useSearchParams();
-    const reason = params.get("reason") || "Không xác định";
+    const code = params.get("reason") || "";
+    const reason = REASONS[code] || code || "Không xác định";
+    const confirmed = code === "already_confirmed";
 
     useEffect(() => {
         toast.error(`Xác nhận thất bại: ${reason}`,);
@@ -17,9 +28,15 @@ function FailPage() {
             <div className="card shadow p-5 text-center" style={{ maxWidth: "500px", borderRadius: "15px" }}>
                 <h2 className="text-danger mb-3">❌ Xác nhận thất bại!</h2>
                 <p className="lead">{reason}</p>
-                <a href="/register" className="btn btn-outline-danger mt-3">
-                    Quay lại đăng ký
-                </a>
+                {confirmed ? (
+                    <a href="/login" className="btn btn-outline-success mt-3">
+                        Đăng nhập
+                    </a>
+                ) : (
+                    <a href="/register" className="btn btn-outline-danger mt-3">
+                        Quay lại đăng ký
+                    </a>
+                )}
             </div>
             <ToastContainer />
         </div>
@@ -1,4 +1,4 @@
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import { Link, useNavigate } from "react-router-dom";
 import { toast } from "react-toastify";
 import api from "../../api/axios";
@@ -30,40 +30,109 @@ function Login() {
     setForm({ ...form, [e.target.name]: e.target.value });
   };
 
+  // Bước 2 khi tài khoản bật/bắt buộc MFA
+  const [mfaToken, setMfaToken] = useState("");
+  const [enroll, setEnroll] = useState<{ secret: string; otpauth_url: string } | null>(null);
+  const [code, setCode] = useState("");
+
+  const finishLogin = (data: any) => {
+    const token = data.token;
+
+    if (!token) {
+      toast.error("❌ Không nhận được token!");
+      return;
+    }
+    localStorage.setItem("token", token);
+    if (data.refresh_token) {
+      localStorage.setItem("refresh_token", data.refresh_token);
+    }
+    if (data.recovery_codes) {
+      // chỉ hiện một lần, người dùng phải tự lưu lại
+      window.alert("Lưu lại các mã khôi phục sau:\n\n" + data.recovery_codes.join("\n"));
+    }
+    toast.success("Đăng nhập thành công!");
+    const decoded = decodeJWT(token);
+
+    if (decoded?.user_id) {
+      localStorage.setItem("userId", String(decoded.user_id));
+    }
+    if (decoded?.role) {
+      localStorage.setItem("role", String(decoded.role));
+    }
+    if (decoded?.username) {
+      localStorage.setItem("username", String(decoded.username));
+    }
+
+    const role = decoded?.role || "";
+
+    if (role === "admin" || role === "staff") {
+      navigate("/admin/dashboard");
+    } else if (role === "customer") {
+      navigate("/");
+    } else {
+      navigate("/login");
+    }
+  };
+
+  // Đăng nhập ngoài (OIDC): backend redirect về /login#token=... hoặc #mfa_token=... hoặc #oauth_error=...
+  const [providers, setProviders] = useState<{ name: string; display_name: string }[]>([]);
+
+  useEffect(() => {
+    api.get("/api/auth/oidc/providers").then((res) => setProviders(res.data || [])).catch(() => {});
+
+    // link xác nhận đổi email redirect về /login?email_change=ok|invalid|expired|taken
+    const emailChange = new URLSearchParams(window.location.search).get("email_change");
+    if (emailChange === "ok") {
+      toast.success("Đổi email thành công, vui lòng đăng nhập bằng email mới!");
+    } else if (emailChange) {
+      const reasons: Record<string, string> = {
+        invalid: "Link đổi email không hợp lệ hoặc đã được sử dụng!",
+        expired: "Link đổi email đã hết hạn!",
+        taken: "Email mới đã được tài khoản khác sử dụng!",
+      };
+      toast.error(reasons[emailChange] || "❌ Đổi email thất bại!");
+    }
+
+    const hash = new URLSearchParams(window.location.hash.slice(1));
+    if (!window.location.hash) return;
+    window.history.replaceState(null, "", window.location.pathname);
+
+    if (hash.get("oauth_error")) {
+      const reasons: Record<string, string> = {
+        email_unverified: "Email của tài khoản này chưa được xác minh!",
+        email_missing: "Không lấy được email từ tài khoản này!",
+        locked: "Tài khoản đang tạm khoá, vui lòng thử lại sau!",
+        cancelled: "Bạn đã huỷ đăng nhập.",
+        email_taken: "Email này thuộc về một tài khoản đã bị khoá, vui lòng liên hệ hỗ trợ!",
+      };
+      toast.error(reasons[hash.get("oauth_error")!] || "❌ Đăng nhập thất bại, vui lòng thử lại!");
+    } else if (hash.get("mfa_token")) {
+      const token = hash.get("mfa_token")!;
+      setMfaToken(token);
+      if (hash.get("mfa_enrollment_required") === "true") {
+        api.post("/api/auth/mfa/setup", { mfa_token: token }).then((res) => setEnroll(res.data));
+      }
+    } else if (hash.get("token")) {
+      finishLogin({ token: hash.get("token"), refresh_token: hash.get("refresh_token") });
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     setLoading(true);
     try {
       const res = await api.post("/api/auth/login", form);
-      const token = res.data.token;
 
-      if (!token) {
-        toast.error("❌ Không nhận được token!");
+      if (res.data.mfa_required) {
+        setMfaToken(res.data.mfa_token);
+        if (res.data.mfa_enrollment_required) {
+          const setup = await api.post("/api/auth/mfa/setup", { mfa_token: res.data.mfa_token });
+          setEnroll(setup.data);
+        }
         return;
       }
-      localStorage.setItem("token", token);
-      toast.success("Đăng nhập thành công!");
-      const decoded = decodeJWT(token);
-
-      if (decoded?.user_id) {
-        localStorage.setItem("userId", String(decoded.user_id));
-      }
-      if (decoded?.role) {
-        localStorage.setItem("role", String(decoded.role));
-      }
-      if (decoded?.username) {
-        localStorage.setItem("username", String(decoded.username));
-      }
-
-      const role = decoded?.role || "";
-
-      if (role === "admin" || role === "staff") {
-        navigate("/admin/dashboard");
-      } else if (role === "customer") {
-        navigate("/");
-      } else {
-        navigate("/login");
-      }
+      finishLogin(res.data);
     } catch (err: any) {
       toast.error(err.response?.data || "❌ Sai email hoặc mật khẩu!");
     } finally {
@@ -71,6 +140,74 @@ function Login() {
     }
   };
 
+  const handleMfaSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setLoading(true);
+    try {
+      const url = enroll ? "/api/auth/mfa/activate" : "/api/auth/mfa/verify";
+      const res = await api.post(url, { mfa_token: mfaToken, code });
+      finishLogin(res.data);
+    } catch (err: any) {
+      toast.error(err.response?.data || "❌ Mã xác thực không đúng!");
+      if (err.response?.status === 401) {
+        // challenge hết hạn → đăng nhập lại từ đầu
+        setMfaToken("");
+        setEnroll(null);
+      }
+    } finally {
+      setLoading(false);
+      setCode("");
+    }
+  };
+
+  if (mfaToken) {
+    return (
+      <div className="d-flex justify-content-center align-items-center vh-100 bg-gradient">
+        <div
+          className="card shadow-lg p-5"
+          style={{
+            width: "500px",
+            borderRadius: "20px",
+            background: "linear-gradient(135deg, #fdfbfb 0%, #ebedee 100%)",
+          }}>
+          <h2 className="text-center mb-4 fw-bold text-primary fs-4">
+            Xác thực hai bước
+          </h2>
+          {enroll && (
+            <div className="mb-4">
+              <p>
+                Tài khoản của bạn bắt buộc bật xác thực hai bước. Thêm khoá sau vào ứng dụng
+                Authenticator (hoặc mở <a href={enroll.otpauth_url}>liên kết này</a> trên điện thoại):
+              </p>
+              <code className="d-block text-center fs-5 user-select-all">{enroll.secret}</code>
+            </div>
+          )}
+          <form onSubmit={handleMfaSubmit} className="fs-5">
+            <div className="mb-4">
+              <label className="form-label fw-semibold">
+                {enroll ? "Mã 6 số từ ứng dụng" : "Mã 6 số hoặc mã khôi phục"}
+              </label>
+              <input
+                type="text"
+                name="code"
+                className="form-control form-control-lg"
+                autoComplete="one-time-code"
+                value={code}
+                onChange={(e) => setCode(e.target.value)}
+                required
+              />
+            </div>
+            <button
+              type="submit"
+              className="btn btn-success btn-lg w-100 shadow-sm mt-2 fs-5"
+              disabled={loading}>
+              {loading ? "Đang xử lý..." : "Xác nhận"}
+            </button>
+          </form>
+        </div>
+      </div>
+    );
+  }
 
   return (
     <div className="d-flex justify-content-center align-items-center vh-100 bg-gradient">
@@ -123,6 +260,19 @@ function Login() {
             {loading ? "Đang xử lý..." : "Đăng nhập"}
           </button>
         </form>
+        {providers.length > 0 && (
+          <div className="mt-4">
+            <div className="text-center text-muted mb-2">hoặc</div>
+            {providers.map((p) => (
+              <a
+                key={p.name}
+                href={`${api.defaults.baseURL}/api/auth/oidc/${p.name}/login`}
+                className="btn btn-outline-secondary btn-lg w-100 mb-2 fs-5">
+                Đăng nhập bằng {p.display_name}
+              </a>
+            ))}
+          </div>
+        )}
         <div className="text-center mt-4">
           <small className="fs-5">
             Chưa có tài khoản?{" "}
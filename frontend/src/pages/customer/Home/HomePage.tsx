@@ -36,7 +36,7 @@ const HomePage: React.FC = () => {
         const latestRes = await api.get("/api/customer/products-latest", { params: { limit: 4 } });
         setLatest(Array.isArray(latestRes.data) ? latestRes.data : []);
 
-        const vestRes = await api.get("/api/customer/products-random", { params: { group: "vest" } });
+        const vestRes = await api.get("/api/customer/products-random", { params: { group: "bo-vest" } });
         setDoBo(Array.isArray(vestRes.data) ? vestRes.data : []);
 
         const pkRes = await api.get("/api/customer/products-random", { params: { group: "phu-kien" } });
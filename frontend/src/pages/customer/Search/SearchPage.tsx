@@ -36,9 +36,9 @@ const SearchPage: React.FC = () => {
             setLoading(true);
             try {
                 const res = await api.get("/api/customer/search", {
-                    params: { q: query },
+                    params: { q: query, limit: 100 },
                 });
-                setProducts(res.data || []);
+                setProducts(res.data?.products || []);
                 setCurrentPage(1);
             } catch (err) {
                 console.error("Search error:", err);
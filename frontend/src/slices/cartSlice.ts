@@ -29,8 +29,7 @@ const initialState: CartState = {
 };
 
 export const fetchCart = createAsyncThunk("cart/fetchCart", async () => {
-  const userId = localStorage.getItem("userId");
-  const res = await api.get(`/api/customer/cart/${userId}`);
+  const res = await api.get("/api/customer/me/cart");
   return res.data as CartItem[];
 });
 
@@ -38,7 +37,7 @@ export const fetchCart = createAsyncThunk("cart/fetchCart", async () => {
 export const addToCart = createAsyncThunk(
   "cart/addToCart",
   async (item: CartItem) => {
-    const res = await api.post("/api/customer/cart/add", item);
+    const res = await api.post("/api/customer/me/cart", item);
     return res.data as CartItem;
   }
 );
@@ -46,8 +45,7 @@ export const addToCart = createAsyncThunk(
 export const removeFromCart = createAsyncThunk(
   "cart/removeFromCart",
   async (payload: { variantId: number }) => {
-    const userId = localStorage.getItem("userId");
-    await api.delete(`/api/customer/cart/remove/${userId}/${payload.variantId}`);
+    await api.delete(`/api/customer/me/cart/${payload.variantId}`);
     return payload;
   }
 );
@@ -55,9 +53,7 @@ export const removeFromCart = createAsyncThunk(
 export const updateCartItem = createAsyncThunk(
   "cart/updateCartItem",
   async (payload: { variantId: number; quantity: number }) => {
-    const userId = Number(localStorage.getItem("userId"));
-    await api.put("/api/customer/cart/update", {
-      user_id: userId,
+    await api.put("/api/customer/me/cart", {
       variantId: payload.variantId,
       quantity: payload.quantity,
     });